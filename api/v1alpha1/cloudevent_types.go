@@ -0,0 +1,50 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Types of the CloudEvents the operator emits about ZapScans. They carry the
+// API version, so a breaking change to ScanEventData comes with new types.
+const (
+	// CloudEventJobCreated is emitted when the scan Job was created.
+	CloudEventJobCreated = "com.spaceship.zap.job.created.v1alpha1"
+
+	// CloudEventJobRunning is emitted once the scan Job's pod is running.
+	CloudEventJobRunning = "com.spaceship.zap.job.running.v1alpha1"
+
+	// CloudEventScanFinished is emitted when the scan succeeded or failed.
+	CloudEventScanFinished = "com.spaceship.zap.scan.finished.v1alpha1"
+
+	// CloudEventHighRiskAlerts is emitted after CloudEventScanFinished when
+	// the scan found high risk alerts.
+	CloudEventHighRiskAlerts = "com.spaceship.zap.alerts.highrisk.v1alpha1"
+)
+
+// ScanEventData is the JSON data of every CloudEvent the operator emits. The
+// event's source is /apis/spaceship.com/v1alpha1/namespaces/<namespace>/zapscans
+// and its subject is the scan name. Fields are only added to it, never
+// renamed or removed, within v1alpha1.
+// +kubebuilder:object:generate=false
+type ScanEventData struct {
+	Namespace     string            `json:"namespace"`
+	Scan          string            `json:"scan"`
+	UID           string            `json:"uid"`
+	ScheduledScan string            `json:"scheduledScan,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Target        string            `json:"target"`
+	JobName       string            `json:"jobName,omitempty"`
+	JobNamespace  string            `json:"jobNamespace,omitempty"`
+	Phase         string            `json:"phase"`
+	Outcome       string            `json:"outcome,omitempty"`
+	StartedAt     *metav1.Time      `json:"startedAt,omitempty"`
+	FinishedAt    *metav1.Time      `json:"finishedAt,omitempty"`
+	AlertsFound   int64             `json:"alertsFound"`
+	AlertsByRisk  *RiskCounts       `json:"alertsByRisk,omitempty"`
+
+	// Alerts are the high risk alerts among the scan's top alerts. Only
+	// set for CloudEventHighRiskAlerts.
+	Alerts []AlertSummary `json:"alerts,omitempty"`
+
+	LastError string `json:"lastError,omitempty"`
+}
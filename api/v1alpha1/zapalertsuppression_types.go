@@ -0,0 +1,88 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ZapAlertSuppressionSpec marks alerts as false positives or accepted risks.
+// Every set field must match for an alert instance to be suppressed.
+type ZapAlertSuppressionSpec struct {
+	// PluginID is the ID of the ZAP rule whose alerts are suppressed.
+	// +kubebuilder:validation:MinLength=1
+	PluginID string `json:"pluginId"`
+
+	// URLPattern is a regular expression matched against the alert instance URL.
+	// If empty, instances on every URL are suppressed.
+	// +optional
+	URLPattern string `json:"urlPattern,omitempty"`
+
+	// Param is the parameter the alert was raised for.
+	// If empty, instances for every parameter are suppressed.
+	// +optional
+	Param string `json:"param,omitempty"`
+
+	// Target limits the suppression to scans of this target.
+	// If empty, it applies to every scan in the namespace.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// Reason explains why the alert is a false positive or an accepted risk.
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+
+	// Owner is the person or team accountable for the suppression.
+	// +kubebuilder:validation:MinLength=1
+	Owner string `json:"owner"`
+
+	// Expires is when the suppression stops applying and the alerts count again.
+	Expires metav1.Time `json:"expires"`
+}
+
+// ZapAlertSuppressionStatus is the observed state of a ZapAlertSuppression.
+type ZapAlertSuppressionStatus struct {
+	// Conditions represent the latest available observations of the suppression.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ConditionSuppressionActive reports whether a suppression is applied to scans.
+	ConditionSuppressionActive = "Active"
+
+	// ReasonSuppressionActive is set while the suppression hasn't expired.
+	ReasonSuppressionActive = "Active"
+	// ReasonSuppressionExpired is set once the suppression's expiry date passed.
+	ReasonSuppressionExpired = "Expired"
+	// ReasonSuppressionInvalid is set when the URL pattern isn't a valid regular expression.
+	ReasonSuppressionInvalid = "InvalidURLPattern"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=zapsuppress
+// +kubebuilder:printcolumn:name="Plugin",type=string,JSONPath=`.spec.pluginId`
+// +kubebuilder:printcolumn:name="Owner",type=string,JSONPath=`.spec.owner`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.spec.expires`
+// +kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.conditions[?(@.type=="Active")].status`
+
+type ZapAlertSuppression struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZapAlertSuppressionSpec   `json:"spec,omitempty"`
+	Status ZapAlertSuppressionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type ZapAlertSuppressionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZapAlertSuppression `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZapAlertSuppression{}, &ZapAlertSuppressionList{})
+}
@@ -0,0 +1,124 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ZapNotificationPolicySpec routes the results of ZapScans across namespaces
+// to ZapNotifiers. A scan must match every set field to be routed.
+type ZapNotificationPolicySpec struct {
+	// Namespaces lists the namespaces whose scans are routed.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// NamespaceSelector selects the namespaces whose scans are routed by
+	// their labels. If neither Namespaces nor NamespaceSelector is set,
+	// scans in every namespace are routed.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Selector selects the routed scans by their labels.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Phases are the final phases of the routed scans. Defaults to both.
+	// +optional
+	Phases []PolicyPhase `json:"phases,omitempty"`
+
+	// MinRisk is the lowest risk a succeeded scan must have found an alert of.
+	// +kubebuilder:validation:Enum=informational;low;medium;high
+	// +optional
+	MinRisk string `json:"minRisk,omitempty"`
+
+	// NewFindingsOnly only routes succeeded scans with findings the previous
+	// run of their ZapScheduledScan didn't have, of MinRisk or higher if set.
+	// Scans without a previous run count all their findings as new.
+	// +optional
+	NewFindingsOnly bool `json:"newFindingsOnly,omitempty"`
+
+	// Notifiers are the ZapNotifiers the routed scans are sent to. Their own
+	// selector and minRisk don't apply, and digest notifiers are skipped.
+	// +kubebuilder:validation:MinItems=1
+	Notifiers []NotifierReference `json:"notifiers"`
+
+	// RateLimit caps how many scans are sent to each notifier.
+	// +optional
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+}
+
+// PolicyPhase is a final ZapScan phase.
+// +kubebuilder:validation:Enum=Succeeded;Failed
+type PolicyPhase string
+
+// NotifierReference names a ZapNotifier.
+type NotifierReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// RateLimit allows at most Max notifications per Period. Scans beyond it
+// aren't sent and are recorded as RateLimited.
+type RateLimit struct {
+	// +kubebuilder:validation:Minimum=1
+	Max int32 `json:"max"`
+
+	// Period is at least a minute; a zero period would never limit.
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1m')",message="period must be at least 1m"
+	Period metav1.Duration `json:"period"`
+}
+
+// ZapNotificationPolicyStatus is the observed state of a ZapNotificationPolicy.
+type ZapNotificationPolicyStatus struct {
+	// RateLimits counts the notifications sent to each notifier in the
+	// current rate limit period.
+	// +optional
+	// +listType=map
+	// +listMapKey=notifier
+	RateLimits []RateLimitStatus `json:"rateLimits,omitempty"`
+}
+
+// RateLimitStatus counts the notifications sent to one notifier.
+type RateLimitStatus struct {
+	// Notifier is the notifier's namespace/name.
+	Notifier string `json:"notifier"`
+
+	// PeriodStart is when the current period began.
+	PeriodStart metav1.Time `json:"periodStart"`
+
+	// Sent is the number of scans sent in the current period.
+	Sent int32 `json:"sent"`
+
+	// Dropped is the number of scans not sent in the current period.
+	// +optional
+	Dropped int32 `json:"dropped,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=zapnotifypolicy
+// +kubebuilder:printcolumn:name="Min Risk",type=string,JSONPath=`.spec.minRisk`
+// +kubebuilder:printcolumn:name="New Only",type=boolean,JSONPath=`.spec.newFindingsOnly`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+type ZapNotificationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZapNotificationPolicySpec   `json:"spec,omitempty"`
+	Status ZapNotificationPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type ZapNotificationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZapNotificationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZapNotificationPolicy{}, &ZapNotificationPolicyList{})
+}
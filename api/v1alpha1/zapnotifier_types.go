@@ -0,0 +1,136 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ZapNotifierSpec configures a destination told about every finished ZapScan
+// in its namespace. Exactly one backend must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.slack) != has(self.smtp)",message="exactly one of slack or smtp must be set"
+type ZapNotifierSpec struct {
+	// Selector limits the notifier to ZapScans with matching labels.
+	// If unset, every scan in the namespace is notified.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// MinRisk is the lowest risk a scan must have found an alert of to be
+	// notified. Failed scans are always notified. If empty, every finished
+	// scan is notified.
+	// +kubebuilder:validation:Enum=informational;low;medium;high
+	// +optional
+	MinRisk string `json:"minRisk,omitempty"`
+
+	// ViewerURL is the external URL of the operator's report viewer. Reports
+	// kept in ConfigMap or Secret storage are linked through it; reports
+	// exported to object storage are linked directly.
+	// +optional
+	ViewerURL string `json:"viewerURL,omitempty"`
+
+	// Slack posts a message to a Slack-compatible incoming webhook.
+	// +optional
+	Slack *SlackNotifier `json:"slack,omitempty"`
+
+	// SMTP mails the results through an SMTP server.
+	// +optional
+	SMTP *SMTPNotifier `json:"smtp,omitempty"`
+}
+
+// SlackNotifier posts a summary of the scan to a Slack incoming webhook.
+type SlackNotifier struct {
+	// WebhookSecret is the name of a Secret in the notifier's namespace whose
+	// webhookURL key holds the incoming webhook URL.
+	// +kubebuilder:validation:MinLength=1
+	WebhookSecret string `json:"webhookSecret"`
+
+	// Channel overrides the webhook's default channel.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// Username overrides the webhook's default username.
+	// +optional
+	Username string `json:"username,omitempty"`
+}
+
+// SMTPNotifier mails scan results with the HTML report attached, either per
+// scan or as a periodic digest of every scan in the namespace.
+type SMTPNotifier struct {
+	// Host is the SMTP server's hostname.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// Port of the SMTP server. Defaults to 587.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// StartTLS upgrades the connection with STARTTLS before authenticating
+	// and fails the delivery if the server doesn't support it.
+	// +optional
+	StartTLS bool `json:"startTLS,omitempty"`
+
+	// CredentialsSecret is the name of a Secret in the notifier's namespace
+	// whose username and password keys are used for PLAIN authentication.
+	// Credentials are only sent over TLS or to localhost.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+
+	// From is the sender address.
+	// +kubebuilder:validation:MinLength=1
+	From string `json:"from"`
+
+	// To are the recipient addresses.
+	// +kubebuilder:validation:MinItems=1
+	To []string `json:"to"`
+
+	// DigestInterval sends one mail per interval listing every selected
+	// scan that finished in it, instead of one mail per scan.
+	// +optional
+	DigestInterval *metav1.Duration `json:"digestInterval,omitempty"`
+}
+
+// ZapNotifierStatus is the observed state of a ZapNotifier.
+type ZapNotifierStatus struct {
+	// LastDigestTime is when the last digest was sent. The next one is due
+	// a digest interval later.
+	// +optional
+	LastDigestTime *metav1.Time `json:"lastDigestTime,omitempty"`
+
+	// DigestedScans are the UIDs of the finished scans in the namespace that
+	// digests already covered. Every other scan finished since the notifier
+	// was created is included in the next digest, also one whose status was
+	// saved only after the last digest was sent.
+	// +optional
+	DigestedScans []string `json:"digestedScans,omitempty"`
+
+	// LastError is the error of the last failed digest.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=zapnotifier
+// +kubebuilder:printcolumn:name="Min Risk",type=string,JSONPath=`.spec.minRisk`
+// +kubebuilder:printcolumn:name="Last Digest",type=date,JSONPath=`.status.lastDigestTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+type ZapNotifier struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZapNotifierSpec   `json:"spec,omitempty"`
+	Status ZapNotifierStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type ZapNotifierList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZapNotifier `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZapNotifier{}, &ZapNotifierList{})
+}
@@ -33,6 +33,296 @@ type ZapScanSpec struct {
 	// Cleanup controls whether completed Jobs should be deleted.
 	// +optional
 	Cleanup *bool `json:"cleanup,omitempty"`
+
+	// ReportStorage keeps the full scan reports after the scan finishes.
+	// +optional
+	ReportStorage *ReportStorage `json:"reportStorage,omitempty"`
+
+	// ObjectStorage uploads the scan reports to an S3-compatible bucket.
+	// Overrides the operator-wide object storage configuration.
+	// +optional
+	ObjectStorage *ObjectStorage `json:"objectStorage,omitempty"`
+
+	// JUnit configures the JUnit XML report generated for CI pipelines.
+	// +optional
+	JUnit *JUnitOptions `json:"junit,omitempty"`
+
+	// Gate sets the thresholds that decide the scan's outcome.
+	// +optional
+	Gate *Gate `json:"gate,omitempty"`
+
+	// KeepSession saves ZAP's session so findings can be investigated in the
+	// ZAP desktop UI. The session is copied to the PersistentVolumeClaim of
+	// ReportStorage, or uploaded to object storage otherwise, and is deleted
+	// along with the reports once ReportStorage's TTL has passed, or kept
+	// until deleted by hand if there is no TTL. Unlike the reports, the
+	// session isn't redacted.
+	// +optional
+	KeepSession bool `json:"keepSession,omitempty"`
+
+	// Notifications configures who is told about the scan once it finished.
+	// +optional
+	Notifications *Notifications `json:"notifications,omitempty"`
+
+	// DefectDojo imports the scan's report into DefectDojo once it finished.
+	// +optional
+	DefectDojo *DefectDojo `json:"defectDojo,omitempty"`
+
+	// IssueTracker opens an issue for each of the scan's findings in a
+	// Jira-compatible issue tracker.
+	// +optional
+	IssueTracker *IssueTracker `json:"issueTracker,omitempty"`
+}
+
+// IssueTracker tracks a target's findings as issues. Each finding gets one
+// issue, which is commented on when a later scan finds it again and
+// transitioned once a later scan doesn't.
+type IssueTracker struct {
+	// URL of the Jira instance, e.g. https://example.atlassian.net.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// CredentialsSecret is the name of a Secret in the scan's namespace. Its
+	// token key holds an API token, sent with the username key as basic auth
+	// if that is set, or as a bearer token otherwise. The Secret must allow
+	// URL in its spaceship.com/allowed-urls annotation.
+	// +kubebuilder:validation:MinLength=1
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// Project is the key of the project issues are created in.
+	// +kubebuilder:validation:MinLength=1
+	Project string `json:"project"`
+
+	// IssueType of created issues. Defaults to Bug.
+	// +optional
+	IssueType string `json:"issueType,omitempty"`
+
+	// MinRisk is the lowest risk a finding needs to get an issue. Defaults to medium.
+	// +kubebuilder:validation:Enum=informational;low;medium;high
+	// +optional
+	MinRisk string `json:"minRisk,omitempty"`
+
+	// TitleTemplate is a Go template rendered with the scan and the finding,
+	// e.g. "{{.Finding.Name}} on {{.Finding.URL}}".
+	// +optional
+	TitleTemplate string `json:"titleTemplate,omitempty"`
+
+	// BodyTemplate is a Go template for the issue description, rendered
+	// with the same data as TitleTemplate.
+	// +optional
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+
+	// Labels are added to created issues.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+
+	// ResolveTransition is the transition, or the status it leads to, applied
+	// to issues whose finding is gone. Defaults to Done.
+	// +optional
+	ResolveTransition string `json:"resolveTransition,omitempty"`
+
+	// ReopenTransition is the transition, or the status it leads to, applied
+	// to a resolved issue whose finding is found again. Such issues are
+	// commented on either way; if empty, they aren't transitioned.
+	// +optional
+	ReopenTransition string `json:"reopenTransition,omitempty"`
+}
+
+// DefectDojo import modes.
+const (
+	// DefectDojoImport creates a new test for every scan.
+	DefectDojoImport = "Import"
+
+	// DefectDojoReimport updates the test with the same title, so findings
+	// a later scan no longer reports can be closed.
+	DefectDojoReimport = "Reimport"
+)
+
+// DefectDojo maps a scan to a DefectDojo product and engagement its report
+// is imported into.
+type DefectDojo struct {
+	// URL of the DefectDojo instance, e.g. https://defectdojo.example.com.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// APIKeySecret is the name of a Secret in the scan's namespace whose
+	// apiKey key holds a DefectDojo API v2 key. The Secret must allow URL in
+	// its spaceship.com/allowed-urls annotation.
+	// +kubebuilder:validation:MinLength=1
+	APIKeySecret string `json:"apiKeySecret"`
+
+	// +kubebuilder:validation:MinLength=1
+	ProductName string `json:"productName"`
+
+	// +kubebuilder:validation:MinLength=1
+	EngagementName string `json:"engagementName"`
+
+	// ProductTypeName is the type of the product AutoCreateContext creates.
+	// +optional
+	ProductTypeName string `json:"productTypeName,omitempty"`
+
+	// AutoCreateContext lets DefectDojo create the product, engagement and
+	// test if they don't exist yet.
+	// +optional
+	AutoCreateContext bool `json:"autoCreateContext,omitempty"`
+
+	// TestTitle identifies the test reports are reimported into. Defaults to
+	// the name of the scan's ZapScheduledScan, or the scan's own name.
+	// +optional
+	TestTitle string `json:"testTitle,omitempty"`
+
+	// Mode is Import or Reimport. Defaults to Reimport.
+	// +kubebuilder:validation:Enum=Import;Reimport
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// CloseOldFindings closes findings the imported report doesn't contain
+	// anymore. Defaults to true.
+	// +optional
+	CloseOldFindings *bool `json:"closeOldFindings,omitempty"`
+
+	// MinimumSeverity drops findings of a lower severity on import.
+	// +kubebuilder:validation:Enum=Info;Low;Medium;High;Critical
+	// +optional
+	MinimumSeverity string `json:"minimumSeverity,omitempty"`
+}
+
+// Notifications lists the destinations notified when a scan finishes.
+type Notifications struct {
+	// Webhooks are HTTP endpoints the scan's results are posted to.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Webhooks []WebhookNotification `json:"webhooks,omitempty"`
+}
+
+// WebhookNotification posts a scan's results to an HTTP endpoint.
+type WebhookNotification struct {
+	// Name identifies the webhook in status.notifications.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// URL the results are posted to.
+	URL string `json:"url"`
+
+	// BodyTemplate is a Go template rendered with the scan event, e.g.
+	// {"text": "{{.Scan}} found {{.AlertsFound}} alerts"}. The json function
+	// renders a value as JSON. Defaults to the whole event as JSON.
+	// +optional
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+
+	// ContentType of the rendered body. Defaults to application/json.
+	// +optional
+	ContentType string `json:"contentType,omitempty"`
+
+	// Headers are added to every request.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// SigningSecret is the name of a Secret in the scan's namespace whose
+	// signingKey key signs every request with HMAC-SHA256.
+	// +optional
+	SigningSecret string `json:"signingSecret,omitempty"`
+}
+
+// JUnitOptions configures the generated JUnit XML report.
+type JUnitOptions struct {
+	// FailOnRisk is the lowest risk that fails a plugin's test case. Defaults to medium.
+	// +kubebuilder:validation:Enum=informational;low;medium;high
+	// +optional
+	FailOnRisk string `json:"failOnRisk,omitempty"`
+}
+
+// Gate configures the quality gate evaluated once a scan's report is parsed.
+// The gate fails when any limit is exceeded; unset limits allow any number of alerts.
+type Gate struct {
+	// MaxAlerts is the maximum number of alerts allowed per risk level.
+	// +optional
+	MaxAlerts *RiskLimits `json:"maxAlerts,omitempty"`
+
+	// MaxAlertsPerPlugin is the maximum number of alerts allowed per ZAP plugin ID.
+	// +optional
+	MaxAlertsPerPlugin map[string]int64 `json:"maxAlertsPerPlugin,omitempty"`
+
+	// MinConfidence ignores alerts with a lower confidence. Defaults to counting every alert.
+	// +kubebuilder:validation:Enum=falsepositive;low;medium;high;confirmed
+	// +optional
+	MinConfidence string `json:"minConfidence,omitempty"`
+}
+
+// RiskLimits caps the number of alerts per risk level.
+type RiskLimits struct {
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	High *int64 `json:"high,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Medium *int64 `json:"medium,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Low *int64 `json:"low,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Informational *int64 `json:"informational,omitempty"`
+}
+
+// Scan outcomes decided by the quality gate.
+const (
+	OutcomePassed  = "Passed"
+	OutcomeBlocked = "Blocked"
+)
+
+// Report storage types.
+const (
+	ReportStorageConfigMap = "ConfigMap"
+	ReportStorageSecret    = "Secret"
+	ReportStoragePVC       = "PersistentVolumeClaim"
+)
+
+// ReportStorage configures where full scan reports are stored.
+type ReportStorage struct {
+	// Type is where reports are stored: ConfigMap, Secret or PersistentVolumeClaim.
+	// ConfigMaps and Secrets hold a gzipped tar of all reports, split into chunks.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret;PersistentVolumeClaim
+	Type string `json:"type"`
+
+	// ClaimName is the PersistentVolumeClaim, in the Job's namespace, reports are copied to.
+	// Required when Type is PersistentVolumeClaim.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// TTL is how long reports are kept after the scan finishes.
+	// If unset, reports are kept as long as the ZapScan exists.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// ObjectStorage configures export of scan reports to an S3-compatible bucket.
+type ObjectStorage struct {
+	// Endpoint is the base URL of the S3 API, e.g. https://s3.eu-west-1.amazonaws.com
+	// or http://minio.minio.svc:9000.
+	Endpoint string `json:"endpoint"`
+
+	// Region used to sign requests. Defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Bucket the reports are uploaded to.
+	Bucket string `json:"bucket"`
+
+	// Prefix is a Go template for the key prefix of the uploaded objects.
+	// Available fields are .Namespace, .Name, .Target, .JobName and .Date (YYYY-MM-DD).
+	// Defaults to "{{.Namespace}}/{{.Name}}/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecret is the name of a Secret holding accessKeyID and
+	// secretAccessKey keys, and optionally sessionToken.
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key>, as MinIO expects.
+	// +optional
+	PathStyle bool `json:"pathStyle,omitempty"`
 }
 
 // ZapScanStatus defines the observed state of ZapScan.
@@ -57,14 +347,415 @@ type ZapScanStatus struct {
 	// +optional
 	AlertsFound int64 `json:"alertsFound,omitempty"`
 
+	// AlertsByRisk counts the alerts per risk level.
+	// +optional
+	AlertsByRisk *RiskCounts `json:"alertsByRisk,omitempty"`
+
+	// AlertsByConfidence counts the alerts per confidence level.
+	// +optional
+	AlertsByConfidence *ConfidenceCounts `json:"alertsByConfidence,omitempty"`
+
+	// TopAlerts are the most severe alerts, highest risk and instance count first.
+	// +optional
+	TopAlerts []AlertSummary `json:"topAlerts,omitempty"`
+
 	// LastError is a human-readable error if any.
 	// +optional
 	LastError string `json:"lastError,omitempty"`
+
+	// ReportRef locates the stored reports, if ReportStorage is configured.
+	// +optional
+	ReportRef *ReportRef `json:"reportRef,omitempty"`
+
+	// ReportURLs are the URLs of the reports uploaded to object storage.
+	// +optional
+	ReportURLs []string `json:"reportURLs,omitempty"`
+
+	// AlertsSuppressed is the number of alert instances suppressed by ZapAlertSuppressions.
+	// Suppressed alerts are not included in the other counts.
+	// +optional
+	AlertsSuppressed int64 `json:"alertsSuppressed,omitempty"`
+
+	// SuppressedAlerts lists the suppressed alerts, most severe first.
+	// +optional
+	SuppressedAlerts []SuppressedAlert `json:"suppressedAlerts,omitempty"`
+
+	// Outcome is Passed or Blocked once the quality gate was evaluated.
+	// Phase only tells whether the scan itself ran.
+	// +optional
+	Outcome string `json:"outcome,omitempty"`
+
+	// Diff compares the findings with the previous completed run of the same
+	// ZapScheduledScan. Only set for scheduled runs that have a previous run.
+	// +optional
+	Diff *FindingDiff `json:"diff,omitempty"`
+
+	// Notifications records the delivery of every configured notification.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +listMapKey=name
+	Notifications []NotificationStatus `json:"notifications,omitempty"`
+
+	// SessionRef locates the saved ZAP session, if KeepSession is set.
+	// +optional
+	SessionRef *SessionRef `json:"sessionRef,omitempty"`
+
+	// Conditions represent the latest available observations of the scan.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// ConditionReportParsed reports whether the scan report could be parsed.
+	ConditionReportParsed = "ReportParsed"
+
+	// ReasonReportParsed is set when the report was parsed successfully.
+	ReasonReportParsed = "Parsed"
+	// ReasonReportTooLarge is set when the report exceeds the operator's size limit.
+	ReasonReportTooLarge = "ReportTooLarge"
+	// ReasonReportParseFailed is set when the report could not be read or parsed.
+	ReasonReportParseFailed = "ParseFailed"
+	// ReasonReportNotFound is set when the scan left no report, e.g. because
+	// ZAP crashed or the pod is gone.
+	ReasonReportNotFound = "NotFound"
+
+	// ConditionReportStored reports whether the scan reports were stored.
+	ConditionReportStored = "ReportStored"
+
+	// ReasonReportStored is set when the reports were written to storage.
+	ReasonReportStored = "Stored"
+	// ReasonReportStoreFailed is set when writing the reports failed.
+	ReasonReportStoreFailed = "StoreFailed"
+	// ReasonReportExpired is set when stored reports were deleted after their TTL.
+	ReasonReportExpired = "Expired"
+
+	// ConditionReportExported reports whether the scan reports were uploaded to object storage.
+	ConditionReportExported = "ReportExported"
+
+	// ReasonReportExported is set when all reports were uploaded.
+	ReasonReportExported = "Exported"
+	// ReasonReportExportFailed is set when uploading the reports failed.
+	ReasonReportExportFailed = "ExportFailed"
+
+	// ConditionGatePassed reports whether the scan's findings are within spec.gate.
+	ConditionGatePassed = "GatePassed"
+
+	// ReasonGatePassed is set when no gate limit was exceeded.
+	ReasonGatePassed = "WithinLimits"
+	// ReasonGateFailed is set when at least one gate limit was exceeded.
+	ReasonGateFailed = "LimitExceeded"
+	// ReasonGateUnknown is set when the gate couldn't be evaluated because the report wasn't parsed.
+	ReasonGateUnknown = "ReportNotParsed"
+	// ReasonGateScanFailed is set when the gate wasn't evaluated because the scan Job failed.
+	ReasonGateScanFailed = "ScanFailed"
+
+	// ConditionSuppressionExpired reports whether expired ZapAlertSuppressions
+	// would have suppressed alerts of the scan.
+	ConditionSuppressionExpired = "SuppressionExpired"
+
+	// ReasonSuppressionsExpired is set when alerts matched expired suppressions.
+	ReasonSuppressionsExpired = "SuppressionsExpired"
+
+	// ConditionSessionStored reports whether the ZAP session was saved.
+	ConditionSessionStored = "SessionStored"
+
+	// ReasonSessionStored is set when the session was written to storage.
+	ReasonSessionStored = "Stored"
+	// ReasonSessionStoreFailed is set when the session couldn't be saved.
+	ReasonSessionStoreFailed = "StoreFailed"
+	// ReasonSessionExpired is set when the saved session was deleted after the reports' TTL.
+	ReasonSessionExpired = "Expired"
+
+	// ConditionJobStarted reports whether the scan Job's pod started running.
+	ConditionJobStarted = "JobStarted"
+
+	// ReasonJobStarted is set once a pod of the scan Job is ready.
+	ReasonJobStarted = "PodReady"
+
+	// ConditionDefectDojoImported reports whether the scan report was imported into DefectDojo.
+	ConditionDefectDojoImported = "DefectDojoImported"
+
+	// ReasonDefectDojoImported is set when DefectDojo accepted the report.
+	ReasonDefectDojoImported = "Imported"
+	// ReasonDefectDojoImportFailed is set when importing the report failed.
+	ReasonDefectDojoImportFailed = "ImportFailed"
+	// ReasonDefectDojoImportSkipped is set when the scan failed or left no
+	// report, which would close every finding of the test.
+	ReasonDefectDojoImportSkipped = "ImportSkipped"
+
+	// ConditionIssuesSynced reports whether the scan's findings were synced to the issue tracker.
+	ConditionIssuesSynced = "IssuesSynced"
+
+	// ReasonIssuesSynced is set when every issue was created, updated or resolved.
+	ReasonIssuesSynced = "Synced"
+	// ReasonIssueSyncFailed is set when syncing stopped at an error.
+	ReasonIssueSyncFailed = "SyncFailed"
+	// ReasonIssueSyncSkipped is set when the scan failed or left no report,
+	// which would resolve every tracked issue.
+	ReasonIssueSyncSkipped = "SyncSkipped"
+
+	// ConditionPullRequestCommented reports whether the scan's summary was posted to its pull request.
+	ConditionPullRequestCommented = "PullRequestCommented"
+
+	// ReasonPullRequestCommented is set when the summary comment was created or updated.
+	ReasonPullRequestCommented = "Commented"
+	// ReasonPullRequestCommentFailed is set when posting the summary comment failed.
+	ReasonPullRequestCommentFailed = "CommentFailed"
+
+	// ReasonSinkPending is set on the DefectDojoImported, IssuesSynced and
+	// PullRequestCommented conditions when the final status is saved, before
+	// the report is sent.
+	ReasonSinkPending = "Pending"
+	// ReasonSinkInterrupted is set when the operator stopped before the
+	// outcome of sending the report was saved. It isn't sent again, since
+	// that could duplicate it.
+	ReasonSinkInterrupted = "Interrupted"
+)
+
+// AnnotationAllowedURLs lists, comma-separated, the URLs a Secret's
+// credentials may be sent to on behalf of a scan, e.g. its DefectDojo API
+// key. Only the scheme, host and port are compared. A scan names both the
+// Secret and the URL, so without it a scan could send any Secret in its
+// namespace to a server of its choice.
+const AnnotationAllowedURLs = "spaceship.com/allowed-urls"
+
+// Annotations on a ZapScan that post its summary to a pull request once it
+// finishes. Repository and number are required; the rest are optional.
+const (
+	// AnnotationPullRequestProvider is github (the default) or gitlab.
+	AnnotationPullRequestProvider = "spaceship.com/pr-provider"
+	// AnnotationPullRequestAPIURL overrides the provider's API URL, e.g. for
+	// GitHub Enterprise or self-managed GitLab.
+	AnnotationPullRequestAPIURL = "spaceship.com/pr-api-url"
+	// AnnotationPullRequestRepository is owner/name on GitHub, and the
+	// project path or ID on GitLab.
+	AnnotationPullRequestRepository = "spaceship.com/pr-repository"
+	// AnnotationPullRequestNumber is the pull request number, or the merge
+	// request IID on GitLab.
+	AnnotationPullRequestNumber = "spaceship.com/pr-number"
+	// AnnotationPullRequestTokenSecret names the Secret in the scan's
+	// namespace whose token key holds the API token. The Secret must allow
+	// the API URL in its spaceship.com/allowed-urls annotation.
+	AnnotationPullRequestTokenSecret = "spaceship.com/pr-token-secret"
+)
+
+// RiskCounts counts alerts per ZAP risk level.
+type RiskCounts struct {
+	High          int64 `json:"high"`
+	Medium        int64 `json:"medium"`
+	Low           int64 `json:"low"`
+	Informational int64 `json:"informational"`
+}
+
+// ConfidenceCounts counts alerts per ZAP confidence level.
+type ConfidenceCounts struct {
+	Confirmed     int64 `json:"confirmed"`
+	High          int64 `json:"high"`
+	Medium        int64 `json:"medium"`
+	Low           int64 `json:"low"`
+	FalsePositive int64 `json:"falsePositive"`
+}
+
+// AlertSummary names one of the most severe alerts of a scan.
+type AlertSummary struct {
+	// Name is the alert title.
+	Name string `json:"name"`
+
+	// PluginID is the ID of the ZAP rule that raised the alert.
+	PluginID string `json:"pluginId"`
+
+	// Risk is one of informational, low, medium or high.
+	Risk string `json:"risk"`
+
+	// Count is the number of instances across all sites.
+	Count int32 `json:"count"`
+}
+
+// Notification types.
+const (
+	NotificationWebhook = "Webhook"
+	NotificationSlack   = "Slack"
+	NotificationSMTP    = "SMTP"
+)
+
+// Notification delivery states.
+const (
+	NotificationPending   = "Pending"
+	NotificationDelivered = "Delivered"
+	NotificationFailed    = "Failed"
+
+	// NotificationRateLimited is set instead of delivering when a
+	// ZapNotificationPolicy's rate limit is exhausted.
+	NotificationRateLimited = "RateLimited"
+)
+
+// NotificationStatus records the delivery of one notification.
+type NotificationStatus struct {
+	// Name of the notification, e.g. the webhook's or the ZapNotifier's name.
+	Name string `json:"name"`
+
+	// Type is the kind of destination, e.g. Webhook or Slack.
+	Type string `json:"type"`
+
+	// State is Pending while deliveries are retried, then Delivered or Failed.
+	// Notifications dropped by a policy's rate limit are RateLimited.
+	State string `json:"state"`
+
+	// Attempts is the number of delivery attempts so far.
+	Attempts int32 `json:"attempts"`
+
+	// LastAttemptTime is when delivery was last attempted.
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// LastError is the error of the last failed attempt.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// SessionStorageObjectStorage is the SessionRef type of sessions uploaded to object storage.
+const SessionStorageObjectStorage = "ObjectStorage"
+
+// SessionRef locates a saved ZAP session.
+type SessionRef struct {
+	// Type is PersistentVolumeClaim or ObjectStorage.
+	Type string `json:"type"`
+
+	// ClaimName is the PersistentVolumeClaim holding the session directory.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// Path is the session directory on the PersistentVolumeClaim.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// URL is the gzipped tar of the session directory in object storage.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Key is the object key of the session archive.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// StoredAt is when the session was saved.
+	// +optional
+	StoredAt *metav1.Time `json:"storedAt,omitempty"`
+}
+
+// ReportRef locates stored scan reports.
+type ReportRef struct {
+	// Type is the storage type the reports were written to.
+	Type string `json:"type"`
+
+	// Names are the ConfigMaps or Secrets holding the report chunks, in order.
+	// +optional
+	Names []string `json:"names,omitempty"`
+
+	// ClaimName is the PersistentVolumeClaim holding the reports.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// Path is the directory on the PersistentVolumeClaim holding the reports.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Files are the names of the stored report files.
+	// +optional
+	Files []string `json:"files,omitempty"`
+
+	// StoredAt is when the reports were stored.
+	// +optional
+	StoredAt *metav1.Time `json:"storedAt,omitempty"`
+}
+
+// SuppressedAlert names an alert excluded from the counts by a ZapAlertSuppression.
+type SuppressedAlert struct {
+	// Suppression is the name of the ZapAlertSuppression.
+	Suppression string `json:"suppression"`
+
+	// Name is the alert title.
+	Name string `json:"name"`
+
+	// PluginID is the ID of the ZAP rule that raised the alert.
+	PluginID string `json:"pluginId"`
+
+	// Risk is one of informational, low, medium or high.
+	Risk string `json:"risk"`
+
+	// Count is the number of suppressed instances.
+	Count int32 `json:"count"`
+}
+
+// FindingDiff lists what changed since the previous run of a schedule.
+// Findings are matched by fingerprint: plugin, normalized URL, parameter and method.
+type FindingDiff struct {
+	// BaselineScan is the name of the previous run the findings were compared with.
+	BaselineScan string `json:"baselineScan"`
+
+	// New is the number of findings not present in the previous run.
+	New int32 `json:"new"`
+
+	// Fixed is the number of findings of the previous run that are gone.
+	Fixed int32 `json:"fixed"`
+
+	// Persisting is the number of findings present in both runs.
+	Persisting int32 `json:"persisting"`
+
+	// NewByRisk counts the new findings per risk level.
+	NewByRisk RiskCounts `json:"newByRisk"`
+
+	// NewFindings lists the new findings, most severe first. The list is truncated; New holds the total.
+	// +optional
+	NewFindings []DiffFinding `json:"newFindings,omitempty"`
+
+	// FixedFindings lists the fixed findings, most severe first. The list is truncated; Fixed holds the total.
+	// +optional
+	FixedFindings []DiffFinding `json:"fixedFindings,omitempty"`
+
+	// PersistingFindings lists the persisting findings, most severe first. The list is truncated; Persisting holds the total.
+	// +optional
+	PersistingFindings []DiffFinding `json:"persistingFindings,omitempty"`
+}
+
+// DiffFinding identifies one alert instance in a FindingDiff.
+type DiffFinding struct {
+	// Fingerprint identifies the finding across runs.
+	Fingerprint string `json:"fingerprint"`
+
+	// PluginID is the ID of the ZAP rule that raised the alert.
+	PluginID string `json:"pluginId"`
+
+	// Name is the alert title.
+	Name string `json:"name"`
+
+	// Risk is one of informational, low, medium or high.
+	Risk string `json:"risk"`
+
+	// URL is the affected URL.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Method is the HTTP method of the request.
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Param is the affected parameter.
+	// +optional
+	Param string `json:"param,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=zaps
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Outcome",type=string,JSONPath=`.status.outcome`
+// +kubebuilder:printcolumn:name="High",type=integer,JSONPath=`.status.alertsByRisk.high`
+// +kubebuilder:printcolumn:name="Medium",type=integer,JSONPath=`.status.alertsByRisk.medium`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 type ZapScan struct {
 	metav1.TypeMeta   `json:",inline"`
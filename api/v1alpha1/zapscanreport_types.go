@@ -0,0 +1,110 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ZapScanReportSpec holds one page of the normalized findings of a ZapScan.
+type ZapScanReportSpec struct {
+	// ScanName is the ZapScan the findings belong to.
+	ScanName string `json:"scanName"`
+
+	// Target is the URL that was scanned.
+	Target string `json:"target"`
+
+	// Page is the 1-based index of this page.
+	Page int32 `json:"page"`
+
+	// Pages is the total number of pages of the scan's report.
+	Pages int32 `json:"pages"`
+
+	// Findings are the alerts reported by ZAP on this page.
+	// +optional
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Finding is a single ZAP alert, e.g. one rule firing on one site.
+type Finding struct {
+	// PluginID is the ID of the ZAP rule that raised the alert.
+	PluginID string `json:"pluginId"`
+
+	// AlertRef distinguishes alerts raised by the same rule, e.g. 10020-1.
+	// +optional
+	AlertRef string `json:"alertRef,omitempty"`
+
+	// Name is the alert title.
+	Name string `json:"name"`
+
+	// Risk is one of informational, low, medium or high.
+	Risk string `json:"risk"`
+
+	// Confidence is one of falsepositive, low, medium, high or confirmed.
+	Confidence string `json:"confidence"`
+
+	// CWEID is the Common Weakness Enumeration ID, if known.
+	// +optional
+	CWEID int32 `json:"cweId,omitempty"`
+
+	// WASCID is the Web Application Security Consortium threat ID, if known.
+	// +optional
+	WASCID int32 `json:"wascId,omitempty"`
+
+	// Count is the number of instances ZAP found, which may exceed len(Instances).
+	Count int32 `json:"count"`
+
+	// Instances are the locations the alert was raised for.
+	// +optional
+	Instances []FindingInstance `json:"instances,omitempty"`
+
+	// SuppressedBy is the ZapAlertSuppression that suppressed these instances.
+	// Suppressed findings are kept in the report but not counted.
+	// +optional
+	SuppressedBy string `json:"suppressedBy,omitempty"`
+}
+
+// FindingInstance is one location an alert was raised for.
+type FindingInstance struct {
+	// URL is the request URL.
+	URL string `json:"url"`
+
+	// Method is the HTTP method of the request.
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Param is the parameter the alert was raised for.
+	// +optional
+	Param string `json:"param,omitempty"`
+
+	// Attack is the payload ZAP used, if any.
+	// +optional
+	Attack string `json:"attack,omitempty"`
+
+	// Evidence is the part of the response that triggered the alert, truncated.
+	// +optional
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=zapreport
+// +kubebuilder:printcolumn:name="Scan",type=string,JSONPath=`.spec.scanName`
+// +kubebuilder:printcolumn:name="Page",type=integer,JSONPath=`.spec.page`
+// +kubebuilder:printcolumn:name="Pages",type=integer,JSONPath=`.spec.pages`
+
+type ZapScanReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ZapScanReportSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type ZapScanReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZapScanReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZapScanReport{}, &ZapScanReportList{})
+}
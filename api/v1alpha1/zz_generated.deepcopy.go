@@ -3,6 +3,7 @@
 package v1alpha1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -10,30 +11,7 @@ func (in *ZapScan) DeepCopyInto(out *ZapScan) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	if in.Spec.OpenAPI != nil {
-		out.Spec.OpenAPI = new(string)
-		*out.Spec.OpenAPI = *in.Spec.OpenAPI
-	}
-	if in.Spec.JobNamespace != nil {
-		out.Spec.JobNamespace = new(string)
-		*out.Spec.JobNamespace = *in.Spec.JobNamespace
-	}
-	if in.Spec.ServiceAccountName != nil {
-		out.Spec.ServiceAccountName = new(string)
-		*out.Spec.ServiceAccountName = *in.Spec.ServiceAccountName
-	}
-	if in.Spec.Image != nil {
-		out.Spec.Image = new(string)
-		*out.Spec.Image = *in.Spec.Image
-	}
-	if in.Spec.Args != nil {
-		out.Spec.Args = append([]string{}, in.Spec.Args...)
-	}
-	if in.Spec.Cleanup != nil {
-		out.Spec.Cleanup = new(bool)
-		*out.Spec.Cleanup = *in.Spec.Cleanup
-	}
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -81,6 +59,279 @@ func (in *ZapScanList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+func (in *ZapScanSpec) DeepCopyInto(out *ZapScanSpec) {
+	*out = *in
+	if in.OpenAPI != nil {
+		out.OpenAPI = new(string)
+		*out.OpenAPI = *in.OpenAPI
+	}
+	if in.JobNamespace != nil {
+		out.JobNamespace = new(string)
+		*out.JobNamespace = *in.JobNamespace
+	}
+	if in.ServiceAccountName != nil {
+		out.ServiceAccountName = new(string)
+		*out.ServiceAccountName = *in.ServiceAccountName
+	}
+	if in.Image != nil {
+		out.Image = new(string)
+		*out.Image = *in.Image
+	}
+	if in.Args != nil {
+		out.Args = append([]string{}, in.Args...)
+	}
+	if in.Cleanup != nil {
+		out.Cleanup = new(bool)
+		*out.Cleanup = *in.Cleanup
+	}
+	if in.ReportStorage != nil {
+		out.ReportStorage = new(ReportStorage)
+		in.ReportStorage.DeepCopyInto(out.ReportStorage)
+	}
+	if in.ObjectStorage != nil {
+		out.ObjectStorage = new(ObjectStorage)
+		*out.ObjectStorage = *in.ObjectStorage
+	}
+	if in.JUnit != nil {
+		out.JUnit = new(JUnitOptions)
+		*out.JUnit = *in.JUnit
+	}
+	if in.Gate != nil {
+		out.Gate = new(Gate)
+		in.Gate.DeepCopyInto(out.Gate)
+	}
+	if in.Notifications != nil {
+		out.Notifications = new(Notifications)
+		in.Notifications.DeepCopyInto(out.Notifications)
+	}
+	if in.DefectDojo != nil {
+		out.DefectDojo = new(DefectDojo)
+		in.DefectDojo.DeepCopyInto(out.DefectDojo)
+	}
+	if in.IssueTracker != nil {
+		out.IssueTracker = new(IssueTracker)
+		in.IssueTracker.DeepCopyInto(out.IssueTracker)
+	}
+}
+
+func (in *ZapScanSpec) DeepCopy() *ZapScanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapScanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ReportStorage) DeepCopyInto(out *ReportStorage) {
+	*out = *in
+	if in.TTL != nil {
+		out.TTL = new(metav1.Duration)
+		*out.TTL = *in.TTL
+	}
+}
+
+func (in *ReportStorage) DeepCopy() *ReportStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ObjectStorage) DeepCopyInto(out *ObjectStorage) {
+	*out = *in
+}
+
+func (in *ObjectStorage) DeepCopy() *ObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *JUnitOptions) DeepCopyInto(out *JUnitOptions) {
+	*out = *in
+}
+
+func (in *JUnitOptions) DeepCopy() *JUnitOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(JUnitOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Gate) DeepCopyInto(out *Gate) {
+	*out = *in
+	if in.MaxAlerts != nil {
+		out.MaxAlerts = new(RiskLimits)
+		in.MaxAlerts.DeepCopyInto(out.MaxAlerts)
+	}
+	if in.MaxAlertsPerPlugin != nil {
+		out.MaxAlertsPerPlugin = make(map[string]int64, len(in.MaxAlertsPerPlugin))
+		for k, v := range in.MaxAlertsPerPlugin {
+			out.MaxAlertsPerPlugin[k] = v
+		}
+	}
+}
+
+func (in *Gate) DeepCopy() *Gate {
+	if in == nil {
+		return nil
+	}
+	out := new(Gate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *RiskLimits) DeepCopyInto(out *RiskLimits) {
+	*out = *in
+	if in.High != nil {
+		out.High = new(int64)
+		*out.High = *in.High
+	}
+	if in.Medium != nil {
+		out.Medium = new(int64)
+		*out.Medium = *in.Medium
+	}
+	if in.Low != nil {
+		out.Low = new(int64)
+		*out.Low = *in.Low
+	}
+	if in.Informational != nil {
+		out.Informational = new(int64)
+		*out.Informational = *in.Informational
+	}
+}
+
+func (in *RiskLimits) DeepCopy() *RiskLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(RiskLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Notifications) DeepCopyInto(out *Notifications) {
+	*out = *in
+	if in.Webhooks != nil {
+		out.Webhooks = make([]WebhookNotification, len(in.Webhooks))
+		for i := range in.Webhooks {
+			in.Webhooks[i].DeepCopyInto(&out.Webhooks[i])
+		}
+	}
+}
+
+func (in *Notifications) DeepCopy() *Notifications {
+	if in == nil {
+		return nil
+	}
+	out := new(Notifications)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *WebhookNotification) DeepCopyInto(out *WebhookNotification) {
+	*out = *in
+	if in.Headers != nil {
+		out.Headers = make(map[string]string, len(in.Headers))
+		for k, v := range in.Headers {
+			out.Headers[k] = v
+		}
+	}
+}
+
+func (in *WebhookNotification) DeepCopy() *WebhookNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *NotificationStatus) DeepCopyInto(out *NotificationStatus) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		out.LastAttemptTime = in.LastAttemptTime.DeepCopy()
+	}
+}
+
+func (in *NotificationStatus) DeepCopy() *NotificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *SessionRef) DeepCopyInto(out *SessionRef) {
+	*out = *in
+	if in.StoredAt != nil {
+		out.StoredAt = in.StoredAt.DeepCopy()
+	}
+}
+
+func (in *SessionRef) DeepCopy() *SessionRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SessionRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ReportRef) DeepCopyInto(out *ReportRef) {
+	*out = *in
+	if in.Names != nil {
+		out.Names = append([]string{}, in.Names...)
+	}
+	if in.Files != nil {
+		out.Files = append([]string{}, in.Files...)
+	}
+	if in.StoredAt != nil {
+		out.StoredAt = in.StoredAt.DeepCopy()
+	}
+}
+
+func (in *ReportRef) DeepCopy() *ReportRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapScheduledScanSpec) DeepCopyInto(out *ZapScheduledScanSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Suspend != nil {
+		out.Suspend = new(bool)
+		*out.Suspend = *in.Suspend
+	}
+	if in.ConcurrencyPolicy != nil {
+		out.ConcurrencyPolicy = new(string)
+		*out.ConcurrencyPolicy = *in.ConcurrencyPolicy
+	}
+}
+
+func (in *ZapScheduledScanSpec) DeepCopy() *ZapScheduledScanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapScheduledScanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 func (in *ZapScanStatus) DeepCopyInto(out *ZapScanStatus) {
 	*out = *in
 	if in.StartedAt != nil {
@@ -89,6 +340,47 @@ func (in *ZapScanStatus) DeepCopyInto(out *ZapScanStatus) {
 	if in.FinishedAt != nil {
 		out.FinishedAt = in.FinishedAt.DeepCopy()
 	}
+	if in.AlertsByRisk != nil {
+		out.AlertsByRisk = new(RiskCounts)
+		*out.AlertsByRisk = *in.AlertsByRisk
+	}
+	if in.AlertsByConfidence != nil {
+		out.AlertsByConfidence = new(ConfidenceCounts)
+		*out.AlertsByConfidence = *in.AlertsByConfidence
+	}
+	if in.TopAlerts != nil {
+		out.TopAlerts = append([]AlertSummary{}, in.TopAlerts...)
+	}
+	if in.ReportRef != nil {
+		out.ReportRef = new(ReportRef)
+		in.ReportRef.DeepCopyInto(out.ReportRef)
+	}
+	if in.ReportURLs != nil {
+		out.ReportURLs = append([]string{}, in.ReportURLs...)
+	}
+	if in.SuppressedAlerts != nil {
+		out.SuppressedAlerts = append([]SuppressedAlert{}, in.SuppressedAlerts...)
+	}
+	if in.Diff != nil {
+		out.Diff = new(FindingDiff)
+		in.Diff.DeepCopyInto(out.Diff)
+	}
+	if in.Notifications != nil {
+		out.Notifications = make([]NotificationStatus, len(in.Notifications))
+		for i := range in.Notifications {
+			in.Notifications[i].DeepCopyInto(&out.Notifications[i])
+		}
+	}
+	if in.SessionRef != nil {
+		out.SessionRef = new(SessionRef)
+		in.SessionRef.DeepCopyInto(out.SessionRef)
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
 }
 
 func (in *ZapScanStatus) DeepCopy() *ZapScanStatus {
@@ -100,42 +392,33 @@ func (in *ZapScanStatus) DeepCopy() *ZapScanStatus {
 	return out
 }
 
-func (in *ZapScheduledScan) DeepCopyInto(out *ZapScheduledScan) {
+func (in *FindingDiff) DeepCopyInto(out *FindingDiff) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	if in.Spec.Suspend != nil {
-		out.Spec.Suspend = new(bool)
-		*out.Spec.Suspend = *in.Spec.Suspend
-	}
-	if in.Spec.ConcurrencyPolicy != nil {
-		out.Spec.ConcurrencyPolicy = new(string)
-		*out.Spec.ConcurrencyPolicy = *in.Spec.ConcurrencyPolicy
-	}
-	if in.Spec.Template.OpenAPI != nil {
-		out.Spec.Template.OpenAPI = new(string)
-		*out.Spec.Template.OpenAPI = *in.Spec.Template.OpenAPI
-	}
-	if in.Spec.Template.JobNamespace != nil {
-		out.Spec.Template.JobNamespace = new(string)
-		*out.Spec.Template.JobNamespace = *in.Spec.Template.JobNamespace
+	if in.NewFindings != nil {
+		out.NewFindings = append([]DiffFinding{}, in.NewFindings...)
 	}
-	if in.Spec.Template.ServiceAccountName != nil {
-		out.Spec.Template.ServiceAccountName = new(string)
-		*out.Spec.Template.ServiceAccountName = *in.Spec.Template.ServiceAccountName
+	if in.FixedFindings != nil {
+		out.FixedFindings = append([]DiffFinding{}, in.FixedFindings...)
 	}
-	if in.Spec.Template.Image != nil {
-		out.Spec.Template.Image = new(string)
-		*out.Spec.Template.Image = *in.Spec.Template.Image
+	if in.PersistingFindings != nil {
+		out.PersistingFindings = append([]DiffFinding{}, in.PersistingFindings...)
 	}
-	if in.Spec.Template.Args != nil {
-		out.Spec.Template.Args = append([]string{}, in.Spec.Template.Args...)
-	}
-	if in.Spec.Template.Cleanup != nil {
-		out.Spec.Template.Cleanup = new(bool)
-		*out.Spec.Template.Cleanup = *in.Spec.Template.Cleanup
+}
+
+func (in *FindingDiff) DeepCopy() *FindingDiff {
+	if in == nil {
+		return nil
 	}
+	out := new(FindingDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapScheduledScan) DeepCopyInto(out *ZapScheduledScan) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -201,3 +484,445 @@ func (in *ZapScheduledScanStatus) DeepCopy() *ZapScheduledScanStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+func (in *ZapScanReport) DeepCopyInto(out *ZapScanReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+func (in *ZapScanReport) DeepCopy() *ZapScanReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapScanReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapScanReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapScanReportList) DeepCopyInto(out *ZapScanReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ZapScanReport, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ZapScanReportList) DeepCopy() *ZapScanReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapScanReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapScanReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapScanReportSpec) DeepCopyInto(out *ZapScanReportSpec) {
+	*out = *in
+	if in.Findings != nil {
+		out.Findings = make([]Finding, len(in.Findings))
+		for i := range in.Findings {
+			in.Findings[i].DeepCopyInto(&out.Findings[i])
+		}
+	}
+}
+
+func (in *ZapScanReportSpec) DeepCopy() *ZapScanReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapScanReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Finding) DeepCopyInto(out *Finding) {
+	*out = *in
+	if in.Instances != nil {
+		out.Instances = append([]FindingInstance{}, in.Instances...)
+	}
+}
+
+func (in *Finding) DeepCopy() *Finding {
+	if in == nil {
+		return nil
+	}
+	out := new(Finding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapAlertSuppression) DeepCopyInto(out *ZapAlertSuppression) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ZapAlertSuppression) DeepCopy() *ZapAlertSuppression {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapAlertSuppression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapAlertSuppression) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapAlertSuppressionList) DeepCopyInto(out *ZapAlertSuppressionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ZapAlertSuppression, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ZapAlertSuppressionList) DeepCopy() *ZapAlertSuppressionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapAlertSuppressionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapAlertSuppressionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapAlertSuppressionSpec) DeepCopyInto(out *ZapAlertSuppressionSpec) {
+	*out = *in
+	in.Expires.DeepCopyInto(&out.Expires)
+}
+
+func (in *ZapAlertSuppressionSpec) DeepCopy() *ZapAlertSuppressionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapAlertSuppressionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapAlertSuppressionStatus) DeepCopyInto(out *ZapAlertSuppressionStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(in.Conditions))
+		for i := range in.Conditions {
+			in.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+func (in *ZapAlertSuppressionStatus) DeepCopy() *ZapAlertSuppressionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapAlertSuppressionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotifier) DeepCopyInto(out *ZapNotifier) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ZapNotifier) DeepCopy() *ZapNotifier {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotifier) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapNotifierList) DeepCopyInto(out *ZapNotifierList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ZapNotifier, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ZapNotifierList) DeepCopy() *ZapNotifierList {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotifierList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotifierList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapNotifierSpec) DeepCopyInto(out *ZapNotifierSpec) {
+	*out = *in
+	if in.Selector != nil {
+		out.Selector = in.Selector.DeepCopy()
+	}
+	if in.Slack != nil {
+		out.Slack = new(SlackNotifier)
+		*out.Slack = *in.Slack
+	}
+	if in.SMTP != nil {
+		out.SMTP = new(SMTPNotifier)
+		in.SMTP.DeepCopyInto(out.SMTP)
+	}
+}
+
+func (in *ZapNotifierSpec) DeepCopy() *ZapNotifierSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotifierSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *SMTPNotifier) DeepCopyInto(out *SMTPNotifier) {
+	*out = *in
+	if in.To != nil {
+		out.To = make([]string, len(in.To))
+		copy(out.To, in.To)
+	}
+	if in.DigestInterval != nil {
+		out.DigestInterval = new(metav1.Duration)
+		*out.DigestInterval = *in.DigestInterval
+	}
+}
+
+func (in *SMTPNotifier) DeepCopy() *SMTPNotifier {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPNotifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotifierStatus) DeepCopyInto(out *ZapNotifierStatus) {
+	*out = *in
+	if in.LastDigestTime != nil {
+		out.LastDigestTime = in.LastDigestTime.DeepCopy()
+	}
+	if in.DigestedScans != nil {
+		out.DigestedScans = make([]string, len(in.DigestedScans))
+		copy(out.DigestedScans, in.DigestedScans)
+	}
+}
+
+func (in *ZapNotifierStatus) DeepCopy() *ZapNotifierStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotifierStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotificationPolicy) DeepCopyInto(out *ZapNotificationPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+func (in *ZapNotificationPolicy) DeepCopy() *ZapNotificationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotificationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotificationPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapNotificationPolicyList) DeepCopyInto(out *ZapNotificationPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]ZapNotificationPolicy, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *ZapNotificationPolicyList) DeepCopy() *ZapNotificationPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotificationPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotificationPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *ZapNotificationPolicySpec) DeepCopyInto(out *ZapNotificationPolicySpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		out.Namespaces = make([]string, len(in.Namespaces))
+		copy(out.Namespaces, in.Namespaces)
+	}
+	if in.NamespaceSelector != nil {
+		out.NamespaceSelector = in.NamespaceSelector.DeepCopy()
+	}
+	if in.Selector != nil {
+		out.Selector = in.Selector.DeepCopy()
+	}
+	if in.Phases != nil {
+		out.Phases = make([]PolicyPhase, len(in.Phases))
+		copy(out.Phases, in.Phases)
+	}
+	if in.Notifiers != nil {
+		out.Notifiers = make([]NotifierReference, len(in.Notifiers))
+		copy(out.Notifiers, in.Notifiers)
+	}
+	if in.RateLimit != nil {
+		out.RateLimit = new(RateLimit)
+		*out.RateLimit = *in.RateLimit
+	}
+}
+
+func (in *ZapNotificationPolicySpec) DeepCopy() *ZapNotificationPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotificationPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ZapNotificationPolicyStatus) DeepCopyInto(out *ZapNotificationPolicyStatus) {
+	*out = *in
+	if in.RateLimits != nil {
+		out.RateLimits = make([]RateLimitStatus, len(in.RateLimits))
+		for i := range in.RateLimits {
+			in.RateLimits[i].DeepCopyInto(&out.RateLimits[i])
+		}
+	}
+}
+
+func (in *ZapNotificationPolicyStatus) DeepCopy() *ZapNotificationPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ZapNotificationPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *RateLimitStatus) DeepCopyInto(out *RateLimitStatus) {
+	*out = *in
+	in.PeriodStart.DeepCopyInto(&out.PeriodStart)
+}
+
+func (in *RateLimitStatus) DeepCopy() *RateLimitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *DefectDojo) DeepCopyInto(out *DefectDojo) {
+	*out = *in
+	if in.CloseOldFindings != nil {
+		out.CloseOldFindings = new(bool)
+		*out.CloseOldFindings = *in.CloseOldFindings
+	}
+}
+
+func (in *DefectDojo) DeepCopy() *DefectDojo {
+	if in == nil {
+		return nil
+	}
+	out := new(DefectDojo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *IssueTracker) DeepCopyInto(out *IssueTracker) {
+	*out = *in
+	if in.Labels != nil {
+		out.Labels = make([]string, len(in.Labels))
+		copy(out.Labels, in.Labels)
+	}
+}
+
+func (in *IssueTracker) DeepCopy() *IssueTracker {
+	if in == nil {
+		return nil
+	}
+	out := new(IssueTracker)
+	in.DeepCopyInto(out)
+	return out
+}
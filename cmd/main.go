@@ -3,17 +3,25 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/alertmanager"
+	"github.com/NCCloud/zap-operator/internal/cloudevents"
 	"github.com/NCCloud/zap-operator/internal/controller"
+	"github.com/NCCloud/zap-operator/internal/results"
+	"github.com/NCCloud/zap-operator/internal/viewer"
 )
 
 var (
@@ -30,10 +38,42 @@ func main() {
 	var metricsAddr string
 	var probeAddr string
 	var leaderElect bool
+	var resultsAddr string
+	var resultsURL string
+	var viewerAddr string
+	var minConfidence string
+	var maxReportBytes int64
+	var resultsMaxFiles int
+	var resultsMaxBytes int64
+	var objectStorage zapv1alpha1.ObjectStorage
+	var objectStorageSecret string
+	var cloudEventsEndpoint string
+	var cloudEventsMode string
+	var alertmanagerURL string
+	var alertmanagerMinRisk string
+	var alertmanagerAlertTTL time.Duration
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&leaderElect, "leader-elect", false, "Enable leader election for controller manager.")
+	flag.StringVar(&resultsAddr, "results-bind-address", "0", "The address the scan results receiver binds to. Set to 0 to disable uploads.")
+	flag.StringVar(&resultsURL, "results-url", "", "The URL scan pods use to reach the results receiver (e.g. http://zap-operator-results.zap-system.svc:8082).")
+	flag.IntVar(&resultsMaxFiles, "results-max-files-per-job", results.DefaultMaxFilesPerJob, "The maximum number of report files the results receiver keeps per scan.")
+	flag.Int64Var(&resultsMaxBytes, "results-max-bytes", results.DefaultMaxStoreBytes, "The maximum size in bytes of all uploaded report files the results receiver keeps until they are consumed.")
+	flag.StringVar(&viewerAddr, "viewer-bind-address", "0", "The address the read-only report viewer binds to. Set to 0 to disable it.")
+	flag.StringVar(&minConfidence, "min-confidence", "", "The lowest ZAP confidence (falsepositive, low, medium, high or confirmed) an alert needs to count in status, metrics and gates. Empty counts every alert.")
+	flag.Int64Var(&maxReportBytes, "max-report-bytes", 64<<20, "The maximum size in bytes of a scan report the operator will accept and parse.")
+	flag.StringVar(&objectStorage.Endpoint, "object-storage-endpoint", "", "The S3-compatible endpoint reports of all scans are uploaded to. Leave empty to disable.")
+	flag.StringVar(&objectStorage.Region, "object-storage-region", "", "The region used to sign object storage requests.")
+	flag.StringVar(&objectStorage.Bucket, "object-storage-bucket", "", "The bucket reports are uploaded to.")
+	flag.StringVar(&objectStorage.Prefix, "object-storage-prefix", "", "The Go template for uploaded object key prefixes.")
+	flag.StringVar(&objectStorageSecret, "object-storage-credentials-secret", "", "The namespace/name of the Secret holding object storage credentials.")
+	flag.BoolVar(&objectStorage.PathStyle, "object-storage-path-style", false, "Use path-style object URLs, as MinIO expects.")
+	flag.StringVar(&cloudEventsEndpoint, "cloudevents-endpoint", "", "The URL CloudEvents about scan lifecycles are posted to. Leave empty to disable.")
+	flag.StringVar(&cloudEventsMode, "cloudevents-mode", "structured", "The HTTP content mode of CloudEvents: structured or binary.")
+	flag.StringVar(&alertmanagerURL, "alertmanager-url", "", "The Alertmanager findings are posted to as alerts. Leave empty to disable.")
+	flag.StringVar(&alertmanagerMinRisk, "alertmanager-min-risk", "medium", "The lowest risk (informational, low, medium or high) a finding needs to be posted to Alertmanager.")
+	flag.DurationVar(&alertmanagerAlertTTL, "alertmanager-alert-ttl", 48*time.Hour, "How long an alert fires unless a later scan of the same target posts it again. Should exceed the interval between scans.")
 
 	opts := zap.Options{Development: true}
 	opts.BindFlags(flag.CommandLine)
@@ -46,6 +86,10 @@ func main() {
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
+		// Secrets and ConfigMaps are only read by name, so don't cache them cluster-wide.
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}}},
+		},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         leaderElect,
 		LeaderElectionID:       "zap-operator.spaceship.com",
@@ -55,14 +99,78 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := (&controller.ScanReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
+	switch minConfidence {
+	case "", "falsepositive", "low", "medium", "high", "confirmed":
+	default:
+		setupLog.Error(nil, "--min-confidence must be one of falsepositive, low, medium, high or confirmed")
+		os.Exit(1)
+	}
+
+	scanReconciler := &controller.ScanReconciler{Client: mgr.GetClient(), APIReader: mgr.GetAPIReader(), Scheme: mgr.GetScheme(), MaxReportBytes: maxReportBytes, MinConfidence: minConfidence, Recorder: mgr.GetEventRecorder("zap-operator")}
+	if resultsAddr != "0" {
+		store := results.NewStore()
+		store.MaxFilesPerJob, store.MaxBytes = resultsMaxFiles, resultsMaxBytes
+		if err := mgr.Add(&results.Server{Addr: resultsAddr, Reader: mgr.GetAPIReader(), Store: store, MaxBytes: maxReportBytes}); err != nil {
+			setupLog.Error(err, "unable to set up results receiver")
+			os.Exit(1)
+		}
+		scanReconciler.Results = store
+		scanReconciler.ResultsURL = resultsURL
+	}
+	if viewerAddr != "0" {
+		if err := mgr.Add(&viewer.Server{Addr: viewerAddr, Client: mgr.GetClient(), MaxBytes: maxReportBytes}); err != nil {
+			setupLog.Error(err, "unable to set up report viewer")
+			os.Exit(1)
+		}
+	}
+	if objectStorage.Endpoint != "" {
+		ns, name, ok := strings.Cut(objectStorageSecret, "/")
+		if !ok || ns == "" || name == "" {
+			setupLog.Error(nil, "--object-storage-credentials-secret must be namespace/name")
+			os.Exit(1)
+		}
+		objectStorage.CredentialsSecret = name
+		scanReconciler.ObjectStorage = &objectStorage
+		scanReconciler.ObjectStorageNamespace = ns
+	}
+
+	if cloudEventsEndpoint != "" {
+		mode := cloudevents.Mode(cloudEventsMode)
+		if mode != cloudevents.ModeStructured && mode != cloudevents.ModeBinary {
+			setupLog.Error(nil, "--cloudevents-mode must be structured or binary")
+			os.Exit(1)
+		}
+		scanReconciler.CloudEvents = &cloudevents.Sender{Endpoint: cloudEventsEndpoint, Mode: mode}
+	}
+
+	if alertmanagerURL != "" {
+		switch alertmanagerMinRisk {
+		case "informational", "low", "medium", "high":
+		default:
+			setupLog.Error(nil, "--alertmanager-min-risk must be one of informational, low, medium or high")
+			os.Exit(1)
+		}
+		scanReconciler.Alertmanager = &alertmanager.Client{URL: alertmanagerURL}
+		scanReconciler.AlertmanagerMinRisk = alertmanagerMinRisk
+		scanReconciler.AlertmanagerAlertTTL = alertmanagerAlertTTL
+	}
+
+	if err := scanReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create ZapScan controller")
 		os.Exit(1)
 	}
-	if err := (&controller.ZapScheduledScanReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
+	if err := (&controller.ZapScheduledScanReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Recorder: mgr.GetEventRecorder("zap-operator")}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create ZapScheduledScan controller")
 		os.Exit(1)
 	}
+	if err := (&controller.ZapAlertSuppressionReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create ZapAlertSuppression controller")
+		os.Exit(1)
+	}
+	if err := (&controller.ZapNotifierReconciler{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create ZapNotifier controller")
+		os.Exit(1)
+	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
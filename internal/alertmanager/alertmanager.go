@@ -0,0 +1,116 @@
+// Package alertmanager posts alerts to the Alertmanager API v2.
+//
+// Alertmanager is the source of truth for which alerts are firing: callers
+// list the active alerts matching their labels and resolve the ones that no
+// longer apply by posting them again with EndsAt set to now.
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single request.
+const DefaultTimeout = 10 * time.Second
+
+// Alert is an alert as Alertmanager's API accepts and returns it.
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt,omitzero"`
+	EndsAt       time.Time         `json:"endsAt,omitzero"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Client talks to one Alertmanager, or a load balancer in front of a cluster.
+type Client struct {
+	// URL is the base URL of Alertmanager, without /api/v2.
+	URL string
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+// Post sends alerts. Alerts with EndsAt in the past are resolved.
+func (c *Client) Post(ctx context.Context, alerts []Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(nil), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := c.do(req); err != nil {
+		return fmt.Errorf("post alerts: %w", err)
+	}
+	return nil
+}
+
+// Active returns the firing alerts whose labels equal matchers, including
+// silenced and inhibited ones.
+func (c *Client) Active(ctx context.Context, matchers map[string]string) ([]Alert, error) {
+	q := url.Values{"active": {"true"}, "unprocessed": {"true"}}
+	names := make([]string, 0, len(matchers))
+	for name := range matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		q.Add("filter", fmt.Sprintf("%s=%q", name, matchers[name]))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint(q), nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list alerts: %w", err)
+	}
+	var alerts []Alert
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		return nil, fmt.Errorf("decode alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+func (c *Client) endpoint(q url.Values) string {
+	u := strings.TrimSuffix(c.URL, "/") + "/api/v2/alerts"
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
+
+// do performs req and returns the response body, or an error for any non-2xx response.
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "zap-operator")
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
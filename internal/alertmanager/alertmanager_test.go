@@ -0,0 +1,91 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Post(t *testing.T) {
+	var raw []map[string]any
+	var path, contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	ends := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := &Client{URL: srv.URL + "/"}
+	err := c.Post(context.Background(), []Alert{
+		{Labels: map[string]string{"alertname": "ZapFinding", "plugin": "40012"}, Annotations: map[string]string{"summary": "XSS"}, EndsAt: ends},
+		{Labels: map[string]string{"alertname": "ZapFinding", "plugin": "10038"}},
+	})
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if path != "/api/v2/alerts" || contentType != "application/json" {
+		t.Errorf("unexpected request path=%q content-type=%q", path, contentType)
+	}
+	if len(raw) != 2 || raw[0]["endsAt"] != "2026-01-02T03:04:05Z" {
+		t.Fatalf("unexpected alerts %v", raw)
+	}
+	if _, ok := raw[1]["endsAt"]; ok {
+		t.Errorf("expected no endsAt for an alert without one, got %v", raw[1])
+	}
+	if _, ok := raw[1]["startsAt"]; ok {
+		t.Errorf("expected no startsAt for an alert without one, got %v", raw[1])
+	}
+
+	// Nothing to send doesn't make a request.
+	path = ""
+	if err := c.Post(context.Background(), nil); err != nil || path != "" {
+		t.Errorf("expected no request, got path=%q err=%v", path, err)
+	}
+}
+
+func TestClient_Active(t *testing.T) {
+	var query map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`[{"labels": {"alertname": "ZapFinding", "plugin": "40012"}, "annotations": {}, "startsAt": "2026-01-02T03:04:05Z", "endsAt": "2026-01-03T03:04:05Z", "fingerprint": "abc", "status": {"state": "active"}}]`))
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL}
+	alerts, err := c.Active(context.Background(), map[string]string{"namespace": "ns1", "target": `https://example.com/"x"`})
+	if err != nil {
+		t.Fatalf("active: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Labels["plugin"] != "40012" {
+		t.Errorf("unexpected alerts %+v", alerts)
+	}
+	filters := query["filter"]
+	if len(filters) != 2 || filters[0] != `namespace="ns1"` || filters[1] != `target="https://example.com/\"x\""` {
+		t.Errorf("unexpected filters %q", filters)
+	}
+	if query["active"][0] != "true" {
+		t.Errorf("expected only active alerts to be listed, got %v", query)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad matcher", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL}
+	if _, err := c.Active(context.Background(), map[string]string{"a": "b"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected a 400 error, got %v", err)
+	}
+	if err := c.Post(context.Background(), []Alert{{Labels: map[string]string{"a": "b"}}}); err == nil || !strings.Contains(err.Error(), "bad matcher") {
+		t.Errorf("expected the response in the error, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+// Package cloudevents sends CloudEvents 1.0 over HTTP.
+//
+// Delivery is attempted once per call. Events are for reacting to scans as
+// they happen; consumers that must not miss one should reconcile against the
+// Kubernetes API as well.
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents version events are sent as.
+const SpecVersion = "1.0"
+
+// Mode is the HTTP content mode of sent events.
+type Mode string
+
+const (
+	// ModeStructured sends the whole event as an application/cloudevents+json body.
+	ModeStructured Mode = "structured"
+
+	// ModeBinary sends the attributes as ce- headers and the data as the body.
+	ModeBinary Mode = "binary"
+)
+
+// DefaultTimeout bounds a single delivery attempt.
+const DefaultTimeout = 10 * time.Second
+
+// Event is a CloudEvent with JSON data.
+type Event struct {
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// Sender posts events to an HTTP endpoint.
+type Sender struct {
+	Endpoint string
+
+	// Mode defaults to ModeStructured.
+	Mode Mode
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+// structuredEvent is the JSON format of an event.
+type structuredEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// Send posts ev and returns an error for any non-2xx response.
+func (s *Sender) Send(ctx context.Context, ev *Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return fmt.Errorf("encode data: %w", err)
+	}
+	var ts string
+	if !ev.Time.IsZero() {
+		ts = ev.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	var body []byte
+	var contentType string
+	switch s.Mode {
+	case ModeBinary:
+		body, contentType = data, "application/json"
+	case ModeStructured, "":
+		body, err = json.Marshal(structuredEvent{
+			SpecVersion:     SpecVersion,
+			ID:              ev.ID,
+			Source:          ev.Source,
+			Type:            ev.Type,
+			Subject:         ev.Subject,
+			Time:            ts,
+			DataContentType: "application/json",
+			Data:            data,
+		})
+		if err != nil {
+			return err
+		}
+		contentType = "application/cloudevents+json; charset=utf-8"
+	default:
+		return fmt.Errorf("unknown mode %q", s.Mode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "zap-operator")
+	if s.Mode == ModeBinary {
+		req.Header.Set("ce-specversion", SpecVersion)
+		req.Header.Set("ce-id", ev.ID)
+		req.Header.Set("ce-source", ev.Source)
+		req.Header.Set("ce-type", ev.Type)
+		if ev.Subject != "" {
+			req.Header.Set("ce-subject", ev.Subject)
+		}
+		if ts != "" {
+			req.Header.Set("ce-time", ts)
+		}
+	}
+
+	hc := s.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
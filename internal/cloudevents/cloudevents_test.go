@@ -0,0 +1,109 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvent() *Event {
+	return &Event{
+		ID:      "uid-1/created",
+		Source:  "/apis/spaceship.com/v1alpha1/namespaces/ns1/zapscans",
+		Type:    "com.spaceship.zap.job.created.v1alpha1",
+		Subject: "s1",
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data:    map[string]string{"scan": "s1"},
+	}
+}
+
+func TestSender_Structured(t *testing.T) {
+	var got map[string]any
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	s := &Sender{Endpoint: srv.URL}
+	if err := s.Send(context.Background(), testEvent()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !strings.HasPrefix(contentType, "application/cloudevents+json") {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+	want := map[string]string{
+		"specversion":     "1.0",
+		"id":              "uid-1/created",
+		"source":          "/apis/spaceship.com/v1alpha1/namespaces/ns1/zapscans",
+		"type":            "com.spaceship.zap.job.created.v1alpha1",
+		"subject":         "s1",
+		"time":            "2026-01-02T03:04:05Z",
+		"datacontenttype": "application/json",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%q, got %v", k, v, got[k])
+		}
+	}
+	if data, _ := got["data"].(map[string]any); data["scan"] != "s1" {
+		t.Errorf("unexpected data %v", got["data"])
+	}
+}
+
+func TestSender_Binary(t *testing.T) {
+	var header http.Header
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer srv.Close()
+
+	s := &Sender{Endpoint: srv.URL, Mode: ModeBinary}
+	if err := s.Send(context.Background(), testEvent()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if body != `{"scan":"s1"}` {
+		t.Errorf("unexpected body %q", body)
+	}
+	want := map[string]string{
+		"Content-Type":   "application/json",
+		"Ce-Specversion": "1.0",
+		"Ce-Id":          "uid-1/created",
+		"Ce-Type":        "com.spaceship.zap.job.created.v1alpha1",
+		"Ce-Subject":     "s1",
+		"Ce-Time":        "2026-01-02T03:04:05Z",
+	}
+	for k, v := range want {
+		if header.Get(k) != v {
+			t.Errorf("expected header %s=%q, got %q", k, v, header.Get(k))
+		}
+	}
+}
+
+func TestSender_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no route", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	s := &Sender{Endpoint: srv.URL}
+	if err := s.Send(context.Background(), testEvent()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+	s.Mode = "batch"
+	if err := s.Send(context.Background(), testEvent()); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
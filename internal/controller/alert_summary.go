@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"sort"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+// topAlertsLimit is the number of alerts listed in status.topAlerts.
+const topAlertsLimit = 5
+
+func countRisk(c *zapv1alpha1.RiskCounts, risk string, n int64) {
+	switch risk {
+	case "high":
+		c.High += n
+	case "medium":
+		c.Medium += n
+	case "low":
+		c.Low += n
+	case "informational":
+		c.Informational += n
+	}
+}
+
+func countConfidence(c *zapv1alpha1.ConfidenceCounts, confidence string, n int64) {
+	switch confidence {
+	case "confirmed":
+		c.Confirmed += n
+	case "high":
+		c.High += n
+	case "medium":
+		c.Medium += n
+	case "low":
+		c.Low += n
+	case "falsepositive":
+		c.FalsePositive += n
+	}
+}
+
+// topAlerts merges findings of the same rule across sites and returns the
+// limit most severe ones, by risk and then by instance count.
+func topAlerts(findings []zapv1alpha1.Finding, limit int) []zapv1alpha1.AlertSummary {
+	byKey := map[string]*zapv1alpha1.AlertSummary{}
+	var all []*zapv1alpha1.AlertSummary
+	for _, f := range findings {
+		key := f.PluginID + "\x00" + f.Name + "\x00" + f.Risk
+		s, ok := byKey[key]
+		if !ok {
+			s = &zapv1alpha1.AlertSummary{Name: f.Name, PluginID: f.PluginID, Risk: f.Risk}
+			byKey[key] = s
+			all = append(all, s)
+		}
+		s.Count += f.Count
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if ri, rj := risk.Rank(all[i].Risk), risk.Rank(all[j].Risk); ri != rj {
+			return ri > rj
+		}
+		return all[i].Count > all[j].Count
+	})
+
+	var out []zapv1alpha1.AlertSummary
+	for i := 0; i < len(all) && i < limit; i++ {
+		out = append(out, *all[i])
+	}
+	return out
+}
+
+// applyMinConfidence removes findings below the operator's minimum confidence
+// from alerts and recomputes the counts from the findings that remain.
+func (r *ScanReconciler) applyMinConfidence(alerts *parsedAlerts) *parsedAlerts {
+	if r.MinConfidence == "" {
+		return alerts
+	}
+
+	var kept, low []zapv1alpha1.Finding
+	for _, f := range alerts.Findings {
+		if confidenceRank(f.Confidence) < confidenceRank(r.MinConfidence) {
+			low = append(low, f)
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	out := summarizeFindings(kept)
+	out.Suppressed = alerts.Suppressed
+	out.ExpiredSuppressions = alerts.ExpiredSuppressions
+	out.LowConfidence = low
+	return out
+}
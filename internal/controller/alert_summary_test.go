@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestTopAlerts(t *testing.T) {
+	findings := []zapv1alpha1.Finding{
+		{PluginID: "10096", Name: "Timestamp Disclosure", Risk: "informational", Count: 50},
+		{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", Count: 3},
+		{PluginID: "40012", Name: "Cross Site Scripting", Risk: "high", Count: 1},
+		{PluginID: "10020", Name: "Missing Anti-clickjacking Header", Risk: "medium", Count: 2},
+		// Same rule on a second site is merged.
+		{PluginID: "10020", Name: "Missing Anti-clickjacking Header", Risk: "medium", Count: 4},
+	}
+
+	top := topAlerts(findings, 3)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 alerts, got %d", len(top))
+	}
+	want := []string{"40012", "10020", "10038"}
+	for i, id := range want {
+		if top[i].PluginID != id {
+			t.Errorf("position %d: expected plugin %s, got %s", i, id, top[i].PluginID)
+		}
+	}
+	if top[1].Count != 6 {
+		t.Errorf("expected instances of the same rule to be merged, got %d", top[1].Count)
+	}
+
+	if got := topAlerts(nil, 3); len(got) != 0 {
+		t.Errorf("expected no alerts, got %v", got)
+	}
+}
+
+func TestCountRiskAndConfidence(t *testing.T) {
+	var risk zapv1alpha1.RiskCounts
+	for _, r := range []string{"high", "medium", "medium", "low", "informational", "unknown"} {
+		countRisk(&risk, r, 1)
+	}
+	if risk != (zapv1alpha1.RiskCounts{High: 1, Medium: 2, Low: 1, Informational: 1}) {
+		t.Errorf("unexpected risk counts %+v", risk)
+	}
+
+	var conf zapv1alpha1.ConfidenceCounts
+	for _, c := range []string{"confirmed", "high", "medium", "low", "falsepositive", "falsepositive", "?"} {
+		countConfidence(&conf, c, 1)
+	}
+	if conf != (zapv1alpha1.ConfidenceCounts{Confirmed: 1, High: 1, Medium: 1, Low: 1, FalsePositive: 2}) {
+		t.Errorf("unexpected confidence counts %+v", conf)
+	}
+}
+
+func TestCollectAlertsFromJobLogs_Summary(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": "test-job"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "p2", Namespace: "ns1", Labels: map[string]string{"job-name": "test-job"}}},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(job, pods[0], pods[1]).Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if alerts.ByRisk != (zapv1alpha1.RiskCounts{Medium: 2, Informational: 2}) {
+		t.Errorf("unexpected risk counts %+v", alerts.ByRisk)
+	}
+	if alerts.ByConfidence != (zapv1alpha1.ConfidenceCounts{High: 2, Low: 2}) {
+		t.Errorf("unexpected confidence counts %+v", alerts.ByConfidence)
+	}
+	if len(alerts.Top) != 2 || alerts.Top[0].PluginID != "10038" || alerts.Top[0].Count != 10 {
+		t.Errorf("unexpected top alerts %+v", alerts.Top)
+	}
+}
+
+func TestApplyMinConfidence(t *testing.T) {
+	findings := []zapv1alpha1.Finding{
+		{PluginID: "10038", Risk: "medium", Confidence: "high"},
+		{PluginID: "10096", Risk: "informational", Confidence: "low"},
+		{PluginID: "40012", Risk: "high", Confidence: "medium"},
+		{PluginID: "90022", Risk: "low", Confidence: "falsepositive"},
+	}
+	suppressed := []zapv1alpha1.Finding{{PluginID: "10020", Risk: "medium", Confidence: "high", SuppressedBy: "s1"}}
+	alerts := summarizeFindings(findings)
+	alerts.Suppressed = suppressed
+
+	if got := (&ScanReconciler{}).applyMinConfidence(alerts); got != alerts {
+		t.Errorf("expected alerts to be unchanged without a minimum confidence")
+	}
+
+	got := (&ScanReconciler{MinConfidence: "medium"}).applyMinConfidence(alerts)
+	if got.Total != 2 || got.ByRisk != (zapv1alpha1.RiskCounts{High: 1, Medium: 1}) {
+		t.Errorf("unexpected counts total=%d byRisk=%+v", got.Total, got.ByRisk)
+	}
+	if got.ByConfidence != (zapv1alpha1.ConfidenceCounts{High: 1, Medium: 1}) {
+		t.Errorf("unexpected confidence counts %+v", got.ByConfidence)
+	}
+	if len(got.ByPlugin) != 2 || got.ByPlugin[0].Confidence != "high" || got.ByPlugin[1].Confidence != "medium" {
+		t.Errorf("expected per-plugin counts to carry the confidence, got %+v", got.ByPlugin)
+	}
+	if len(got.LowConfidence) != 2 || len(got.Suppressed) != 1 {
+		t.Errorf("expected filtered findings to be kept aside, got low=%v suppressed=%v", got.LowConfidence, got.Suppressed)
+	}
+	if len(got.reportFindings()) != 5 {
+		t.Errorf("expected every finding in the report, got %d", len(got.reportFindings()))
+	}
+}
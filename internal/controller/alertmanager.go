@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/alertmanager"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+const (
+	// alertmanagerAlertName is the alertname of every alert the operator posts.
+	alertmanagerAlertName = "ZapFinding"
+
+	defaultAlertmanagerMinRisk  = "medium"
+	defaultAlertmanagerAlertTTL = 48 * time.Hour
+
+	// maxAlertURLs caps the URLs listed in an alert's description.
+	maxAlertURLs = 5
+)
+
+// postAlerts fires an Alertmanager alert per plugin for a finished scan's
+// findings of AlertmanagerMinRisk or higher, and resolves the alerts of
+// earlier scans of the same target the scan no longer reports. Failures are
+// logged and don't fail the scan.
+func (r *ScanReconciler) postAlerts(ctx context.Context, scan *zapv1alpha1.ZapScan, alerts *parsedAlerts) {
+	if r.Alertmanager == nil || alerts == nil || scan.Status.Phase != "Succeeded" {
+		// The findings of a crashed scan are missing, not fixed; posting
+		// nothing would resolve every firing alert of the target.
+		return
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, alertmanager.DefaultTimeout)
+	defer cancel()
+	if err := r.syncAlerts(sendCtx, scan, alerts.Findings); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to post alerts to Alertmanager")
+	}
+}
+
+func (r *ScanReconciler) syncAlerts(ctx context.Context, scan *zapv1alpha1.ZapScan, findings []zapv1alpha1.Finding) error {
+	minRisk, ttl := r.AlertmanagerMinRisk, r.AlertmanagerAlertTTL
+	if minRisk == "" {
+		minRisk = defaultAlertmanagerMinRisk
+	}
+	if ttl <= 0 {
+		ttl = defaultAlertmanagerAlertTTL
+	}
+	scope := map[string]string{
+		"alertname": alertmanagerAlertName,
+		"namespace": scan.Namespace,
+		"target":    scan.Spec.Target,
+	}
+
+	firing := findingAlerts(scan, findings, minRisk, scope)
+	now := time.Now()
+	for i := range firing {
+		// Alerts not posted again by a later scan resolve themselves after ttl.
+		firing[i].EndsAt = now.Add(ttl)
+	}
+
+	active, err := r.Alertmanager.Active(ctx, scope)
+	if err != nil {
+		return err
+	}
+	current := map[string]bool{}
+	for _, a := range firing {
+		current[a.Labels["plugin"]+"/"+a.Labels["risk"]] = true
+	}
+	var resolved []alertmanager.Alert
+	for _, a := range active {
+		if current[a.Labels["plugin"]+"/"+a.Labels["risk"]] {
+			continue
+		}
+		a.EndsAt = now
+		resolved = append(resolved, a)
+	}
+
+	if err := r.Alertmanager.Post(ctx, append(firing, resolved...)); err != nil {
+		return err
+	}
+	if len(firing) > 0 || len(resolved) > 0 {
+		ctrl.LoggerFrom(ctx).Info("posted alerts to Alertmanager", "firing", len(firing), "resolved", len(resolved))
+	}
+	return nil
+}
+
+// findingAlerts returns one alert per plugin with findings of minRisk or
+// higher, labelled with scope and the plugin's highest risk.
+func findingAlerts(scan *zapv1alpha1.ZapScan, findings []zapv1alpha1.Finding, minRisk string, scope map[string]string) []alertmanager.Alert {
+	type group struct {
+		name  string
+		risk  string
+		count int
+		urls  []string
+	}
+	groups := map[string]*group{}
+	for _, f := range findings {
+		if risk.Rank(f.Risk) < risk.Rank(minRisk) {
+			continue
+		}
+		g := groups[f.PluginID]
+		if g == nil {
+			g = &group{name: f.Name, risk: f.Risk}
+			groups[f.PluginID] = g
+		}
+		if risk.Rank(f.Risk) > risk.Rank(g.risk) {
+			g.name, g.risk = f.Name, f.Risk
+		}
+		g.count += int(f.Count)
+		for _, in := range f.Instances {
+			if len(g.urls) < maxAlertURLs && in.URL != "" {
+				g.urls = append(g.urls, in.URL)
+			}
+		}
+	}
+
+	out := make([]alertmanager.Alert, 0, len(groups))
+	for _, id := range sortedKeys(groups) {
+		g := groups[id]
+		labels := map[string]string{"plugin": id, "risk": g.risk}
+		for k, v := range scope {
+			labels[k] = v
+		}
+		description := fmt.Sprintf("ZAP found %d instances of %s on %s", g.count, g.name, scan.Spec.Target)
+		if len(g.urls) > 0 {
+			description += ", e.g. " + strings.Join(g.urls, ", ")
+		}
+		out = append(out, alertmanager.Alert{
+			Labels: labels,
+			Annotations: map[string]string{
+				"summary":     g.name,
+				"description": description,
+				"scan":        scan.Name,
+				"instances":   strconv.Itoa(g.count),
+			},
+		})
+	}
+	return out
+}
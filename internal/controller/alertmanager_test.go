@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/alertmanager"
+)
+
+// fakeAlertmanager keeps the alerts posted to it, keyed by plugin, and lists
+// the ones that haven't ended yet as active.
+type fakeAlertmanager struct {
+	mu     sync.Mutex
+	alerts map[string]alertmanager.Alert
+	posts  int
+}
+
+func (am *fakeAlertmanager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if r.Method == http.MethodPost {
+		var in []alertmanager.Alert
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		for _, a := range in {
+			am.alerts[a.Labels["plugin"]] = a
+		}
+		am.posts++
+		return
+	}
+	var active []alertmanager.Alert
+	for _, a := range am.alerts {
+		if a.EndsAt.After(time.Now()) {
+			active = append(active, a)
+		}
+	}
+	_ = json.NewEncoder(w).Encode(active)
+}
+
+func TestScanReconciler_PostsAlerts(t *testing.T) {
+	am := &fakeAlertmanager{alerts: map[string]alertmanager.Alert{}}
+	srv := httptest.NewServer(am)
+	defer srv.Close()
+
+	r := &ScanReconciler{Alertmanager: &alertmanager.Client{URL: srv.URL}, AlertmanagerAlertTTL: time.Hour}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	scan := &zapv1alpha1.ZapScan{}
+	scan.Name, scan.Namespace, scan.Spec.Target = "s1", "ns1", "https://example.com"
+	scan.Status.Phase = "Succeeded"
+
+	findings := []zapv1alpha1.Finding{
+		{PluginID: "40012", Name: "Cross Site Scripting", Risk: "high", Confidence: "medium", Count: 1, Instances: []zapv1alpha1.FindingInstance{{URL: "https://example.com/search"}}},
+		{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", Confidence: "high", Count: 2},
+		{PluginID: "10096", Name: "Timestamp Disclosure", Risk: "low", Confidence: "low", Count: 4},
+	}
+	r.postAlerts(ctx, scan, &parsedAlerts{Findings: findings})
+	if len(am.alerts) != 2 {
+		t.Fatalf("expected alerts for the medium and high findings, got %v", am.alerts)
+	}
+	xss := am.alerts["40012"]
+	for k, v := range map[string]string{"alertname": alertmanagerAlertName, "namespace": "ns1", "target": "https://example.com", "risk": "high"} {
+		if xss.Labels[k] != v {
+			t.Errorf("expected label %s=%q, got %v", k, v, xss.Labels)
+		}
+	}
+	if !strings.Contains(xss.Annotations["description"], "https://example.com/search") || xss.Annotations["summary"] != "Cross Site Scripting" {
+		t.Errorf("unexpected annotations %v", xss.Annotations)
+	}
+	if d := time.Until(xss.EndsAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expected the alert to end after the TTL, got %v", xss.EndsAt)
+	}
+
+	// A later scan no longer finds the XSS, so its alert is resolved.
+	scan.Name = "s2"
+	r.postAlerts(ctx, scan, &parsedAlerts{Findings: findings[1:]})
+	if a := am.alerts["40012"]; a.EndsAt.After(time.Now()) {
+		t.Errorf("expected the fixed finding's alert to be resolved, got %v", a.EndsAt)
+	}
+	if a := am.alerts["10038"]; !a.EndsAt.After(time.Now()) || a.Annotations["scan"] != "s2" {
+		t.Errorf("expected the persisting finding's alert to keep firing, got %+v", a)
+	}
+
+	// Without a parsed report, or after a crash, nothing is posted or resolved.
+	posts := am.posts
+	r.postAlerts(ctx, scan, nil)
+	scan.Status.Phase = "Failed"
+	r.postAlerts(ctx, scan, &parsedAlerts{})
+	if am.posts != posts || !am.alerts["10038"].EndsAt.After(time.Now()) {
+		t.Errorf("expected no alerts to be posted for an unparsed report or a failed scan")
+	}
+}
+
+func TestScanReconciler_CrashedScanKeepsAlertsFiring(t *testing.T) {
+	am := &fakeAlertmanager{alerts: map[string]alertmanager.Alert{
+		"10038": {Labels: map[string]string{"alertname": alertmanagerAlertName, "plugin": "10038", "risk": "medium"}, EndsAt: time.Now().Add(time.Hour)},
+	}}
+	srv := httptest.NewServer(am)
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}}
+
+	// ZAP crashed before the reporter printed the report.
+	r := &ScanReconciler{
+		Client:       fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod).Build(),
+		Scheme:       s,
+		Alertmanager: &alertmanager.Client{URL: srv.URL},
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte("java.lang.OutOfMemoryError\n"), nil
+		}),
+	}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "s1", Namespace: "ns1"}}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if am.posts != 0 || !am.alerts["10038"].EndsAt.After(time.Now()) {
+		t.Errorf("expected the firing alert to be left alone, got %d posts", am.posts)
+	}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"path"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/cloudevents"
+)
+
+// sendCloudEvent sends a CloudEvent of type typ about scan. Events are best
+// effort: a failed delivery is logged and not retried, so it never holds up
+// the scan.
+func (r *ScanReconciler) sendCloudEvent(ctx context.Context, scan *zapv1alpha1.ZapScan, typ string) {
+	if r.CloudEvents == nil {
+		return
+	}
+	data := zapv1alpha1.ScanEventData{
+		Namespace:     scan.Namespace,
+		Scan:          scan.Name,
+		UID:           string(scan.UID),
+		ScheduledScan: scan.Labels[scheduledScanLabel],
+		Labels:        scan.Labels,
+		Target:        scan.Spec.Target,
+		JobName:       scan.Status.JobName,
+		JobNamespace:  jobNamespaceFor(scan.Namespace, scan.Spec.JobNamespace),
+		Phase:         scan.Status.Phase,
+		Outcome:       scan.Status.Outcome,
+		StartedAt:     scan.Status.StartedAt,
+		FinishedAt:    scan.Status.FinishedAt,
+		AlertsFound:   scan.Status.AlertsFound,
+		AlertsByRisk:  scan.Status.AlertsByRisk,
+		LastError:     scan.Status.LastError,
+	}
+	if typ == zapv1alpha1.CloudEventHighRiskAlerts {
+		for _, a := range scan.Status.TopAlerts {
+			if a.Risk == "high" {
+				data.Alerts = append(data.Alerts, a)
+			}
+		}
+	}
+
+	// The ID only depends on the scan and type, so consumers can drop
+	// duplicates sent when a reconcile is retried.
+	ev := &cloudevents.Event{
+		ID:      string(scan.UID) + "/" + typ,
+		Source:  path.Join("/apis", zapv1alpha1.GroupVersion.String(), "namespaces", scan.Namespace, "zapscans"),
+		Type:    typ,
+		Subject: scan.Name,
+		Time:    time.Now(),
+		Data:    data,
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, cloudevents.DefaultTimeout)
+	defer cancel()
+	if err := r.CloudEvents.Send(sendCtx, ev); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to send CloudEvent", "type", typ)
+	}
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/cloudevents"
+)
+
+func TestScanReconciler_SendsCloudEvents(t *testing.T) {
+	ctx := context.Background()
+
+	type received struct {
+		ID      string                    `json:"id"`
+		Source  string                    `json:"source"`
+		Type    string                    `json:"type"`
+		Subject string                    `json:"subject"`
+		Data    zapv1alpha1.ScanEventData `json:"data"`
+	}
+	var mu sync.Mutex
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev received
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		mu.Lock()
+		got = append(got, ev)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	sentTypes := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var out []string
+		for _, ev := range got {
+			out = append(out, ev.Type)
+		}
+		return out
+	}
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", UID: "uid-1", CreationTimestamp: metav1.NewTime(time.Unix(1700000000, 0))},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+	}
+	report := `{"site":[{"alerts":[{"pluginid":"40012","alert":"Cross Site Scripting","riskcode":"3","confidence":"2","instances":[{"uri":"https://example.com/"}]}]}]}`
+	r := &ScanReconciler{
+		Client:      fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan).Build(),
+		Scheme:      s,
+		CloudEvents: &cloudevents.Sender{Endpoint: srv.URL},
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + report + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+	lctx := ctrl.LoggerInto(ctx, ctrl.Log.WithName("test"))
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scan)}
+
+	if _, err := r.Reconcile(lctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, req.NamespacedName, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	jobNN := types.NamespacedName{Name: updated.Status.JobName, Namespace: "ns1"}
+
+	// The running event is only sent once the pod is ready, and only once.
+	if _, err := r.Reconcile(lctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var job batchv1.Job
+	if err := r.Get(ctx, jobNN, &job); err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	job.Status.Active, job.Status.Ready = 1, ptr[int32](1)
+	if err := r.Status().Update(ctx, &job); err != nil {
+		t.Fatalf("update job: %v", err)
+	}
+	for range 2 {
+		if _, err := r.Reconcile(lctx, req); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+	}
+
+	job.Status.Active, job.Status.Ready = 0, ptr[int32](0)
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+	if err := r.Status().Update(ctx, &job); err != nil {
+		t.Fatalf("update job: %v", err)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": job.Name}}}
+	if err := r.Create(ctx, pod); err != nil {
+		t.Fatalf("create pod: %v", err)
+	}
+	if _, err := r.Reconcile(lctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	want := []string{
+		zapv1alpha1.CloudEventJobCreated,
+		zapv1alpha1.CloudEventJobRunning,
+		zapv1alpha1.CloudEventScanFinished,
+		zapv1alpha1.CloudEventHighRiskAlerts,
+	}
+	if gotTypes := sentTypes(); len(gotTypes) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, gotTypes)
+	}
+	for i, ev := range got {
+		if ev.Type != want[i] {
+			t.Errorf("event %d: expected type %s, got %s", i, want[i], ev.Type)
+		}
+		if ev.ID != "uid-1/"+want[i] || ev.Source != "/apis/spaceship.com/v1alpha1/namespaces/ns1/zapscans" || ev.Subject != "s1" {
+			t.Errorf("event %d: unexpected attributes id=%q source=%q subject=%q", i, ev.ID, ev.Source, ev.Subject)
+		}
+		if ev.Data.Scan != "s1" || ev.Data.JobName != job.Name || ev.Data.Target != "https://example.com" {
+			t.Errorf("event %d: unexpected data %+v", i, ev.Data)
+		}
+	}
+	if finished := got[2].Data; finished.Phase != "Succeeded" || finished.AlertsFound != 1 || finished.AlertsByRisk == nil || finished.AlertsByRisk.High != 1 {
+		t.Errorf("unexpected finished event data %+v", finished)
+	}
+	if alerts := got[3].Data.Alerts; len(alerts) != 1 || alerts[0].PluginID != "40012" {
+		t.Errorf("expected the high risk alert in the event, got %+v", alerts)
+	}
+}
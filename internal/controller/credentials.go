@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+// checkSecretURL returns an error unless secret allows its credentials to be
+// sent to rawURL in its allowed-urls annotation.
+func checkSecretURL(secret *corev1.Secret, rawURL string) error {
+	origin, err := urlOrigin(rawURL)
+	if err != nil {
+		return err
+	}
+	for _, allowed := range strings.Split(secret.Annotations[zapv1alpha1.AnnotationAllowedURLs], ",") {
+		if o, err := urlOrigin(strings.TrimSpace(allowed)); err == nil && o == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret %s doesn't allow sending its credentials to %s; add it to the %s annotation", secret.Name, origin, zapv1alpha1.AnnotationAllowedURLs)
+}
+
+// urlOrigin returns the scheme, host and port of rawURL, without the
+// scheme's default port.
+func urlOrigin(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL", rawURL)
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	if p := u.Port(); (scheme == "https" && p == "443") || (scheme == "http" && p == "80") {
+		host = strings.TrimSuffix(host, ":"+p)
+	}
+	return scheme + "://" + host, nil
+}
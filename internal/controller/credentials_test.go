@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestCheckSecretURL(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "dojo", Annotations: map[string]string{
+		zapv1alpha1.AnnotationAllowedURLs: "https://defectdojo.example.com, http://dojo.tools.svc:8080/api",
+	}}}
+	for rawURL, want := range map[string]string{
+		"https://defectdojo.example.com":            "",
+		"https://DefectDojo.example.com:443/api/v2": "",
+		"http://dojo.tools.svc:8080":                "",
+		"http://defectdojo.example.com":             "doesn't allow sending its credentials to http://defectdojo.example.com",
+		"https://defectdojo.example.com:8443":       "doesn't allow",
+		"https://attacker.example.com":              "doesn't allow",
+		"defectdojo.example.com":                    "not an absolute URL",
+	} {
+		err := checkSecretURL(secret, rawURL)
+		if want == "" && err != nil {
+			t.Errorf("%s: expected to be allowed, got %v", rawURL, err)
+		}
+		if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: expected an error containing %q, got %v", rawURL, want, err)
+		}
+	}
+
+	if err := checkSecretURL(&corev1.Secret{}, "https://defectdojo.example.com"); err == nil {
+		t.Errorf("expected a Secret without the annotation not to allow anything")
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/defectdojo"
+)
+
+// defectDojoAPIKey is the key of the DefectDojo Secret holding the API key.
+const defectDojoAPIKey = "apiKey"
+
+// importToDefectDojo imports the report of a finished scan into DefectDojo
+// according to spec.defectDojo and records the outcome in status. Failures
+// don't fail the scan.
+func (r *ScanReconciler) importToDefectDojo(ctx context.Context, scan *zapv1alpha1.ZapScan, alerts *parsedAlerts, generated map[string][]byte) {
+	cfg := scan.Spec.DefectDojo
+	if cfg == nil {
+		return
+	}
+
+	// Reimporting the empty report of a crashed scan would close every
+	// finding of the test, so only complete reports are imported.
+	var skip string
+	switch {
+	case scan.Status.Phase != "Succeeded":
+		skip = "the scan failed"
+	case alerts == nil:
+		skip = "the scan report wasn't parsed"
+	}
+	if skip != "" {
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionDefectDojoImported,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonDefectDojoImportSkipped,
+			Message: "not imported because " + skip,
+		})
+		return
+	}
+
+	res, err := r.defectDojoImport(ctx, scan, cfg, generated)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to import scan report into DefectDojo", "product", cfg.ProductName)
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionDefectDojoImported,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonDefectDojoImportFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionDefectDojoImported,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonDefectDojoImported,
+		Message: fmt.Sprintf("imported into test %d of product %s, engagement %s", res.Test, cfg.ProductName, cfg.EngagementName),
+	})
+}
+
+func (r *ScanReconciler) defectDojoImport(ctx context.Context, scan *zapv1alpha1.ZapScan, cfg *zapv1alpha1.DefectDojo, generated map[string][]byte) (*defectdojo.Result, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: scan.Namespace, Name: cfg.APIKeySecret}, &secret); err != nil {
+		return nil, fmt.Errorf("get DefectDojo API key: %w", err)
+	}
+	if err := checkSecretURL(&secret, cfg.URL); err != nil {
+		return nil, err
+	}
+	apiKey := string(secret.Data[defectDojoAPIKey])
+	if apiKey == "" {
+		return nil, fmt.Errorf("secret %s has no %s key", cfg.APIKeySecret, defectDojoAPIKey)
+	}
+
+	// Runs always import the SARIF log, so a test isn't fed ZAP's XML report
+	// by some runs and SARIF by others, which would close and reopen every
+	// finding as DefectDojo matches them differently.
+	data, ok := generated[sarifReportFile]
+	if !ok {
+		return nil, fmt.Errorf("no report to import")
+	}
+	im := &defectdojo.Import{
+		Reimport:          cfg.Mode != zapv1alpha1.DefectDojoImport,
+		ProductName:       cfg.ProductName,
+		ProductTypeName:   cfg.ProductTypeName,
+		EngagementName:    cfg.EngagementName,
+		TestTitle:         defectDojoTestTitle(scan),
+		AutoCreateContext: cfg.AutoCreateContext,
+		CloseOldFindings:  cfg.CloseOldFindings == nil || *cfg.CloseOldFindings,
+		MinimumSeverity:   cfg.MinimumSeverity,
+		ScanDate:          time.Now(),
+		Tags:              []string{"zap-operator", scan.Namespace},
+		ScanType:          defectdojo.ScanTypeSARIF,
+		FileName:          sarifReportFile,
+		Report:            data,
+	}
+	if scan.Status.FinishedAt != nil {
+		im.ScanDate = scan.Status.FinishedAt.Time
+	}
+
+	c := &defectdojo.Client{URL: cfg.URL, APIKey: apiKey}
+	return c.Import(ctx, im)
+}
+
+// defectDojoTestTitle returns the title of the DefectDojo test a scan's
+// report is imported into. Runs of a ZapScheduledScan share their test.
+func defectDojoTestTitle(scan *zapv1alpha1.ZapScan) string {
+	if t := scan.Spec.DefectDojo.TestTitle; t != "" {
+		return t
+	}
+	if sched := scan.Labels[scheduledScanLabel]; sched != "" {
+		return sched
+	}
+	return scan.Name
+}
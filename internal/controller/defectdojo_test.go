@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+func TestScanReconciler_ImportsIntoDefectDojo(t *testing.T) {
+	type request struct {
+		path, auth, scanType, testTitle, closeOld, fileName, file string
+	}
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("form file: %v", err)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		got = append(got, request{
+			path:      r.URL.Path,
+			auth:      r.Header.Get("Authorization"),
+			scanType:  r.FormValue("scan_type"),
+			testTitle: r.FormValue("test_title"),
+			closeOld:  r.FormValue("close_old_findings"),
+			fileName:  h.Filename,
+			file:      string(b),
+		})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"test": 42, "engagement": 7, "product": 3}`))
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	newScan := func(name string, labels map[string]string, dd *zapv1alpha1.DefectDojo) (*zapv1alpha1.ZapScan, *batchv1.Job, *corev1.Pod) {
+		jobName := scanJobNameWithTimestamp(name, creationTime.Time)
+		scan := &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", Labels: labels, CreationTimestamp: creationTime},
+			Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com", DefectDojo: dd},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+		}
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+			},
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name + "-pod", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}}
+		return scan, job, pod
+	}
+	dd := zapv1alpha1.DefectDojo{URL: srv.URL, APIKeySecret: "dojo", ProductName: "shop", EngagementName: "zap"}
+	importMode := dd
+	importMode.Mode = zapv1alpha1.DefectDojoImport
+	missingKey := dd
+	missingKey.APIKeySecret = "missing"
+	elsewhere := dd
+	elsewhere.URL = "https://attacker.example.com"
+
+	uploaded, uploadedJob, uploadedPod := newScan("nightly-1", map[string]string{scheduledScanLabel: "nightly"}, &dd)
+	fromLogs, fromLogsJob, fromLogsPod := newScan("adhoc", nil, &importMode)
+	failing, failingJob, failingPod := newScan("failing", nil, &missingKey)
+	exfiltrating, exfiltratingJob, exfiltratingPod := newScan("exfiltrating", nil, &elsewhere)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dojo", Namespace: "ns1", Annotations: map[string]string{zapv1alpha1.AnnotationAllowedURLs: srv.URL}},
+		Data:       map[string][]byte{defectDojoAPIKey: []byte("k3y")},
+	}
+
+	store := results.NewStore()
+	store.Put(client.ObjectKeyFromObject(uploadedJob), "zap.json", []byte(sampleZapReport))
+	store.Put(client.ObjectKeyFromObject(uploadedJob), "zap.xml", []byte("<OWASPZAPReport/>"))
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).
+			WithObjects(uploaded, uploadedJob, uploadedPod, fromLogs, fromLogsJob, fromLogsPod, failing, failingJob, failingPod, exfiltrating, exfiltratingJob, exfiltratingPod, secret).Build(),
+		Scheme:  s,
+		Results: store,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	conditionOf := func(scan *zapv1alpha1.ZapScan) *metav1.Condition {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scan)}); err != nil {
+			t.Fatalf("reconcile %s: %v", scan.Name, err)
+		}
+		var updated zapv1alpha1.ZapScan
+		if err := r.Get(ctx, types.NamespacedName{Name: scan.Name, Namespace: "ns1"}, &updated); err != nil {
+			t.Fatalf("get scan: %v", err)
+		}
+		if updated.Status.Phase != "Succeeded" {
+			t.Errorf("expected %s to succeed regardless of the import, got %q", scan.Name, updated.Status.Phase)
+		}
+		return meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionDefectDojoImported)
+	}
+
+	// Runs of a schedule are reimported into one test. The SARIF log is
+	// imported even though ZAP's XML report was uploaded, so every run of
+	// the test imports the same format.
+	cond := conditionOf(uploaded)
+	if cond == nil || cond.Status != metav1.ConditionTrue || !strings.Contains(cond.Message, "test 42") {
+		t.Errorf("expected an imported condition, got %+v", cond)
+	}
+	if len(got) != 1 || !strings.Contains(got[0].file, "10038") {
+		t.Fatalf("expected the SARIF log to be imported, got %+v", got)
+	}
+	got[0].file = ""
+	want := request{path: "/api/v2/reimport-scan/", auth: "Token k3y", scanType: "SARIF", testTitle: "nightly", closeOld: "true", fileName: "zap.sarif"}
+	if got[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, got[0])
+	}
+
+	// Without uploads the SARIF log is imported too.
+	cond = conditionOf(fromLogs)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected an imported condition, got %+v", cond)
+	}
+	if len(got) != 2 || got[1].path != "/api/v2/import-scan/" || got[1].scanType != "SARIF" || got[1].testTitle != "adhoc" || !strings.Contains(got[1].file, "10038") {
+		t.Errorf("expected the SARIF log to be imported into a new test, got %+v", got[1:])
+	}
+
+	cond = conditionOf(failing)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != zapv1alpha1.ReasonDefectDojoImportFailed {
+		t.Errorf("expected a failed import condition, got %+v", cond)
+	}
+	if len(got) != 2 {
+		t.Errorf("expected no request without an API key, got %d", len(got))
+	}
+
+	// The API key isn't sent to a URL its Secret doesn't allow.
+	cond = conditionOf(exfiltrating)
+	if cond == nil || cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, "doesn't allow") {
+		t.Errorf("expected a failed import condition, got %+v", cond)
+	}
+}
+
+func TestScanReconciler_SkipsDefectDojoImport(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	r := &ScanReconciler{}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	dd := &zapv1alpha1.DefectDojo{URL: srv.URL, APIKeySecret: "dojo", ProductName: "shop", EngagementName: "zap"}
+	generated := map[string][]byte{sarifReportFile: []byte("{}")}
+
+	for name, tc := range map[string]struct {
+		phase  string
+		alerts *parsedAlerts
+		want   string
+	}{
+		"crashed":   {phase: "Failed", alerts: &parsedAlerts{}, want: "the scan failed"},
+		"no report": {phase: "Succeeded", want: "the scan report wasn't parsed"},
+	} {
+		scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"}, Spec: zapv1alpha1.ZapScanSpec{DefectDojo: dd}}
+		scan.Status.Phase = tc.phase
+		r.importToDefectDojo(ctx, scan, tc.alerts, generated)
+		cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionDefectDojoImported)
+		if cond == nil || cond.Reason != zapv1alpha1.ReasonDefectDojoImportSkipped || !strings.Contains(cond.Message, tc.want) {
+			t.Errorf("%s: expected a skipped import, got %+v", name, cond)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected nothing to be imported, got %d requests", requests)
+	}
+}
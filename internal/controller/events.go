@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+)
+
+// Event reasons emitted on ZapScans and ZapScheduledScans.
+const (
+	eventJobCreated        = "JobCreated"
+	eventScanSucceeded     = "ScanSucceeded"
+	eventScanFailed        = "ScanFailed"
+	eventReportParseFailed = "ReportParseFailed"
+	eventRedactionFallback = "RedactionFallback"
+	eventScanCreated       = "ScanCreated"
+	eventScheduleSkipped   = "ScheduleSkipped"
+	eventScanReplaced      = "ScanReplaced"
+)
+
+// maxEventNote is the longest note the events API accepts.
+const maxEventNote = 1024
+
+// recordEvent emits an event on obj, truncating long notes such as job
+// errors. It does nothing without a recorder, so reconcilers built in tests
+// don't need one.
+func recordEvent(rec events.EventRecorder, obj runtime.Object, eventtype, reason, action, note string, args ...any) {
+	if rec == nil {
+		return
+	}
+	msg := fmt.Sprintf(note, args...)
+	if len(msg) > maxEventNote {
+		msg = msg[:maxEventNote-3] + "..."
+	}
+	rec.Eventf(obj, nil, eventtype, reason, action, "%s", msg)
+}
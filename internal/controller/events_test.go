@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/events"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+// expectEvents checks that rec recorded exactly the events starting with
+// want, in order, as "<type> <reason> <note>".
+func expectEvents(t *testing.T, rec *events.FakeRecorder, want ...string) {
+	t.Helper()
+	var got []string
+	for len(rec.Events) > 0 {
+		got = append(got, <-rec.Events)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected events %q, got %q", want, got)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestRecordEvent(t *testing.T) {
+	scan := &zapv1alpha1.ZapScan{}
+
+	// Reconcilers without a recorder don't emit events.
+	recordEvent(nil, scan, "Normal", eventJobCreated, "CreateJob", "Created job %s", "j1")
+
+	rec := events.NewFakeRecorder(2)
+	recordEvent(rec, scan, "Warning", eventScanFailed, "CompleteScan", "Job %s failed: %s", "j1", strings.Repeat("x", 2000))
+	got := <-rec.Events
+	if note := strings.TrimPrefix(got, "Warning ScanFailed "); len(note) != maxEventNote || !strings.HasSuffix(note, "...") {
+		t.Errorf("expected the note to be truncated to %d bytes, got %d: %q", maxEventNote, len(note), got)
+	}
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+const (
+	// scheduledScanLabel names the ZapScheduledScan that created a ZapScan.
+	scheduledScanLabel = "spaceship.com/zapscheduledscan"
+
+	// maxDiffFindings caps each list of a FindingDiff; the counts still hold the totals.
+	maxDiffFindings = 50
+)
+
+// fingerprint identifies an alert instance across runs. Evidence and attack
+// strings change between runs, so only where the alert was raised is used.
+func fingerprint(pluginID, rawURL, param, method string) string {
+	key := strings.Join([]string{pluginID, normalizeFindingURL(rawURL), param, strings.ToUpper(method)}, "\x00")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// normalizeFindingURL lowercases the scheme and host, drops default ports,
+// fragments and credentials, and replaces the query with its sorted parameter
+// names, since query values often hold cache busters or session tokens.
+func normalizeFindingURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	names := make([]string, 0, len(u.Query()))
+	for name := range u.Query() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	u.RawQuery = strings.Join(names, "&")
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// diffEntries flattens findings into one entry per fingerprint, skipping
+// suppressed ones and those below minConfidence. Findings without instances
+// get a single entry without a URL.
+func diffEntries(findings []zapv1alpha1.Finding, minConfidence string) map[string]zapv1alpha1.DiffFinding {
+	entries := map[string]zapv1alpha1.DiffFinding{}
+	add := func(f *zapv1alpha1.Finding, in zapv1alpha1.FindingInstance) {
+		fp := fingerprint(f.PluginID, in.URL, in.Param, in.Method)
+		if prev, ok := entries[fp]; ok && risk.Rank(prev.Risk) >= risk.Rank(f.Risk) {
+			return
+		}
+		entries[fp] = zapv1alpha1.DiffFinding{
+			Fingerprint: fp,
+			PluginID:    f.PluginID,
+			Name:        f.Name,
+			Risk:        f.Risk,
+			URL:         in.URL,
+			Method:      in.Method,
+			Param:       in.Param,
+		}
+	}
+	for i := range findings {
+		f := &findings[i]
+		if f.SuppressedBy != "" {
+			continue
+		}
+		if minConfidence != "" && confidenceRank(f.Confidence) < confidenceRank(minConfidence) {
+			continue
+		}
+		if len(f.Instances) == 0 {
+			add(f, zapv1alpha1.FindingInstance{})
+		}
+		for _, in := range f.Instances {
+			add(f, in)
+		}
+	}
+	return entries
+}
+
+// diffFindings compares the findings of a run with those of baseline.
+// Both sides are filtered with the current minimum confidence, so changing it
+// doesn't make findings show up as new or fixed.
+func diffFindings(baseline string, previous, current []zapv1alpha1.Finding, minConfidence string) *zapv1alpha1.FindingDiff {
+	prev, cur := diffEntries(previous, minConfidence), diffEntries(current, minConfidence)
+	diff := &zapv1alpha1.FindingDiff{BaselineScan: baseline}
+
+	var added, fixed, persisting []zapv1alpha1.DiffFinding
+	for fp, e := range cur {
+		if _, ok := prev[fp]; ok {
+			persisting = append(persisting, e)
+			continue
+		}
+		added = append(added, e)
+		countRisk(&diff.NewByRisk, e.Risk, 1)
+	}
+	for fp, e := range prev {
+		if _, ok := cur[fp]; !ok {
+			fixed = append(fixed, e)
+		}
+	}
+
+	diff.New, diff.Fixed, diff.Persisting = int32(len(added)), int32(len(fixed)), int32(len(persisting))
+	diff.NewFindings = sortDiffFindings(added)
+	diff.FixedFindings = sortDiffFindings(fixed)
+	diff.PersistingFindings = sortDiffFindings(persisting)
+	return diff
+}
+
+// sortDiffFindings orders entries most severe first and truncates them to maxDiffFindings.
+func sortDiffFindings(entries []zapv1alpha1.DiffFinding) []zapv1alpha1.DiffFinding {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if risk.Rank(a.Risk) != risk.Rank(b.Risk) {
+			return risk.Rank(a.Risk) > risk.Rank(b.Risk)
+		}
+		if a.PluginID != b.PluginID {
+			return a.PluginID < b.PluginID
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	if len(entries) > maxDiffFindings {
+		entries = entries[:maxDiffFindings]
+	}
+	return entries
+}
+
+// previousRun returns the latest completed run of the schedule that created
+// scan, or nil if scan isn't scheduled or is its schedule's first run.
+// Runs whose report couldn't be parsed are skipped, as their findings are unknown.
+func (r *ScanReconciler) previousRun(ctx context.Context, scan *zapv1alpha1.ZapScan) (*zapv1alpha1.ZapScan, error) {
+	schedule := scan.Labels[scheduledScanLabel]
+	if schedule == "" {
+		return nil, nil
+	}
+
+	var runs zapv1alpha1.ZapScanList
+	if err := r.List(ctx, &runs, client.InNamespace(scan.Namespace), client.MatchingLabels{scheduledScanLabel: schedule}); err != nil {
+		return nil, err
+	}
+
+	var latest *zapv1alpha1.ZapScan
+	for i := range runs.Items {
+		run := &runs.Items[i]
+		if run.Name == scan.Name || !run.CreationTimestamp.Before(&scan.CreationTimestamp) {
+			continue
+		}
+		if run.Status.Phase != "Succeeded" && run.Status.Phase != "Failed" {
+			continue
+		}
+		if !meta.IsStatusConditionTrue(run.Status.Conditions, zapv1alpha1.ConditionReportParsed) {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&run.CreationTimestamp) {
+			latest = run
+		}
+	}
+	return latest, nil
+}
+
+// loadFindings reads a scan's findings back from its ZapScanReport pages.
+// It returns false if the scan has no pages left.
+func (r *ScanReconciler) loadFindings(ctx context.Context, namespace, scanName string) ([]zapv1alpha1.Finding, bool, error) {
+	var reports zapv1alpha1.ZapScanReportList
+	if err := r.List(ctx, &reports, client.InNamespace(namespace), client.MatchingLabels{"spaceship.com/scan-name": scanName}); err != nil {
+		return nil, false, err
+	}
+	if len(reports.Items) == 0 {
+		return nil, false, nil
+	}
+	sort.Slice(reports.Items, func(i, j int) bool { return reports.Items[i].Spec.Page < reports.Items[j].Spec.Page })
+
+	var findings []zapv1alpha1.Finding
+	for _, report := range reports.Items {
+		findings = append(findings, report.Spec.Findings...)
+	}
+	return findings, true, nil
+}
+
+// diffWithPreviousRun records in status what changed since the previous run
+// of the scan's schedule.
+func (r *ScanReconciler) diffWithPreviousRun(ctx context.Context, scan *zapv1alpha1.ZapScan, findings []zapv1alpha1.Finding) error {
+	prev, err := r.previousRun(ctx, scan)
+	if err != nil || prev == nil {
+		return err
+	}
+	previous, ok, err := r.loadFindings(ctx, prev.Namespace, prev.Name)
+	if err != nil || !ok {
+		return err
+	}
+	scan.Status.Diff = diffFindings(prev.Name, previous, findings, r.MinConfidence)
+	return nil
+}
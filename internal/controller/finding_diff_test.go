@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestNormalizeFindingURL(t *testing.T) {
+	cases := map[string]string{
+		"https://Example.COM:443/login#top":         "https://example.com/login",
+		"http://example.com:80":                     "http://example.com/",
+		"http://example.com:8080/a":                 "http://example.com:8080/a",
+		"https://user:pw@example.com/a?b=2&a=1&b=3": "https://example.com/a?a&b",
+		"https://example.com/search?q=1700000000":   "https://example.com/search?q",
+		"https://[::1]:443/":                        "https://[::1]/",
+		"not a url":                                 "not a url",
+		"":                                          "",
+	}
+	for in, want := range cases {
+		if got := normalizeFindingURL(in); got != want {
+			t.Errorf("%q: expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := fingerprint("10038", "https://example.com/search?q=1", "q", "get")
+	if b := fingerprint("10038", "https://EXAMPLE.com:443/search?q=2#x", "q", "GET"); a != b {
+		t.Errorf("expected equivalent instances to share a fingerprint, got %s and %s", a, b)
+	}
+	for _, other := range []string{
+		fingerprint("10039", "https://example.com/search?q=1", "q", "GET"),
+		fingerprint("10038", "https://example.com/other?q=1", "q", "GET"),
+		fingerprint("10038", "https://example.com/search?q=1", "p", "GET"),
+		fingerprint("10038", "https://example.com/search?q=1", "q", "POST"),
+	} {
+		if other == a {
+			t.Errorf("expected a different fingerprint than %s", a)
+		}
+	}
+}
+
+func TestDiffFindings(t *testing.T) {
+	previous := []zapv1alpha1.Finding{
+		{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/", Method: "GET"},
+			{URL: "https://example.com/old", Method: "GET"},
+		}},
+		{PluginID: "40012", Name: "Cross Site Scripting", Risk: "high"},
+	}
+	current := []zapv1alpha1.Finding{
+		{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/#main", Method: "GET"},
+			{URL: "https://example.com/new", Method: "GET"},
+		}},
+		{PluginID: "90022", Name: "Application Error Disclosure", Risk: "low", Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/err", Method: "GET"},
+		}},
+	}
+
+	diff := diffFindings("nightly-1", previous, current, "")
+	if diff.BaselineScan != "nightly-1" || diff.New != 2 || diff.Fixed != 2 || diff.Persisting != 1 {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+	if diff.NewByRisk != (zapv1alpha1.RiskCounts{Medium: 1, Low: 1}) {
+		t.Errorf("unexpected new risk counts %+v", diff.NewByRisk)
+	}
+	if diff.NewFindings[0].URL != "https://example.com/new" || diff.NewFindings[1].PluginID != "90022" {
+		t.Errorf("expected new findings most severe first, got %+v", diff.NewFindings)
+	}
+	if diff.FixedFindings[0].PluginID != "40012" || diff.FixedFindings[0].URL != "" {
+		t.Errorf("expected fixed findings most severe first, got %+v", diff.FixedFindings)
+	}
+	if diff.PersistingFindings[0].URL != "https://example.com/#main" {
+		t.Errorf("expected persisting finding to carry the current URL, got %+v", diff.PersistingFindings)
+	}
+}
+
+func TestDiffFindings_Truncates(t *testing.T) {
+	var current []zapv1alpha1.Finding
+	for i := 0; i < maxDiffFindings+10; i++ {
+		current = append(current, zapv1alpha1.Finding{PluginID: "10038", Risk: "medium", Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/" + string(rune('a'+i%26)) + string(rune('a'+i/26)), Method: "GET"},
+		}})
+	}
+
+	diff := diffFindings("nightly-1", nil, current, "")
+	if diff.New != int32(maxDiffFindings+10) || len(diff.NewFindings) != maxDiffFindings {
+		t.Errorf("expected %d new findings with %d listed, got %d with %d listed", maxDiffFindings+10, maxDiffFindings, diff.New, len(diff.NewFindings))
+	}
+}
+
+func TestScanReconciler_DiffsWithPreviousRun(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	labels := map[string]string{scheduledScanLabel: "nightly"}
+	parsed := []metav1.Condition{{Type: zapv1alpha1.ConditionReportParsed, Status: metav1.ConditionTrue, Reason: zapv1alpha1.ReasonReportParsed}}
+	run := func(name string, unix int64, phase string, conditions []metav1.Condition) *zapv1alpha1.ZapScan {
+		return &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", Labels: labels, CreationTimestamp: metav1.NewTime(time.Unix(unix, 0))},
+			Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: phase, Conditions: conditions},
+		}
+	}
+	older := run("nightly-1", 1699800000, "Succeeded", parsed)
+	previous := run("nightly-2", 1699900000, "Succeeded", parsed)
+	// A later run whose report couldn't be parsed isn't a usable baseline.
+	broken := run("nightly-3", 1699950000, "Failed", nil)
+	// Another schedule's runs are ignored.
+	other := run("weekly-1", 1699960000, "Succeeded", parsed)
+	other.Labels = map[string]string{scheduledScanLabel: "weekly"}
+
+	report := func(scanName string, findings ...zapv1alpha1.Finding) *zapv1alpha1.ZapScanReport {
+		return &zapv1alpha1.ZapScanReport{
+			ObjectMeta: metav1.ObjectMeta{Name: scanReportName(scanName, 1), Namespace: "ns1", Labels: map[string]string{"spaceship.com/scan-name": scanName}},
+			Spec:       zapv1alpha1.ZapScanReportSpec{ScanName: scanName, Page: 1, Pages: 1, Findings: findings},
+		}
+	}
+	xss := zapv1alpha1.Finding{PluginID: "40012", Name: "Cross Site Scripting", Risk: "high"}
+	csp := zapv1alpha1.Finding{PluginID: "10038", Name: "Content Security Policy (CSP) Header Not Set", Risk: "medium",
+		Instances: []zapv1alpha1.FindingInstance{{URL: "https://EXAMPLE.com:443/", Method: "GET"}}}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("nightly-4", creationTime.Time)
+	scan := run("nightly-4", creationTime.Unix(), "Running", nil)
+	scan.UID = "uid-4"
+	scan.Status.JobName = jobName
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).
+			WithObjects(scan, job, pod, older, previous, broken, other,
+				report("nightly-1"), report("nightly-2", xss, csp), report("weekly-1")).
+			Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	diff := updated.Status.Diff
+	if diff == nil {
+		t.Fatalf("expected a diff with the previous run")
+	}
+	if diff.BaselineScan != "nightly-2" {
+		t.Errorf("expected nightly-2 as baseline, got %q", diff.BaselineScan)
+	}
+	if diff.New != 2 || diff.Fixed != 1 || diff.Persisting != 1 {
+		t.Errorf("unexpected counts new=%d fixed=%d persisting=%d", diff.New, diff.Fixed, diff.Persisting)
+	}
+	if diff.NewByRisk != (zapv1alpha1.RiskCounts{Medium: 1, Informational: 1}) {
+		t.Errorf("unexpected new risk counts %+v", diff.NewByRisk)
+	}
+	if len(diff.FixedFindings) != 1 || diff.FixedFindings[0].PluginID != "40012" {
+		t.Errorf("unexpected fixed findings %+v", diff.FixedFindings)
+	}
+}
+
+func TestScanReconciler_NoDiffForFirstOrUnscheduledRun(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	first := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "nightly-1", Namespace: "ns1", Labels: map[string]string{scheduledScanLabel: "nightly"}}}
+	adhoc := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "adhoc", Namespace: "ns1"}}
+	r := &ScanReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(first, adhoc).Build(), Scheme: s}
+
+	for _, scan := range []*zapv1alpha1.ZapScan{first, adhoc} {
+		if err := r.diffWithPreviousRun(ctx, scan, nil); err != nil {
+			t.Fatalf("%s: unexpected error: %v", scan.Name, err)
+		}
+		if scan.Status.Diff != nil {
+			t.Errorf("%s: expected no diff, got %+v", scan.Name, scan.Status.Diff)
+		}
+	}
+}
+
+func TestDiffFindings_MinConfidence(t *testing.T) {
+	previous := []zapv1alpha1.Finding{{PluginID: "10096", Risk: "informational", Confidence: "low"}}
+	current := []zapv1alpha1.Finding{
+		{PluginID: "10038", Risk: "medium", Confidence: "low"},
+		{PluginID: "40012", Risk: "high", Confidence: "high", SuppressedBy: "accepted-xss"},
+	}
+
+	diff := diffFindings("nightly-1", previous, current, "medium")
+	if diff.New != 0 || diff.Fixed != 0 || diff.Persisting != 0 {
+		t.Errorf("expected low confidence and suppressed findings to be ignored, got %+v", diff)
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+const (
+	// maxFindingInstances caps the instances kept per finding; Count still holds the total.
+	maxFindingInstances = 100
+
+	// maxEvidenceBytes caps the evidence kept per instance.
+	maxEvidenceBytes = 512
+
+	// maxReportPageBytes keeps each ZapScanReport comfortably below the 1.5MiB object size limit.
+	maxReportPageBytes = 800 << 10
+)
+
+// newFinding normalizes a ZAP alert into the ZapScanReport schema.
+func newFinding(a *zapJSONAlert) zapv1alpha1.Finding {
+	f := zapv1alpha1.Finding{
+		PluginID:   a.PluginID,
+		AlertRef:   a.AlertRef,
+		Name:       a.Name,
+		Risk:       normalizeRisk(a.RiskCode),
+		Confidence: normalizeConfidence(a.Confidence),
+		CWEID:      parseZapID(a.CWEID),
+		WASCID:     parseZapID(a.WASCID),
+		Count:      parseZapID(a.Count),
+	}
+	if f.Count == 0 {
+		f.Count = int32(len(a.Instances))
+	}
+	for i, in := range a.Instances {
+		if i == maxFindingInstances {
+			break
+		}
+		f.Instances = append(f.Instances, zapv1alpha1.FindingInstance{
+			URL:      in.URI,
+			Method:   in.Method,
+			Param:    in.Param,
+			Attack:   truncate(in.Attack, maxEvidenceBytes),
+			Evidence: truncate(in.Evidence, maxEvidenceBytes),
+		})
+	}
+	return f
+}
+
+func normalizeConfidence(code string) string {
+	switch strings.TrimSpace(code) {
+	case "0":
+		return "falsepositive"
+	case "1":
+		return "low"
+	case "2":
+		return "medium"
+	case "3":
+		return "high"
+	case "4":
+		return "confirmed"
+	default:
+		return code
+	}
+}
+
+// parseZapID parses the numeric strings ZAP uses for IDs and counts.
+// Missing or unknown values (ZAP uses -1) become 0.
+func parseZapID(s string) int32 {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return int32(n)
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// paginateFindings splits findings into pages whose encoded size stays below maxBytes.
+// A scan without findings still gets a single empty page.
+func paginateFindings(findings []zapv1alpha1.Finding, maxBytes int) ([][]zapv1alpha1.Finding, error) {
+	var pages [][]zapv1alpha1.Finding
+	var page []zapv1alpha1.Finding
+	size := 0
+	for _, f := range findings {
+		b, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) > 0 && size+len(b) > maxBytes {
+			pages = append(pages, page)
+			page, size = nil, 0
+		}
+		page = append(page, f)
+		size += len(b)
+	}
+	return append(pages, page), nil
+}
+
+// scanReportName returns the name of the page-th ZapScanReport of a scan.
+func scanReportName(scanName string, page int) string {
+	return fmt.Sprintf("%s-findings-%d", scanName, page)
+}
+
+// writeScanReports creates or updates the ZapScanReport pages of a scan and
+// removes pages left over from a previous, larger report.
+func (r *ScanReconciler) writeScanReports(ctx context.Context, scan *zapv1alpha1.ZapScan, findings []zapv1alpha1.Finding) error {
+	pages, err := paginateFindings(findings, maxReportPageBytes)
+	if err != nil {
+		return err
+	}
+
+	for i, page := range pages {
+		report := &zapv1alpha1.ZapScanReport{
+			ObjectMeta: metav1.ObjectMeta{Name: scanReportName(scan.Name, i+1), Namespace: scan.Namespace},
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, r.Client, report, func() error {
+			if report.Labels == nil {
+				report.Labels = map[string]string{}
+			}
+			report.Labels["app.kubernetes.io/name"] = "zap-operator"
+			report.Labels["spaceship.com/scan-name"] = scan.Name
+			report.Spec = zapv1alpha1.ZapScanReportSpec{
+				ScanName: scan.Name,
+				Target:   scan.Spec.Target,
+				Page:     int32(i + 1),
+				Pages:    int32(len(pages)),
+				Findings: page,
+			}
+			return controllerutil.SetControllerReference(scan, report, r.Scheme)
+		})
+		if err != nil {
+			return fmt.Errorf("write %s: %w", report.Name, err)
+		}
+	}
+
+	var existing zapv1alpha1.ZapScanReportList
+	if err := r.List(ctx, &existing, client.InNamespace(scan.Namespace), client.MatchingLabels{"spaceship.com/scan-name": scan.Name}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		if existing.Items[i].Spec.Page > int32(len(pages)) {
+			if err := r.Delete(ctx, &existing.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
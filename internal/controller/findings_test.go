@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+const sampleZapReport = `{
+  "@programName": "ZAP",
+  "site": [{
+    "@name": "https://example.com",
+    "alerts": [
+      {
+        "pluginid": "10038",
+        "alertRef": "10038-1",
+        "alert": "Content Security Policy (CSP) Header Not Set",
+        "riskcode": "2",
+        "confidence": "3",
+        "cweid": "693",
+        "wascid": "15",
+        "count": "5",
+        "instances": [
+          {"uri": "https://example.com/", "method": "GET", "param": "", "attack": "", "evidence": ""},
+          {"uri": "https://example.com/login", "method": "POST", "param": "user", "attack": "", "evidence": "<form>"}
+        ]
+      },
+      {
+        "pluginid": "10096",
+        "alert": "Timestamp Disclosure",
+        "riskcode": "0",
+        "confidence": "1",
+        "cweid": "-1",
+        "wascid": "13",
+        "instances": [{"uri": "https://example.com/app.js", "method": "GET", "evidence": "1700000000"}]
+      }
+    ]
+  }]
+}`
+
+func TestNewFinding(t *testing.T) {
+	var findings []zapv1alpha1.Finding
+	err := decodeZapReport(strings.NewReader(sampleZapReport), func(a *zapJSONAlert) {
+		findings = append(findings, newFinding(a))
+	})
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d", len(findings))
+	}
+
+	csp := findings[0]
+	if csp.PluginID != "10038" || csp.AlertRef != "10038-1" || csp.Name != "Content Security Policy (CSP) Header Not Set" {
+		t.Errorf("unexpected identity %+v", csp)
+	}
+	if csp.Risk != "medium" || csp.Confidence != "high" || csp.CWEID != 693 || csp.WASCID != 15 || csp.Count != 5 {
+		t.Errorf("unexpected classification %+v", csp)
+	}
+	if len(csp.Instances) != 2 || csp.Instances[1].URL != "https://example.com/login" ||
+		csp.Instances[1].Method != "POST" || csp.Instances[1].Param != "user" || csp.Instances[1].Evidence != "<form>" {
+		t.Errorf("unexpected instances %+v", csp.Instances)
+	}
+
+	ts := findings[1]
+	if ts.Risk != "informational" || ts.Confidence != "low" || ts.CWEID != 0 {
+		t.Errorf("unexpected classification %+v", ts)
+	}
+	if ts.Count != 1 {
+		t.Errorf("expected count to fall back to the number of instances, got %d", ts.Count)
+	}
+}
+
+func TestNewFinding_Limits(t *testing.T) {
+	a := &zapJSONAlert{PluginID: "1", RiskCode: "3", Confidence: "4", Count: "250"}
+	for i := 0; i < 250; i++ {
+		a.Instances = append(a.Instances, zapJSONInstance{URI: fmt.Sprintf("https://example.com/%d", i), Evidence: strings.Repeat("x", 2000)})
+	}
+
+	f := newFinding(a)
+	if len(f.Instances) != maxFindingInstances || f.Count != 250 {
+		t.Errorf("expected %d instances of 250, got %d of %d", maxFindingInstances, len(f.Instances), f.Count)
+	}
+	if len(f.Instances[0].Evidence) != maxEvidenceBytes {
+		t.Errorf("expected evidence to be truncated, got %d bytes", len(f.Instances[0].Evidence))
+	}
+	if f.Confidence != "confirmed" {
+		t.Errorf("expected confirmed confidence, got %q", f.Confidence)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("héllo", 2); got != "h" {
+		t.Errorf("expected truncation before a multi-byte rune, got %q", got)
+	}
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("expected short strings to be kept, got %q", got)
+	}
+}
+
+func TestPaginateFindings(t *testing.T) {
+	var findings []zapv1alpha1.Finding
+	for i := 0; i < 5; i++ {
+		findings = append(findings, zapv1alpha1.Finding{PluginID: fmt.Sprint(i), Name: strings.Repeat("n", 100)})
+	}
+
+	pages, err := paginateFindings(findings, 400)
+	if err != nil {
+		t.Fatalf("paginate: %v", err)
+	}
+	if len(pages) != 3 || len(pages[0]) != 2 || len(pages[2]) != 1 {
+		t.Errorf("unexpected page sizes %d", len(pages))
+	}
+
+	pages, err = paginateFindings(nil, 300)
+	if err != nil {
+		t.Fatalf("paginate: %v", err)
+	}
+	if len(pages) != 1 || len(pages[0]) != 0 {
+		t.Errorf("expected a single empty page, got %v", pages)
+	}
+}
+
+func TestScanReconciler_WritesScanReports(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, UID: "uid-1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}},
+	}
+	// A page left over from an earlier attempt with a larger report.
+	stale := &zapv1alpha1.ZapScanReport{
+		ObjectMeta: metav1.ObjectMeta{Name: scanReportName("s1", 2), Namespace: "ns1", Labels: map[string]string{"spaceship.com/scan-name": "s1"}},
+		Spec:       zapv1alpha1.ZapScanReportSpec{ScanName: "s1", Page: 2, Pages: 2},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod, stale).Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var report zapv1alpha1.ZapScanReport
+	if err := r.Get(ctx, types.NamespacedName{Name: scanReportName("s1", 1), Namespace: "ns1"}, &report); err != nil {
+		t.Fatalf("get report: %v", err)
+	}
+	if report.Spec.ScanName != "s1" || report.Spec.Target != "https://example.com" || report.Spec.Page != 1 || report.Spec.Pages != 1 {
+		t.Errorf("unexpected report spec %+v", report.Spec)
+	}
+	if len(report.Spec.Findings) != 2 || report.Spec.Findings[0].PluginID != "10038" {
+		t.Errorf("unexpected findings %+v", report.Spec.Findings)
+	}
+	if !metav1.IsControlledBy(&report, scan) {
+		t.Errorf("expected report to be owned by the scan")
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if updated.Status.AlertsByRisk == nil || updated.Status.AlertsByRisk.Medium != 1 || updated.Status.AlertsByRisk.Informational != 1 {
+		t.Errorf("unexpected alertsByRisk %+v", updated.Status.AlertsByRisk)
+	}
+	if updated.Status.AlertsByConfidence == nil || updated.Status.AlertsByConfidence.High != 1 {
+		t.Errorf("unexpected alertsByConfidence %+v", updated.Status.AlertsByConfidence)
+	}
+	if len(updated.Status.TopAlerts) != 2 || updated.Status.TopAlerts[0].Name != "Content Security Policy (CSP) Header Not Set" {
+		t.Errorf("unexpected topAlerts %+v", updated.Status.TopAlerts)
+	}
+
+	err = r.Get(ctx, client.ObjectKeyFromObject(stale), &zapv1alpha1.ZapScanReport{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("expected stale page to be deleted, got %v", err)
+	}
+}
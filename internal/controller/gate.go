@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+// confidenceRank orders normalized confidence levels from least to most certain.
+func confidenceRank(confidence string) int {
+	switch confidence {
+	case "falsepositive":
+		return 0
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	case "confirmed":
+		return 4
+	default:
+		return -1
+	}
+}
+
+// evaluateGate counts the alerts of findings against the gate's limits and
+// returns a description of every exceeded limit, most severe first.
+func evaluateGate(gate *zapv1alpha1.Gate, findings []zapv1alpha1.Finding) []string {
+	var byRisk zapv1alpha1.RiskCounts
+	byPlugin := map[string]int64{}
+	for _, f := range findings {
+		if gate.MinConfidence != "" && confidenceRank(f.Confidence) < confidenceRank(gate.MinConfidence) {
+			continue
+		}
+		countRisk(&byRisk, f.Risk, 1)
+		byPlugin[f.PluginID]++
+	}
+
+	var violations []string
+	if limits := gate.MaxAlerts; limits != nil {
+		for _, l := range []struct {
+			risk  string
+			limit *int64
+			count int64
+		}{
+			{"high", limits.High, byRisk.High},
+			{"medium", limits.Medium, byRisk.Medium},
+			{"low", limits.Low, byRisk.Low},
+			{"informational", limits.Informational, byRisk.Informational},
+		} {
+			if l.limit != nil && l.count > *l.limit {
+				violations = append(violations, fmt.Sprintf("%d %s risk alerts (max %d)", l.count, l.risk, *l.limit))
+			}
+		}
+	}
+
+	plugins := make([]string, 0, len(gate.MaxAlertsPerPlugin))
+	for id := range gate.MaxAlertsPerPlugin {
+		plugins = append(plugins, id)
+	}
+	sort.Strings(plugins)
+	for _, id := range plugins {
+		if limit := gate.MaxAlertsPerPlugin[id]; byPlugin[id] > limit {
+			violations = append(violations, fmt.Sprintf("%d alerts from plugin %s (max %d)", byPlugin[id], id, limit))
+		}
+	}
+	return violations
+}
+
+// setGateStatus evaluates spec.gate and records the outcome. alerts is nil
+// when the report couldn't be parsed or wasn't found. That, or a failed scan
+// Job, leaves the outcome unknown, so a crashed scan never passes the gate.
+func setGateStatus(scan *zapv1alpha1.ZapScan, succeeded bool, alerts *parsedAlerts) {
+	gate := scan.Spec.Gate
+	if gate == nil {
+		return
+	}
+
+	if !succeeded || alerts == nil {
+		reason, msg := zapv1alpha1.ReasonGateUnknown, "the scan report couldn't be parsed"
+		if !succeeded {
+			reason, msg = zapv1alpha1.ReasonGateScanFailed, "the scan job failed"
+		}
+		scan.Status.Outcome = ""
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionGatePassed,
+			Status:  metav1.ConditionUnknown,
+			Reason:  reason,
+			Message: msg,
+		})
+		return
+	}
+
+	if violations := evaluateGate(gate, alerts.Findings); len(violations) > 0 {
+		scan.Status.Outcome = zapv1alpha1.OutcomeBlocked
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionGatePassed,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonGateFailed,
+			Message: "found " + strings.Join(violations, ", "),
+		})
+		return
+	}
+	scan.Status.Outcome = zapv1alpha1.OutcomePassed
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionGatePassed,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonGatePassed,
+		Message: "no gate limit was exceeded",
+	})
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestEvaluateGate(t *testing.T) {
+	findings := []zapv1alpha1.Finding{
+		{PluginID: "40012", Risk: "high", Confidence: "medium"},
+		{PluginID: "40012", Risk: "high", Confidence: "low"},
+		{PluginID: "10038", Risk: "medium", Confidence: "high"},
+		{PluginID: "10096", Risk: "informational", Confidence: "falsepositive"},
+	}
+
+	cases := []struct {
+		name string
+		gate zapv1alpha1.Gate
+		want []string
+	}{
+		{"no limits", zapv1alpha1.Gate{}, nil},
+		{"within limits", zapv1alpha1.Gate{MaxAlerts: &zapv1alpha1.RiskLimits{High: ptr(int64(2)), Medium: ptr(int64(1))}}, nil},
+		{
+			"risk limits",
+			zapv1alpha1.Gate{MaxAlerts: &zapv1alpha1.RiskLimits{High: ptr(int64(0)), Informational: ptr(int64(0))}},
+			[]string{"2 high risk alerts (max 0)", "1 informational risk alerts (max 0)"},
+		},
+		{
+			"plugin limits",
+			zapv1alpha1.Gate{MaxAlertsPerPlugin: map[string]int64{"40012": 1, "10038": 1, "90022": 0}},
+			[]string{"2 alerts from plugin 40012 (max 1)"},
+		},
+		{
+			"min confidence",
+			zapv1alpha1.Gate{MinConfidence: "medium", MaxAlerts: &zapv1alpha1.RiskLimits{High: ptr(int64(0)), Informational: ptr(int64(0))}},
+			[]string{"1 high risk alerts (max 0)"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := evaluateGate(&tc.gate, findings)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("expected %q, got %q", tc.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSetGateStatus_Unparsed(t *testing.T) {
+	scan := &zapv1alpha1.ZapScan{Spec: zapv1alpha1.ZapScanSpec{Gate: &zapv1alpha1.Gate{}}}
+	setGateStatus(scan, true, nil)
+
+	cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionGatePassed)
+	if cond == nil || cond.Status != metav1.ConditionUnknown || scan.Status.Outcome != "" {
+		t.Errorf("expected an unknown outcome, got %q %+v", scan.Status.Outcome, cond)
+	}
+
+	// A failed job's report may be partial, so even a clean one doesn't pass.
+	failed := &zapv1alpha1.ZapScan{Spec: zapv1alpha1.ZapScanSpec{Gate: &zapv1alpha1.Gate{}}}
+	setGateStatus(failed, false, &parsedAlerts{})
+	cond = meta.FindStatusCondition(failed.Status.Conditions, zapv1alpha1.ConditionGatePassed)
+	if cond == nil || cond.Status != metav1.ConditionUnknown || cond.Reason != zapv1alpha1.ReasonGateScanFailed || failed.Status.Outcome != "" {
+		t.Errorf("expected an unknown outcome for a failed scan, got %q %+v", failed.Status.Outcome, cond)
+	}
+
+	noGate := &zapv1alpha1.ZapScan{}
+	setGateStatus(noGate, true, &parsedAlerts{})
+	if len(noGate.Status.Conditions) != 0 || noGate.Status.Outcome != "" {
+		t.Errorf("expected no gate status without spec.gate, got %+v", noGate.Status)
+	}
+}
+
+func TestScanReconciler_GateBlocksScan(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		maxMedium   int64
+		wantOutcome string
+		wantStatus  metav1.ConditionStatus
+	}{
+		{"blocked", 0, zapv1alpha1.OutcomeBlocked, metav1.ConditionFalse},
+		{"passed", 1, zapv1alpha1.OutcomePassed, metav1.ConditionTrue},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+			jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+			scan := &zapv1alpha1.ZapScan{
+				ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, UID: "uid-1"},
+				Spec: zapv1alpha1.ZapScanSpec{
+					Target: "https://example.com",
+					Gate:   &zapv1alpha1.Gate{MaxAlerts: &zapv1alpha1.RiskLimits{Medium: ptr(tc.maxMedium)}},
+				},
+				Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+			}
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+				},
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}},
+			}
+
+			r := &ScanReconciler{
+				Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod).Build(),
+				Scheme: s,
+				logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+					return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+				}),
+			}
+
+			_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+			if err != nil {
+				t.Fatalf("reconcile: %v", err)
+			}
+
+			var updated zapv1alpha1.ZapScan
+			if err := r.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+				t.Fatalf("get scan: %v", err)
+			}
+			// The scan itself ran fine either way.
+			if updated.Status.Phase != "Succeeded" {
+				t.Errorf("expected phase Succeeded, got %q", updated.Status.Phase)
+			}
+			if updated.Status.Outcome != tc.wantOutcome {
+				t.Errorf("expected outcome %q, got %q", tc.wantOutcome, updated.Status.Outcome)
+			}
+			cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionGatePassed)
+			if cond == nil || cond.Status != tc.wantStatus {
+				t.Errorf("unexpected GatePassed condition %+v", cond)
+			}
+		})
+	}
+}
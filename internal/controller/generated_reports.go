@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"time"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/junit"
+	"github.com/NCCloud/zap-operator/internal/sarif"
+)
+
+const (
+	// sarifReportFile is the name the SARIF log is stored and exported under.
+	sarifReportFile = "zap.sarif"
+
+	// junitReportFile is the name the JUnit XML report is stored and exported under.
+	junitReportFile = "junit.xml"
+)
+
+// generateReports renders the report formats the operator derives from the
+// parsed findings. They are stored and exported alongside ZAP's own reports.
+func generateReports(scan *zapv1alpha1.ZapScan, alerts *parsedAlerts) (map[string][]byte, error) {
+	if alerts == nil {
+		return nil, nil
+	}
+
+	sarifLog, err := sarif.Marshal(scan.Spec.Target, alerts.Findings)
+	if err != nil {
+		return nil, err
+	}
+
+	suite := junit.Suite{Target: scan.Spec.Target}
+	if scan.Status.StartedAt != nil {
+		suite.Timestamp = scan.Status.StartedAt.UTC().Format(time.RFC3339)
+		if scan.Status.FinishedAt != nil {
+			suite.Seconds = scan.Status.FinishedAt.Sub(scan.Status.StartedAt.Time).Seconds()
+		}
+	}
+	var failOnRisk string
+	if scan.Spec.JUnit != nil {
+		failOnRisk = scan.Spec.JUnit.FailOnRisk
+	}
+	junitReport, err := junit.Marshal(suite, alerts.Findings, failOnRisk)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		sarifReportFile: sarifLog,
+		junitReportFile: junitReport,
+	}, nil
+}
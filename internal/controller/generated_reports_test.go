@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/junit"
+	"github.com/NCCloud/zap-operator/internal/results"
+	"github.com/NCCloud/zap-operator/internal/sarif"
+)
+
+func TestGenerateReports(t *testing.T) {
+	scan := &zapv1alpha1.ZapScan{Spec: zapv1alpha1.ZapScanSpec{Target: "https://example.com"}}
+
+	files, err := generateReports(scan, nil)
+	if err != nil || files != nil {
+		t.Errorf("expected nothing to be generated without parsed alerts, got %v, %v", files, err)
+	}
+
+	alerts := &parsedAlerts{Findings: []zapv1alpha1.Finding{{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", CWEID: 693}}}
+	files, err = generateReports(scan, alerts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	var log sarif.Log
+	if err := json.Unmarshal(files[sarifReportFile], &log); err != nil {
+		t.Fatalf("decode sarif: %v", err)
+	}
+	if log.Version != sarif.Version || len(log.Runs[0].Results) != 1 || log.Runs[0].Results[0].Level != "warning" {
+		t.Errorf("unexpected sarif log %+v", log)
+	}
+
+	var report junit.TestSuites
+	if err := xml.Unmarshal(files[junitReportFile], &report); err != nil {
+		t.Fatalf("decode junit: %v", err)
+	}
+	if report.Failures != 1 {
+		t.Errorf("expected medium alert to fail by default, got %d failures", report.Failures)
+	}
+}
+
+func TestGenerateReports_JUnitOptions(t *testing.T) {
+	started := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	finished := metav1.NewTime(started.Add(90 * time.Second))
+	scan := &zapv1alpha1.ZapScan{
+		Spec:   zapv1alpha1.ZapScanSpec{Target: "https://example.com", JUnit: &zapv1alpha1.JUnitOptions{FailOnRisk: "high"}},
+		Status: zapv1alpha1.ZapScanStatus{StartedAt: &started, FinishedAt: &finished},
+	}
+	alerts := &parsedAlerts{Findings: []zapv1alpha1.Finding{{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium"}}}
+
+	files, err := generateReports(scan, alerts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	var report junit.TestSuites
+	if err := xml.Unmarshal(files[junitReportFile], &report); err != nil {
+		t.Fatalf("decode junit: %v", err)
+	}
+	suite := report.Suites[0]
+	if report.Failures != 0 || suite.Time != "90.000" || suite.Timestamp != "2026-01-02T03:04:05Z" {
+		t.Errorf("unexpected junit report %+v", report)
+	}
+}
+
+func TestReportFiles_GeneratedOnlyWithRawReports(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+	store := results.NewStore()
+	r := &ScanReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(s).WithObjects(job).Build(),
+		Scheme:  s,
+		Results: store,
+	}
+	generated := map[string][]byte{sarifReportFile: []byte("{}")}
+
+	files, err := r.reportFiles(ctx, job, generated)
+	if err != nil {
+		t.Fatalf("report files: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files without ZAP reports, got %v", files)
+	}
+
+	store.Put(types.NamespacedName{Name: "test-job", Namespace: "ns1"}, "zap.json", []byte("{}"))
+	files, err = r.reportFiles(ctx, job, generated)
+	if err != nil {
+		t.Fatalf("report files: %v", err)
+	}
+	if len(files) != 2 || string(files[sarifReportFile]) != "{}" {
+		t.Errorf("expected generated reports next to zap.json, got %v", files)
+	}
+}
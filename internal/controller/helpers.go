@@ -37,7 +37,7 @@ func buildZapFullScanJob(jobName, scanNamespace, scanName string, specTarget str
 
 	// We use zap-full-scan.py inside the official image.
 	// It expects /zap/wrk to exist and be writable when file outputs are configured.
-	args := []string{"zap-full-scan.py", "-t", specTarget, "-J", "/zap/wrk/zap.json", "-r", "/zap/wrk/zap.html", "-d"}
+	args := []string{"zap-full-scan.py", "-t", specTarget, "-J", "/zap/wrk/zap.json", "-r", "/zap/wrk/zap.html", "-x", "/zap/wrk/zap.xml", "-w", "/zap/wrk/zap.md", "-d"}
 	if openapi != nil && *openapi != "" {
 		args = append(args, "-O", *openapi)
 	}
@@ -86,7 +86,9 @@ func buildZapFullScanJob(jobName, scanNamespace, scanName string, specTarget str
 								// ZAP exits with 1/2/3 when alerts are found (by severity).
 								// We treat these as success since finding alerts is expected behavior.
 								// Only propagate exit codes > 3 which indicate real errors.
-								"mkdir -p /zap/wrk && python3 /zap/" + joinShell(args) + "; ec=$?; if [ $ec -le 3 ]; then exit 0; else exit $ec; fi",
+								// The .done marker tells the reporter that every report file has been written.
+								// ZAP doesn't create the directory of a -newsession path itself.
+								"mkdir -p /zap/wrk " + sessionDir + " && python3 /zap/" + joinShell(args) + "; ec=$?; touch " + scanDoneMarker + "; if [ $ec -le 3 ]; then exit 0; else exit $ec; fi",
 							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
@@ -114,9 +116,7 @@ func buildZapFullScanJob(jobName, scanNamespace, scanName string, specTarget str
 							Image:           "busybox:1.36",
 							ImagePullPolicy: corev1.PullIfNotPresent,
 							Command:         []string{"/bin/sh", "-c"},
-							Args: []string{
-								"set -eu; echo 'zap-operator: waiting for /zap/wrk/zap.json'; while [ ! -f /zap/wrk/zap.json ]; do sleep 2; done; echo 'zap-operator: begin zap.json'; cat /zap/wrk/zap.json; echo; echo 'zap-operator: end zap.json';",
-							},
+							Args:            []string{reporterScript(reporterOptions{})},
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "zap-wrk",
@@ -264,6 +264,11 @@ func TestBuildZapFullScanJob(t *testing.T) {
 		t.Errorf("expected 2 containers, got %d", len(job.Spec.Template.Spec.Containers))
 	}
 
+	// ZAP needs the session directory to exist for spec.keepSession
+	if cmd := job.Spec.Template.Spec.Containers[0].Args[0]; !containsSubstring(cmd, "mkdir -p /zap/wrk "+sessionDir+" &&") {
+		t.Errorf("expected the session directory to be created, got %q", cmd)
+	}
+
 	// Test with custom image
 	customImg := "my-registry/zap:custom"
 	job = buildZapFullScanJob("test-job", "test-ns", "my-scan", "https://example.com", nil, &customImg, nil, nil)
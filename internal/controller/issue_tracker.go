@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/jira"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+const (
+	// issueTrackerTokenKey and issueTrackerUsernameKey are the keys of the
+	// issue tracker's credentials Secret.
+	issueTrackerTokenKey    = "token"
+	issueTrackerUsernameKey = "username"
+
+	// issueTrackerTargetAnnotation records the target on the ConfigMap
+	// mapping a target's finding fingerprints to issue keys.
+	issueTrackerTargetAnnotation = "spaceship.com/target"
+
+	// resolvedIssueSuffix marks an issue index entry whose issue was
+	// resolved. The entry is kept so the issue is reopened, not duplicated,
+	// if its finding comes back.
+	resolvedIssueSuffix = ",resolved"
+
+	defaultIssueType         = "Bug"
+	defaultIssueMinRisk      = "medium"
+	defaultResolveTransition = "Done"
+
+	defaultIssueTitleTemplate = `[ZAP] {{.Finding.Name}} ({{.Finding.Risk}}) on {{.Finding.URL}}`
+	defaultIssueBodyTemplate  = `ZAP found {{.Finding.Name}} (plugin {{.Finding.PluginID}}, {{.Finding.Risk}} risk) while scanning {{.Target}}.
+
+URL: {{.Finding.URL}}
+{{- with .Finding.Method}}
+Method: {{.}}{{end}}
+{{- with .Finding.Param}}
+Parameter: {{.}}{{end}}
+
+Reported by ZapScan {{.Namespace}}/{{.Scan}}. This issue is resolved automatically once a scan no longer finds it.
+`
+)
+
+// issueTemplateData is what issue title and body templates are rendered with.
+type issueTemplateData struct {
+	Scan      string
+	Namespace string
+	Target    string
+	Finding   zapv1alpha1.DiffFinding
+}
+
+// syncIssues creates, updates and resolves the issues of a finished scan's
+// findings according to spec.issueTracker and records the outcome in status.
+// Failures don't fail the scan.
+func (r *ScanReconciler) syncIssues(ctx context.Context, scan *zapv1alpha1.ZapScan, alerts *parsedAlerts) {
+	cfg := scan.Spec.IssueTracker
+	if cfg == nil {
+		return
+	}
+
+	// The findings of a crashed scan are missing, not fixed, so only complete
+	// reports are synced; otherwise every tracked issue would be resolved.
+	var skip string
+	switch {
+	case scan.Status.Phase != "Succeeded":
+		skip = "the scan failed"
+	case alerts == nil:
+		skip = "the scan report wasn't parsed"
+	}
+	if skip != "" {
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionIssuesSynced,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonIssueSyncSkipped,
+			Message: "not synced because " + skip,
+		})
+		return
+	}
+
+	msg, err := r.syncIssuesTo(ctx, scan, cfg, alerts.reportFindings())
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to sync findings to the issue tracker", "project", cfg.Project)
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionIssuesSynced,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonIssueSyncFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionIssuesSynced,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonIssuesSynced,
+		Message: msg,
+	})
+}
+
+// syncIssuesTo syncs findings to cfg's tracker. The issue key of every
+// tracked finding is kept in a ConfigMap per target, so a finding keeps its
+// issue across scans. Issues of findings that are still there are left alone
+// rather than commented on by every scan; resolved issues of findings that
+// came back are commented on and reopened. Progress made before an error is saved.
+func (r *ScanReconciler) syncIssuesTo(ctx context.Context, scan *zapv1alpha1.ZapScan, cfg *zapv1alpha1.IssueTracker, findings []zapv1alpha1.Finding) (string, error) {
+	tracker, err := r.issueTrackerClient(ctx, scan.Namespace, cfg)
+	if err != nil {
+		return "", err
+	}
+	title, body, err := issueTemplates(cfg)
+	if err != nil {
+		return "", err
+	}
+	current := map[string]zapv1alpha1.DiffFinding{}
+	for fp, f := range diffEntries(findings, r.MinConfidence) {
+		if risk.Rank(f.Risk) >= risk.Rank(issueMinRisk(cfg)) {
+			current[fp] = f
+		}
+	}
+
+	cm, err := r.issueIndex(ctx, scan, cfg)
+	if err != nil {
+		return "", err
+	}
+	saved := maps.Clone(cm.Data)
+	var created, reopened, open, resolved int
+	syncErr := func() error {
+		for _, fp := range sortedKeys(current) {
+			f := current[fp]
+			if entry, ok := cm.Data[fp]; ok {
+				key, wasResolved := strings.CutSuffix(entry, resolvedIssueSuffix)
+				if !wasResolved {
+					open++
+					continue
+				}
+				if err := tracker.AddComment(ctx, key, fmt.Sprintf("ZAP found this again while scanning %s in ZapScan %s/%s.", scan.Spec.Target, scan.Namespace, scan.Name)); err != nil {
+					return err
+				}
+				if cfg.ReopenTransition != "" {
+					if err := tracker.Transition(ctx, key, cfg.ReopenTransition); err != nil {
+						return err
+					}
+				}
+				cm.Data[fp] = key
+				reopened++
+				continue
+			}
+			data := issueTemplateData{Scan: scan.Name, Namespace: scan.Namespace, Target: scan.Spec.Target, Finding: f}
+			issue := &jira.Issue{
+				Project:   cfg.Project,
+				IssueType: cfg.IssueType,
+				Labels:    append(append([]string{}, cfg.Labels...), "zap-operator", "zap-"+fp),
+			}
+			if issue.IssueType == "" {
+				issue.IssueType = defaultIssueType
+			}
+			if issue.Summary, err = renderIssueTemplate(title, data); err != nil {
+				return err
+			}
+			if issue.Description, err = renderIssueTemplate(body, data); err != nil {
+				return err
+			}
+			key, err := tracker.CreateIssue(ctx, issue)
+			if err != nil {
+				return err
+			}
+			cm.Data[fp] = key
+			created++
+		}
+
+		transition := cfg.ResolveTransition
+		if transition == "" {
+			transition = defaultResolveTransition
+		}
+		for _, fp := range sortedKeys(cm.Data) {
+			if _, ok := current[fp]; ok || strings.HasSuffix(cm.Data[fp], resolvedIssueSuffix) {
+				continue
+			}
+			if err := tracker.Transition(ctx, cm.Data[fp], transition); err != nil {
+				return err
+			}
+			cm.Data[fp] += resolvedIssueSuffix
+			resolved++
+		}
+		return nil
+	}()
+
+	changes := map[string]string{}
+	for fp, entry := range cm.Data {
+		if saved[fp] != entry {
+			changes[fp] = entry
+		}
+	}
+	if err := r.saveIssueIndex(ctx, cm, changes); err != nil {
+		if syncErr != nil {
+			return "", fmt.Errorf("%w; save issue index: %v", syncErr, err)
+		}
+		return "", fmt.Errorf("save issue index: %w", err)
+	}
+	if syncErr != nil {
+		return "", syncErr
+	}
+	return fmt.Sprintf("created %d, reopened %d and resolved %d issues in project %s, %d still open", created, reopened, resolved, cfg.Project, open), nil
+}
+
+func (r *ScanReconciler) issueTrackerClient(ctx context.Context, namespace string, cfg *zapv1alpha1.IssueTracker) (*jira.Client, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: cfg.CredentialsSecret}, &secret); err != nil {
+		return nil, fmt.Errorf("get issue tracker credentials: %w", err)
+	}
+	if err := checkSecretURL(&secret, cfg.URL); err != nil {
+		return nil, err
+	}
+	token := string(secret.Data[issueTrackerTokenKey])
+	if token == "" {
+		return nil, fmt.Errorf("secret %s has no %s key", cfg.CredentialsSecret, issueTrackerTokenKey)
+	}
+	return &jira.Client{URL: cfg.URL, Username: string(secret.Data[issueTrackerUsernameKey]), Token: token}, nil
+}
+
+func issueTemplates(cfg *zapv1alpha1.IssueTracker) (title, body *template.Template, err error) {
+	titleText, bodyText := cfg.TitleTemplate, cfg.BodyTemplate
+	if titleText == "" {
+		titleText = defaultIssueTitleTemplate
+	}
+	if bodyText == "" {
+		bodyText = defaultIssueBodyTemplate
+	}
+	if title, err = template.New("title").Option("missingkey=error").Parse(titleText); err != nil {
+		return nil, nil, fmt.Errorf("invalid title template: %w", err)
+	}
+	if body, err = template.New("body").Option("missingkey=error").Parse(bodyText); err != nil {
+		return nil, nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return title, body, nil
+}
+
+func renderIssueTemplate(t *template.Template, data issueTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render %s template: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+func issueMinRisk(cfg *zapv1alpha1.IssueTracker) string {
+	if cfg.MinRisk == "" {
+		return defaultIssueMinRisk
+	}
+	return cfg.MinRisk
+}
+
+// issueIndexName returns the name of the ConfigMap tracking the issues a
+// scan's configuration opened in a project. Runs of the same ZapScheduledScan
+// share it, as do other scans of the same target configured the same way, so
+// their findings share issues. Scans that find different sets of findings,
+// like an API and a full scan of a target, don't resolve each other's issues.
+func issueIndexName(scan *zapv1alpha1.ZapScan, cfg *zapv1alpha1.IssueTracker) string {
+	scope := []string{cfg.Project}
+	if schedule := scan.Labels[scheduledScanLabel]; schedule != "" {
+		scope = append(scope, "schedule", schedule)
+	} else {
+		var openapi, image string
+		if scan.Spec.OpenAPI != nil {
+			openapi = *scan.Spec.OpenAPI
+		}
+		if scan.Spec.Image != nil {
+			image = *scan.Spec.Image
+		}
+		scope = append(scope, "scan", normalizeFindingURL(scan.Spec.Target), issueMinRisk(cfg), openapi, image)
+		scope = append(scope, scan.Spec.Args...)
+	}
+	h := sha256.Sum256([]byte(strings.Join(scope, "\x00")))
+	return "zap-issues-" + hex.EncodeToString(h[:])[:12]
+}
+
+// issueIndex returns the ConfigMap mapping the fingerprints of a target's
+// tracked findings to issue keys. A new one is returned if there is none yet.
+func (r *ScanReconciler) issueIndex(ctx context.Context, scan *zapv1alpha1.ZapScan, cfg *zapv1alpha1.IssueTracker) (*corev1.ConfigMap, error) {
+	var cm corev1.ConfigMap
+	err := r.Get(ctx, types.NamespacedName{Namespace: scan.Namespace, Name: issueIndexName(scan, cfg)}, &cm)
+	if errors.IsNotFound(err) {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      issueIndexName(scan, cfg),
+				Namespace: scan.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/name":      "zap-operator",
+					"app.kubernetes.io/component": "zap-issues",
+				},
+				Annotations: map[string]string{issueTrackerTargetAnnotation: scan.Spec.Target},
+			},
+			Data: map[string]string{},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get issue index: %w", err)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	return &cm, nil
+}
+
+// saveIssueIndex writes changes to the issue index cm. If another scan saved
+// the index in the meantime, the changes are applied to its version instead,
+// so issue keys aren't lost and their issues aren't created again.
+func (r *ScanReconciler) saveIssueIndex(ctx context.Context, cm *corev1.ConfigMap, changes map[string]string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return errors.IsConflict(err) || errors.IsAlreadyExists(err)
+	}, func() error {
+		maps.Copy(cm.Data, changes)
+		var err error
+		if cm.ResourceVersion == "" {
+			err = r.Create(ctx, cm)
+		} else {
+			err = r.Update(ctx, cm)
+		}
+		if errors.IsConflict(err) || errors.IsAlreadyExists(err) {
+			if getErr := r.Get(ctx, client.ObjectKeyFromObject(cm), cm); getErr != nil {
+				return getErr
+			}
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+		}
+		return err
+	})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
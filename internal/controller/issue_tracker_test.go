@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+// fakeJira is an in-memory stand-in for the parts of the Jira REST API the
+// issue tracker uses.
+type fakeJira struct {
+	mu       sync.Mutex
+	summary  map[string]string
+	labels   map[string][]string
+	comments map[string][]string
+	status   map[string]string
+}
+
+func newFakeJira() *fakeJira {
+	return &fakeJira{summary: map[string]string{}, labels: map[string][]string{}, comments: map[string][]string{}, status: map[string]string{}}
+}
+
+func (j *fakeJira) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "bot" || pass != "t0ken" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue")
+	key, action, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	switch {
+	case path == "" && r.Method == http.MethodPost:
+		var in struct {
+			Fields struct {
+				Summary string   `json:"summary"`
+				Labels  []string `json:"labels"`
+			} `json:"fields"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		key := fmt.Sprintf("SEC-%d", len(j.summary)+1)
+		j.summary[key], j.labels[key], j.status[key] = in.Fields.Summary, in.Fields.Labels, "Open"
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"key": %q}`, key)
+	case action == "comment":
+		var in struct {
+			Body string `json:"body"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		j.comments[key] = append(j.comments[key], in.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	case action == "transitions" && r.Method == http.MethodGet:
+		_, _ = w.Write([]byte(`{"transitions": [{"id": "31", "name": "Resolve", "to": {"name": "Done"}}, {"id": "41", "name": "Reopen", "to": {"name": "Open"}}]}`))
+	case action == "transitions":
+		var in struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		j.status[key] = map[string]string{"31": "Done", "41": "Open"}[in.Transition.ID]
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestScanReconciler_SyncsIssues(t *testing.T) {
+	jira := newFakeJira()
+	srv := httptest.NewServer(jira)
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	tracker := &zapv1alpha1.IssueTracker{
+		URL:               srv.URL,
+		CredentialsSecret: "jira",
+		Project:           "SEC",
+		TitleTemplate:     "{{.Finding.Name}} on {{.Finding.URL}} ({{.Namespace}})",
+		Labels:            []string{"appsec"},
+		ReopenTransition:  "Reopen",
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jira", Namespace: "ns1", Annotations: map[string]string{zapv1alpha1.AnnotationAllowedURLs: srv.URL}},
+		Data:       map[string][]byte{issueTrackerUsernameKey: []byte("bot"), issueTrackerTokenKey: []byte("t0ken")},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(secret).Build()
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	// runScan completes a scan of https://example.com whose report holds report.
+	runScan := func(name, report string) *metav1.Condition {
+		t.Helper()
+		creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+		jobName := scanJobNameWithTimestamp(name, creationTime.Time)
+		objs := []client.Object{
+			&zapv1alpha1.ZapScan{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", CreationTimestamp: creationTime},
+				Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com", IssueTracker: tracker},
+				Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+			},
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+				},
+			},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name + "-pod", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}},
+		}
+		for _, o := range objs {
+			if err := c.Create(ctx, o); err != nil {
+				t.Fatalf("create %s: %v", o.GetName(), err)
+			}
+		}
+		r := &ScanReconciler{
+			Client: c,
+			Scheme: s,
+			logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+				return []byte(reportBeginMarker + "\n" + report + "\n" + reportEndMarker + "\n"), nil
+			}),
+		}
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "ns1"}}); err != nil {
+			t.Fatalf("reconcile %s: %v", name, err)
+		}
+		var updated zapv1alpha1.ZapScan
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: "ns1"}, &updated); err != nil {
+			t.Fatalf("get scan: %v", err)
+		}
+		return meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionIssuesSynced)
+	}
+
+	// The medium CSP finding has two instances; the informational one is below minRisk.
+	cond := runScan("s1", sampleZapReport)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != "created 2, reopened 0 and resolved 0 issues in project SEC, 0 still open" {
+		t.Fatalf("unexpected condition %+v", cond)
+	}
+	if len(jira.summary) != 2 || jira.summary["SEC-1"] != "Content Security Policy (CSP) Header Not Set on https://example.com/ (ns1)" {
+		t.Errorf("unexpected issues %v", jira.summary)
+	}
+	if labels := jira.labels["SEC-1"]; len(labels) != 3 || labels[0] != "appsec" || labels[1] != "zap-operator" || !strings.HasPrefix(labels[2], "zap-") {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	var index corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Name: issueIndexName(&zapv1alpha1.ZapScan{Spec: zapv1alpha1.ZapScanSpec{Target: "https://example.com"}}, tracker), Namespace: "ns1"}, &index); err != nil {
+		t.Fatalf("get issue index: %v", err)
+	}
+	fpRoot := fingerprint("10038", "https://example.com/", "", "GET")
+	fpLogin := fingerprint("10038", "https://example.com/login", "user", "POST")
+	rootKey, loginKey := index.Data[fpRoot], index.Data[fpLogin]
+	if len(index.Data) != 2 || rootKey == "" || loginKey == "" {
+		t.Fatalf("expected both fingerprints in the index, got %v", index.Data)
+	}
+
+	// A later scan of the same target only finds the root page instance.
+	report := strings.Replace(sampleZapReport,
+		`{"uri": "https://example.com/login", "method": "POST", "param": "user", "attack": "", "evidence": "<form>"}`,
+		`{"uri": "https://EXAMPLE.com:443/#top", "method": "get", "param": "", "attack": "", "evidence": "changed"}`, 1)
+	cond = runScan("s2", report)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != "created 0, reopened 0 and resolved 1 issues in project SEC, 1 still open" {
+		t.Fatalf("unexpected condition %+v", cond)
+	}
+	if len(jira.summary) != 2 {
+		t.Errorf("expected no duplicate issues, got %v", jira.summary)
+	}
+	if len(jira.comments) != 0 {
+		t.Errorf("expected the persisting finding's issue not to be commented on, got %v", jira.comments)
+	}
+	if jira.status[loginKey] != "Done" || jira.status[rootKey] != "Open" {
+		t.Errorf("expected only the fixed finding's issue to be resolved, got %v", jira.status)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: index.Name, Namespace: "ns1"}, &index); err != nil {
+		t.Fatalf("get issue index: %v", err)
+	}
+	if len(index.Data) != 2 || index.Data[fpRoot] != rootKey || index.Data[fpLogin] != loginKey+resolvedIssueSuffix {
+		t.Errorf("expected the fixed finding to be kept as resolved, got %v", index.Data)
+	}
+
+	// The fixed finding comes back, so its issue is reopened rather than duplicated.
+	cond = runScan("s3", sampleZapReport)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != "created 0, reopened 1 and resolved 0 issues in project SEC, 1 still open" {
+		t.Fatalf("unexpected condition %+v", cond)
+	}
+	if len(jira.summary) != 2 || jira.status[loginKey] != "Open" {
+		t.Errorf("expected the resolved issue to be reopened, got %v %v", jira.summary, jira.status)
+	}
+	if comments := jira.comments[loginKey]; len(comments) != 1 || !strings.Contains(comments[0], "ns1/s3") {
+		t.Errorf("expected a comment on the reopened issue, got %v", jira.comments)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: index.Name, Namespace: "ns1"}, &index); err != nil {
+		t.Fatalf("get issue index: %v", err)
+	}
+	if index.Data[fpLogin] != loginKey {
+		t.Errorf("expected the reopened issue to be open in the index, got %v", index.Data)
+	}
+}
+
+func TestIssueIndexName(t *testing.T) {
+	cfg := &zapv1alpha1.IssueTracker{Project: "SEC"}
+	scan := func(target string, openapi *string, labels map[string]string) *zapv1alpha1.ZapScan {
+		return &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Spec: zapv1alpha1.ZapScanSpec{Target: target, OpenAPI: openapi}}
+	}
+	spec := "https://example.com/openapi.json"
+	full := issueIndexName(scan("https://example.com", nil, nil), cfg)
+
+	if got := issueIndexName(scan("https://EXAMPLE.com:443/", nil, nil), cfg); got != full {
+		t.Errorf("expected scans of the same target to share an index, got %s and %s", got, full)
+	}
+	if got := issueIndexName(scan("https://example.com", &spec, nil), cfg); got == full {
+		t.Errorf("expected an API scan to have its own index")
+	}
+	if got := issueIndexName(scan("https://example.com", nil, nil), &zapv1alpha1.IssueTracker{Project: "SEC", MinRisk: "high"}); got == full {
+		t.Errorf("expected another minRisk to have its own index")
+	}
+	nightly := issueIndexName(scan("https://example.com", nil, map[string]string{scheduledScanLabel: "nightly"}), cfg)
+	if nightly == full || issueIndexName(scan("https://other.example.com", nil, map[string]string{scheduledScanLabel: "nightly"}), cfg) != nightly {
+		t.Errorf("expected the runs of a scheduled scan to share their own index")
+	}
+}
+
+func TestSaveIssueIndex_Conflict(t *testing.T) {
+	ctx := context.Background()
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	index := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "zap-issues-x", Namespace: "ns1"}, Data: map[string]string{"fp1": "SEC-1"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(index).Build()
+	r := &ScanReconciler{Client: c, Scheme: s}
+
+	var stale corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKeyFromObject(index), &stale); err != nil {
+		t.Fatalf("get index: %v", err)
+	}
+	// Another scan created an issue meanwhile.
+	var other corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKeyFromObject(index), &other); err != nil {
+		t.Fatalf("get index: %v", err)
+	}
+	other.Data["fp2"] = "SEC-2"
+	if err := c.Update(ctx, &other); err != nil {
+		t.Fatalf("update index: %v", err)
+	}
+
+	stale.Data["fp3"] = "SEC-3"
+	if err := r.saveIssueIndex(ctx, &stale, map[string]string{"fp3": "SEC-3"}); err != nil {
+		t.Fatalf("save index: %v", err)
+	}
+	var got corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKeyFromObject(index), &got); err != nil {
+		t.Fatalf("get index: %v", err)
+	}
+	if len(got.Data) != 3 || got.Data["fp2"] != "SEC-2" || got.Data["fp3"] != "SEC-3" {
+		t.Errorf("expected both scans' issues in the index, got %v", got.Data)
+	}
+
+	// Creating an index another scan just created merges into it too.
+	fresh := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "zap-issues-x", Namespace: "ns1"}, Data: map[string]string{}}
+	if err := r.saveIssueIndex(ctx, fresh, map[string]string{"fp4": "SEC-4"}); err != nil {
+		t.Fatalf("save new index: %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(index), &got); err != nil {
+		t.Fatalf("get index: %v", err)
+	}
+	if len(got.Data) != 4 || got.Data["fp4"] != "SEC-4" {
+		t.Errorf("expected the new issue to be merged, got %v", got.Data)
+	}
+}
+
+func TestScanReconciler_IssueSyncFailure(t *testing.T) {
+	jira := newFakeJira()
+	srv := httptest.NewServer(jira)
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec: zapv1alpha1.ZapScanSpec{Target: "https://example.com", IssueTracker: &zapv1alpha1.IssueTracker{
+			URL: srv.URL, CredentialsSecret: "jira", Project: "SEC",
+		}},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Succeeded"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jira", Namespace: "ns1", Annotations: map[string]string{zapv1alpha1.AnnotationAllowedURLs: srv.URL}},
+		Data:       map[string][]byte{issueTrackerTokenKey: []byte("wrong")},
+	}
+	r := &ScanReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(secret).Build(), Scheme: s}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	findings := []zapv1alpha1.Finding{{PluginID: "40012", Name: "Cross Site Scripting", Risk: "high", Confidence: "medium", Instances: []zapv1alpha1.FindingInstance{{URL: "https://example.com/"}}}}
+	r.syncIssues(ctx, scan, &parsedAlerts{Findings: findings})
+	cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionIssuesSynced)
+	if cond == nil || cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, "401") {
+		t.Errorf("expected a failed sync condition, got %+v", cond)
+	}
+
+	// The token isn't sent to a tracker its Secret doesn't allow.
+	elsewhere := scan.DeepCopy()
+	elsewhere.Spec.IssueTracker.URL = "https://attacker.example.com"
+	r.syncIssues(ctx, elsewhere, &parsedAlerts{Findings: findings})
+	cond = meta.FindStatusCondition(elsewhere.Status.Conditions, zapv1alpha1.ConditionIssuesSynced)
+	if cond == nil || cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, "doesn't allow") {
+		t.Errorf("expected a failed sync condition, got %+v", cond)
+	}
+
+	// Without a parsed report, or after a crash, nothing is resolved.
+	r.syncIssues(ctx, scan, nil)
+	cond = meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionIssuesSynced)
+	if cond == nil || cond.Reason != zapv1alpha1.ReasonIssueSyncSkipped || !strings.Contains(cond.Message, "wasn't parsed") {
+		t.Errorf("expected a skipped sync condition, got %+v", cond)
+	}
+	scan.Status.Phase = "Failed"
+	r.syncIssues(ctx, scan, &parsedAlerts{})
+	cond = meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionIssuesSynced)
+	if cond == nil || cond.Reason != zapv1alpha1.ReasonIssueSyncSkipped || !strings.Contains(cond.Message, "scan failed") {
+		t.Errorf("expected a skipped sync condition, got %+v", cond)
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -132,9 +133,11 @@ users:
 
 	// Test getPodLogs - Should SUCCEED now if using mock server (fallback path)
 	// If using envtest, it fails because pod doesn't exist.
-	logs, err := r.getPodLogs(ctx, "ns", "pod", "container")
+	stream, err := r.getPodLogs(ctx, "ns", "pod", "container")
 	if err == nil {
 		// If it succeeded, check content
+		logs, _ := io.ReadAll(stream)
+		_ = stream.Close()
 		if string(logs) != "fake logs" {
 			// If envtest worked, maybe it returned empty logs? Unlikely without pod.
 			// Just accept success or specific failure.
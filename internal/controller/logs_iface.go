@@ -1,7 +1,10 @@
 package controller
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 type podLogsGetter interface {
-	getPodLogs(ctx context.Context, namespace, podName, container string) ([]byte, error)
+	getPodLogs(ctx context.Context, namespace, podName, container string) (io.ReadCloser, error)
 }
@@ -1,9 +1,17 @@
 package controller
 
-import "context"
+import (
+	"bytes"
+	"context"
+	"io"
+)
 
 type podLogsGetterFunc func(ctx context.Context, namespace, podName, container string) ([]byte, error)
 
-func (f podLogsGetterFunc) getPodLogs(ctx context.Context, namespace, podName, container string) ([]byte, error) {
-	return f(ctx, namespace, podName, container)
+func (f podLogsGetterFunc) getPodLogs(ctx context.Context, namespace, podName, container string) (io.ReadCloser, error) {
+	b, err := f(ctx, namespace, podName, container)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
 }
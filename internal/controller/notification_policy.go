@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/notify"
+)
+
+// policyTargetsFor returns the notifications ZapNotificationPolicies route
+// scan to. They're named <policy>/<namespace>/<notifier> in status. Policies
+// created after the scan finished are left out, like notifiers. It also
+// returns the namespace/name of every notifier the policies reference.
+func (r *ScanReconciler) policyTargetsFor(ctx context.Context, scan *zapv1alpha1.ZapScan, ev *notify.Event) ([]notificationTarget, map[string]bool, error) {
+	var list zapv1alpha1.ZapNotificationPolicyList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	log := ctrl.LoggerFrom(ctx)
+
+	var nsLabels labels.Set
+	var targets []notificationTarget
+	referenced := map[string]bool{}
+	for i := range list.Items {
+		p := &list.Items[i]
+		if scan.Status.FinishedAt != nil && p.CreationTimestamp.After(scan.Status.FinishedAt.Time) {
+			continue
+		}
+		for _, ref := range p.Spec.Notifiers {
+			referenced[ref.Namespace+"/"+ref.Name] = true
+		}
+		if p.Spec.NamespaceSelector != nil && nsLabels == nil {
+			var ns corev1.Namespace
+			if err := r.Get(ctx, types.NamespacedName{Name: scan.Namespace}, &ns); err != nil {
+				return nil, nil, err
+			}
+			nsLabels = labels.Set(ns.Labels)
+		}
+		if !policySelects(ctx, p, scan, ev, nsLabels) {
+			continue
+		}
+
+		for _, ref := range p.Spec.Notifiers {
+			key := ref.Namespace + "/" + ref.Name
+			var n zapv1alpha1.ZapNotifier
+			if err := r.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, &n); err != nil {
+				if errors.IsNotFound(err) {
+					log.Info("ignoring missing notifier of notification policy", "policy", p.Name, "notifier", key)
+					continue
+				}
+				return nil, nil, err
+			}
+			t, ok := r.notifierTarget(ctx, p.Name+"/"+key, &n, scan, ev)
+			if !ok {
+				continue
+			}
+			t.policy, t.notifierKey = p, key
+			targets = append(targets, t)
+		}
+	}
+	return targets, referenced, nil
+}
+
+// policySelects reports whether p routes scan. nsLabels are the labels of the
+// scan's namespace, only needed if p has a namespace selector. Failed scans
+// skip the risk and new findings filters, as they have no findings.
+func policySelects(ctx context.Context, p *zapv1alpha1.ZapNotificationPolicy, scan *zapv1alpha1.ZapScan, ev *notify.Event, nsLabels labels.Set) bool {
+	if len(p.Spec.Namespaces) > 0 && !slices.Contains(p.Spec.Namespaces, scan.Namespace) {
+		return false
+	}
+	for _, sel := range []struct {
+		selector *metav1.LabelSelector
+		labels   labels.Set
+	}{{p.Spec.NamespaceSelector, nsLabels}, {p.Spec.Selector, labels.Set(scan.Labels)}} {
+		if sel.selector == nil {
+			continue
+		}
+		s, err := metav1.LabelSelectorAsSelector(sel.selector)
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Info("ignoring notification policy with invalid selector", "policy", p.Name, "error", err.Error())
+			return false
+		}
+		if !s.Matches(sel.labels) {
+			return false
+		}
+	}
+
+	phase := zapv1alpha1.PolicyPhase(scan.Status.Phase)
+	if len(p.Spec.Phases) > 0 && !slices.Contains(p.Spec.Phases, phase) {
+		return false
+	}
+	if phase == "Failed" {
+		return true
+	}
+	if !p.Spec.NewFindingsOnly {
+		return ev.MeetsRisk(p.Spec.MinRisk)
+	}
+	minRisk := p.Spec.MinRisk
+	if minRisk == "" {
+		minRisk = "informational"
+	}
+	if ev.Diff != nil {
+		return notify.CountsMeetRisk(ev.Diff.NewByRisk, minRisk)
+	}
+	return ev.MeetsRisk(minRisk)
+}
+
+// rateLimitCount is a notification yet to be counted against the rate limit
+// of the policy that routed it.
+type rateLimitCount struct {
+	policy      *zapv1alpha1.ZapNotificationPolicy
+	notifierKey string
+	sent        bool
+}
+
+// rateLimitAllows reports whether p's rate limit lets one more notification
+// to notifierKey through at now. p is read again past the cache first, so the
+// counts of a scan notified just before are seen.
+func (r *ScanReconciler) rateLimitAllows(ctx context.Context, p *zapv1alpha1.ZapNotificationPolicy, notifierKey string, now time.Time) (bool, error) {
+	if p.Spec.RateLimit == nil {
+		return true, nil
+	}
+	if err := r.policyReader().Get(ctx, client.ObjectKeyFromObject(p), p); err != nil {
+		return false, err
+	}
+	rl := p.Spec.RateLimit
+	if rl == nil {
+		return true, nil
+	}
+	for _, st := range p.Status.RateLimits {
+		if st.Notifier == notifierKey && now.Before(st.PeriodStart.Add(rl.Period.Duration)) {
+			return st.Sent < rl.Max, nil
+		}
+	}
+	return true, nil
+}
+
+// countRateLimit counts a notification to notifierKey, sent or dropped,
+// against p's rate limit in p's status. It's called once the scan's status
+// records the notification, so a failed scan update doesn't count it twice,
+// and retries on conflicts so the count isn't lost.
+func (r *ScanReconciler) countRateLimit(ctx context.Context, p *zapv1alpha1.ZapNotificationPolicy, notifierKey string, now time.Time, sent bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.policyReader().Get(ctx, client.ObjectKeyFromObject(p), p); err != nil {
+			return err
+		}
+		rl := p.Spec.RateLimit
+		if rl == nil {
+			return nil
+		}
+
+		var st *zapv1alpha1.RateLimitStatus
+		for i := range p.Status.RateLimits {
+			if p.Status.RateLimits[i].Notifier == notifierKey {
+				st = &p.Status.RateLimits[i]
+			}
+		}
+		if st == nil {
+			p.Status.RateLimits = append(p.Status.RateLimits, zapv1alpha1.RateLimitStatus{Notifier: notifierKey})
+			st = &p.Status.RateLimits[len(p.Status.RateLimits)-1]
+		}
+		if !now.Before(st.PeriodStart.Add(rl.Period.Duration)) {
+			st.PeriodStart, st.Sent, st.Dropped = metav1.NewTime(now), 0, 0
+		}
+		if sent {
+			st.Sent++
+		} else {
+			st.Dropped++
+		}
+		return r.Status().Update(ctx, p)
+	})
+}
+
+// policyReader returns the reader rate limits are read with.
+func (r *ScanReconciler) policyReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/notify"
+)
+
+func TestPolicySelects(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	scan := func(phase string, risk zapv1alpha1.RiskCounts, diff *zapv1alpha1.FindingDiff) *zapv1alpha1.ZapScan {
+		return &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "shop", Labels: map[string]string{"tier": "prod"}},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: phase, AlertsByRisk: &risk, Diff: diff},
+		}
+	}
+	medium := zapv1alpha1.RiskCounts{Medium: 2, Low: 1}
+	onlyLowNew := &zapv1alpha1.FindingDiff{New: 1, NewByRisk: zapv1alpha1.RiskCounts{Low: 1}}
+	nsLabels := labels.Set{"team": "payments"}
+
+	cases := []struct {
+		name string
+		spec zapv1alpha1.ZapNotificationPolicySpec
+		scan *zapv1alpha1.ZapScan
+		want bool
+	}{
+		{"everything", zapv1alpha1.ZapNotificationPolicySpec{}, scan("Succeeded", zapv1alpha1.RiskCounts{}, nil), true},
+		{"listed namespace", zapv1alpha1.ZapNotificationPolicySpec{Namespaces: []string{"shop"}}, scan("Succeeded", medium, nil), true},
+		{"other namespace", zapv1alpha1.ZapNotificationPolicySpec{Namespaces: []string{"blog"}}, scan("Succeeded", medium, nil), false},
+		{"namespace labels", zapv1alpha1.ZapNotificationPolicySpec{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}}, scan("Succeeded", medium, nil), true},
+		{"other namespace labels", zapv1alpha1.ZapNotificationPolicySpec{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}}}, scan("Succeeded", medium, nil), false},
+		{"scan labels", zapv1alpha1.ZapNotificationPolicySpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "dev"}}}, scan("Succeeded", medium, nil), false},
+		{"phase", zapv1alpha1.ZapNotificationPolicySpec{Phases: []zapv1alpha1.PolicyPhase{"Failed"}}, scan("Succeeded", medium, nil), false},
+		{"risk met", zapv1alpha1.ZapNotificationPolicySpec{MinRisk: "medium"}, scan("Succeeded", medium, nil), true},
+		{"risk not met", zapv1alpha1.ZapNotificationPolicySpec{MinRisk: "high"}, scan("Succeeded", medium, nil), false},
+		{"failed ignores risk", zapv1alpha1.ZapNotificationPolicySpec{MinRisk: "high", NewFindingsOnly: true}, scan("Failed", zapv1alpha1.RiskCounts{}, nil), true},
+		{"new findings", zapv1alpha1.ZapNotificationPolicySpec{NewFindingsOnly: true}, scan("Succeeded", medium, onlyLowNew), true},
+		{"new findings below risk", zapv1alpha1.ZapNotificationPolicySpec{NewFindingsOnly: true, MinRisk: "medium"}, scan("Succeeded", medium, onlyLowNew), false},
+		{"nothing new", zapv1alpha1.ZapNotificationPolicySpec{NewFindingsOnly: true}, scan("Succeeded", medium, &zapv1alpha1.FindingDiff{Persisting: 3}), false},
+		{"first run counts as new", zapv1alpha1.ZapNotificationPolicySpec{NewFindingsOnly: true, MinRisk: "medium"}, scan("Succeeded", medium, nil), true},
+		{"first run without findings", zapv1alpha1.ZapNotificationPolicySpec{NewFindingsOnly: true}, scan("Succeeded", zapv1alpha1.RiskCounts{}, nil), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &zapv1alpha1.ZapNotificationPolicy{ObjectMeta: metav1.ObjectMeta{Name: "p"}, Spec: tc.spec}
+			if got := policySelects(ctx, p, tc.scan, notify.NewEvent(tc.scan), nsLabels); got != tc.want {
+				t.Errorf("policySelects = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSendNotifications_PolicyRateLimit(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	created := metav1.NewTime(time.Now().Add(-time.Hour))
+	policy := &zapv1alpha1.ZapNotificationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-high", CreationTimestamp: created},
+		Spec: zapv1alpha1.ZapNotificationPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			MinRisk:           "medium",
+			Notifiers: []zapv1alpha1.NotifierReference{
+				{Namespace: "security", Name: "chat"},
+				{Namespace: "security", Name: "missing"},
+			},
+			RateLimit: &zapv1alpha1.RateLimit{Max: 1, Period: metav1.Duration{Duration: time.Hour}},
+		},
+	}
+	objs := []client.Object{
+		policy,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"env": "prod"}}},
+		&zapv1alpha1.ZapNotifier{
+			ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "security", CreationTimestamp: created},
+			// The notifier's own threshold only applies to scans in its namespace.
+			Spec: zapv1alpha1.ZapNotifierSpec{MinRisk: "high", Slack: &zapv1alpha1.SlackNotifier{WebhookSecret: "slack"}},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "security"}, Data: map[string][]byte{slackWebhookURLKey: []byte(srv.URL)}},
+	}
+	finished := metav1.NewTime(time.Now().Add(-time.Minute))
+	var scans []*zapv1alpha1.ZapScan
+	for _, name := range []string{"run-1", "run-2"} {
+		scan := &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: "Succeeded", FinishedAt: &finished, AlertsByRisk: &zapv1alpha1.RiskCounts{Medium: 1}},
+		}
+		scans = append(scans, scan)
+		objs = append(objs, scan)
+	}
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithStatusSubresource(&zapv1alpha1.ZapScan{}, &zapv1alpha1.ZapNotificationPolicy{}).
+		WithObjects(objs...).Build()
+	r := &ScanReconciler{Client: c, Scheme: s}
+
+	for _, scan := range scans {
+		if _, err := r.sendNotifications(ctx, scan); err != nil {
+			t.Fatalf("send notifications for %s: %v", scan.Name, err)
+		}
+		if len(scan.Status.Notifications) != 1 || scan.Status.Notifications[0].Name != "prod-high/security/chat" {
+			t.Fatalf("expected the policy's notification only, got %+v", scan.Status.Notifications)
+		}
+	}
+	if st := scans[0].Status.Notifications[0]; st.State != zapv1alpha1.NotificationDelivered || st.Type != zapv1alpha1.NotificationSlack {
+		t.Errorf("expected the first run to be delivered, got %+v", st)
+	}
+	if st := scans[1].Status.Notifications[0]; st.State != zapv1alpha1.NotificationRateLimited || st.Attempts != 0 {
+		t.Errorf("expected the second run to be rate limited, got %+v", st)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected one message, got %d", calls.Load())
+	}
+
+	var updated zapv1alpha1.ZapNotificationPolicy
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), &updated); err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	if len(updated.Status.RateLimits) != 1 || updated.Status.RateLimits[0].Sent != 1 || updated.Status.RateLimits[0].Dropped != 1 {
+		t.Errorf("unexpected rate limit status %+v", updated.Status.RateLimits)
+	}
+}
+
+func TestSendNotifications_PolicyNotifierNotSelectedDirectly(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	// The notifier lives next to the scans and selects all of them itself.
+	created := metav1.NewTime(time.Now().Add(-time.Hour))
+	finished := metav1.NewTime(time.Now().Add(-time.Minute))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-1", Namespace: "shop"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Succeeded", FinishedAt: &finished, AlertsByRisk: &zapv1alpha1.RiskCounts{Medium: 1}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithStatusSubresource(&zapv1alpha1.ZapScan{}, &zapv1alpha1.ZapNotificationPolicy{}).
+		WithObjects(
+			scan,
+			&zapv1alpha1.ZapNotificationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "shop", CreationTimestamp: created},
+				Spec: zapv1alpha1.ZapNotificationPolicySpec{
+					Namespaces: []string{"shop"},
+					Notifiers:  []zapv1alpha1.NotifierReference{{Namespace: "shop", Name: "chat"}},
+					RateLimit:  &zapv1alpha1.RateLimit{Max: 1, Period: metav1.Duration{Duration: time.Hour}},
+				},
+			},
+			&zapv1alpha1.ZapNotifier{
+				ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "shop", CreationTimestamp: created},
+				Spec:       zapv1alpha1.ZapNotifierSpec{Slack: &zapv1alpha1.SlackNotifier{WebhookSecret: "slack"}},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "shop"}, Data: map[string][]byte{slackWebhookURLKey: []byte(srv.URL)}},
+		).Build()
+	r := &ScanReconciler{Client: c, Scheme: s}
+
+	if _, err := r.sendNotifications(ctx, scan); err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+	if len(scan.Status.Notifications) != 1 || scan.Status.Notifications[0].Name != "shop/shop/chat" {
+		t.Errorf("expected the policy's notification only, got %+v", scan.Status.Notifications)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected one message, got %d", calls.Load())
+	}
+}
+
+func TestCountRateLimit_NewPeriod(t *testing.T) {
+	ctx := context.Background()
+	s := runtime.NewScheme()
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	start := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	policy := &zapv1alpha1.ZapNotificationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "p"},
+		Spec:       zapv1alpha1.ZapNotificationPolicySpec{RateLimit: &zapv1alpha1.RateLimit{Max: 2, Period: metav1.Duration{Duration: time.Hour}}},
+		Status:     zapv1alpha1.ZapNotificationPolicyStatus{RateLimits: []zapv1alpha1.RateLimitStatus{{Notifier: "ns/n", PeriodStart: start, Sent: 2, Dropped: 5}}},
+	}
+	r := &ScanReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(policy).WithObjects(policy).Build(), Scheme: s}
+
+	now := time.Now()
+	if ok, err := r.rateLimitAllows(ctx, policy, "ns/n", now); err != nil || !ok {
+		t.Fatalf("expected a new period to allow the notification, got %v %v", ok, err)
+	}
+	if err := r.countRateLimit(ctx, policy, "ns/n", now, true); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if st := policy.Status.RateLimits[0]; st.Sent != 1 || st.Dropped != 0 || now.Sub(st.PeriodStart.Time) > time.Second {
+		t.Errorf("expected the period to restart, got %+v", st)
+	}
+}
+
+func TestRateLimit_StalePolicy(t *testing.T) {
+	ctx := context.Background()
+	s := runtime.NewScheme()
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	now := time.Now()
+	policy := &zapv1alpha1.ZapNotificationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "p"},
+		Spec:       zapv1alpha1.ZapNotificationPolicySpec{RateLimit: &zapv1alpha1.RateLimit{Max: 2, Period: metav1.Duration{Duration: time.Hour}}},
+		Status:     zapv1alpha1.ZapNotificationPolicyStatus{RateLimits: []zapv1alpha1.RateLimitStatus{{Notifier: "ns/n", PeriodStart: metav1.NewTime(now), Sent: 1}}},
+	}
+	conflicts := 0
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(policy).WithObjects(policy).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if conflicts < 1 {
+					conflicts++
+					return errors.NewConflict(schema.GroupResource{Resource: "zapnotificationpolicies"}, obj.GetName(), fmt.Errorf("changed"))
+				}
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+		}).Build()
+	r := &ScanReconciler{Client: c, Scheme: s}
+
+	// Another scan used up the limit after this copy was read.
+	stale := policy.DeepCopy()
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), policy); err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	policy.Status.RateLimits[0].Sent = 2
+	conflicts = 1
+	if err := c.Status().Update(ctx, policy); err != nil {
+		t.Fatalf("update policy: %v", err)
+	}
+	if ok, err := r.rateLimitAllows(ctx, stale.DeepCopy(), "ns/n", now); err != nil || ok {
+		t.Errorf("expected the current counts to drop the notification, got %v %v", ok, err)
+	}
+
+	// A conflicting write is retried rather than losing the count.
+	conflicts = 0
+	if err := r.countRateLimit(ctx, stale, "ns/n", now, false); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), policy); err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	if st := policy.Status.RateLimits[0]; conflicts != 1 || st.Sent != 2 || st.Dropped != 1 {
+		t.Errorf("expected the drop to be counted after a conflict, got %+v", st)
+	}
+}
+
+func TestSendNotifications_FailedScanUpdateDoesNotCount(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls.Add(1) }))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	created := metav1.NewTime(time.Now().Add(-time.Hour))
+	finished := metav1.NewTime(time.Now().Add(-time.Minute))
+	policy := &zapv1alpha1.ZapNotificationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", CreationTimestamp: created},
+		Spec: zapv1alpha1.ZapNotificationPolicySpec{
+			Notifiers: []zapv1alpha1.NotifierReference{{Namespace: "security", Name: "chat"}},
+			RateLimit: &zapv1alpha1.RateLimit{Max: 1, Period: metav1.Duration{Duration: time.Hour}},
+		},
+	}
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-1", Namespace: "shop"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Failed", FinishedAt: &finished},
+	}
+	failUpdates := true
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithStatusSubresource(&zapv1alpha1.ZapScan{}, &zapv1alpha1.ZapNotificationPolicy{}).
+		WithObjects(
+			scan, policy,
+			&zapv1alpha1.ZapNotifier{
+				ObjectMeta: metav1.ObjectMeta{Name: "chat", Namespace: "security", CreationTimestamp: created},
+				Spec:       zapv1alpha1.ZapNotifierSpec{Slack: &zapv1alpha1.SlackNotifier{WebhookSecret: "slack"}},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "security"}, Data: map[string][]byte{slackWebhookURLKey: []byte(srv.URL)}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if _, ok := obj.(*zapv1alpha1.ZapScan); ok && failUpdates {
+					return fmt.Errorf("conflict")
+				}
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+		}).Build()
+	r := &ScanReconciler{Client: c, Scheme: s}
+
+	// The scan's status isn't saved, so the retry delivers again but the
+	// notification is counted once.
+	if _, err := r.sendNotifications(ctx, scan.DeepCopy()); err == nil {
+		t.Fatalf("expected the scan update to fail")
+	}
+	failUpdates = false
+	if _, err := r.sendNotifications(ctx, scan.DeepCopy()); err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+	var updated zapv1alpha1.ZapNotificationPolicy
+	if err := c.Get(ctx, client.ObjectKeyFromObject(policy), &updated); err != nil {
+		t.Fatalf("get policy: %v", err)
+	}
+	if len(updated.Status.RateLimits) != 1 || updated.Status.RateLimits[0].Sent != 1 || updated.Status.RateLimits[0].Dropped != 0 {
+		t.Errorf("expected the notification to be counted once, got %+v", updated.Status.RateLimits)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected the delivery to be retried, got %d messages", calls.Load())
+	}
+}
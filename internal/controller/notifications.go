@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/notify"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+)
+
+const (
+	// webhookSigningKey is the key of a webhook's signing Secret holding the HMAC key.
+	webhookSigningKey = "signingKey"
+
+	// slackWebhookURLKey is the key of a Slack notifier's Secret holding the incoming webhook URL.
+	slackWebhookURLKey = "webhookURL"
+
+	// maxMailReportBytes caps the stored reports loaded to attach a scan's
+	// HTML report to a mail.
+	maxMailReportBytes = 10 << 20
+)
+
+// notificationTarget is a destination a finished scan is reported to.
+// The notifier is only built when a delivery is due, as it may read Secrets.
+type notificationTarget struct {
+	name     string
+	typ      string
+	ev       *notify.Event
+	notifier func(ctx context.Context) (notify.Notifier, error)
+
+	// policy is the ZapNotificationPolicy that routed the notification, whose
+	// rate limit for notifierKey applies.
+	policy      *zapv1alpha1.ZapNotificationPolicy
+	notifierKey string
+}
+
+// notificationTargets returns every destination configured for scan: its own
+// webhooks, the ZapNotifiers in its namespace that select it and the
+// notifiers ZapNotificationPolicies route it to. A notifier referenced by a
+// policy is only reached through policies, so their rate limits hold and a
+// scan isn't posted to it twice.
+func (r *ScanReconciler) notificationTargets(ctx context.Context, scan *zapv1alpha1.ZapScan) ([]notificationTarget, error) {
+	ev := notify.NewEvent(scan)
+	var targets []notificationTarget
+	if cfg := scan.Spec.Notifications; cfg != nil {
+		for i := range cfg.Webhooks {
+			wh := &cfg.Webhooks[i]
+			targets = append(targets, notificationTarget{
+				name: wh.Name,
+				typ:  zapv1alpha1.NotificationWebhook,
+				ev:   ev,
+				notifier: func(ctx context.Context) (notify.Notifier, error) {
+					return r.webhookNotifier(ctx, scan.Namespace, wh)
+				},
+			})
+		}
+	}
+
+	routed, referenced, err := r.policyTargetsFor(ctx, scan, ev)
+	if err != nil {
+		return nil, err
+	}
+	notifiers, err := r.notifiersFor(ctx, scan, ev, referenced)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(targets, notifiers...), routed...), nil
+}
+
+// notifiersFor returns the ZapNotifiers that select scan and whose threshold
+// it meets, except those in skip. Notifiers created after the scan finished
+// are left out, so adding one doesn't notify about every past scan.
+func (r *ScanReconciler) notifiersFor(ctx context.Context, scan *zapv1alpha1.ZapScan, ev *notify.Event, skip map[string]bool) ([]notificationTarget, error) {
+	var list zapv1alpha1.ZapNotifierList
+	if err := r.List(ctx, &list, client.InNamespace(scan.Namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+
+	var targets []notificationTarget
+	for i := range list.Items {
+		n := &list.Items[i]
+		if skip[n.Namespace+"/"+n.Name] {
+			continue
+		}
+		if scan.Status.FinishedAt != nil && n.CreationTimestamp.After(scan.Status.FinishedAt.Time) {
+			continue
+		}
+		if !notifierSelects(ctx, n, scan, ev) {
+			continue
+		}
+		if t, ok := r.notifierTarget(ctx, n.Name, n, scan, ev); ok {
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// notifierTarget returns the per-scan notification of n about scan, named
+// name. Digest notifiers and notifiers without a backend have none.
+func (r *ScanReconciler) notifierTarget(ctx context.Context, name string, n *zapv1alpha1.ZapNotifier, scan *zapv1alpha1.ZapScan, ev *notify.Event) (notificationTarget, bool) {
+	t := notificationTarget{name: name, ev: withViewerLink(ev, n.Spec.ViewerURL, scan)}
+	switch {
+	case n.Spec.Slack != nil:
+		slack := n.Spec.Slack
+		t.typ = zapv1alpha1.NotificationSlack
+		t.notifier = func(ctx context.Context) (notify.Notifier, error) {
+			return r.slackNotifier(ctx, n.Namespace, slack)
+		}
+	case n.Spec.SMTP != nil && n.Spec.SMTP.DigestInterval == nil:
+		cfg := n.Spec.SMTP
+		t.typ = zapv1alpha1.NotificationSMTP
+		t.notifier = func(ctx context.Context) (notify.Notifier, error) {
+			m, err := smtpNotifier(ctx, r.Client, n.Namespace, cfg)
+			if err != nil {
+				return nil, err
+			}
+			m.Attachments = reportAttachments(ctx, r.Client, scan, "")
+			return m, nil
+		}
+	case n.Spec.SMTP != nil:
+		// Digests are sent by the ZapNotifier reconciler.
+		return t, false
+	default:
+		ctrl.LoggerFrom(ctx).Info("ignoring notifier without a backend", "notifier", n.Namespace+"/"+n.Name)
+		return t, false
+	}
+	return t, true
+}
+
+// notifierSelects reports whether n notifies about scan: its selector matches
+// and the scan failed or meets the notifier's minimum risk.
+func notifierSelects(ctx context.Context, n *zapv1alpha1.ZapNotifier, scan *zapv1alpha1.ZapScan, ev *notify.Event) bool {
+	if n.Spec.Selector != nil {
+		sel, err := metav1.LabelSelectorAsSelector(n.Spec.Selector)
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Info("ignoring notifier with invalid selector", "notifier", n.Name, "error", err.Error())
+			return false
+		}
+		if !sel.Matches(labels.Set(scan.Labels)) {
+			return false
+		}
+	}
+	return scan.Status.Phase == "Failed" || ev.MeetsRisk(n.Spec.MinRisk)
+}
+
+// withViewerLink returns ev linking the report through the viewer at
+// viewerURL if it has no other link.
+func withViewerLink(ev *notify.Event, viewerURL string, scan *zapv1alpha1.ZapScan) *notify.Event {
+	link := viewerReportURL(viewerURL, scan)
+	if ev.ReportURL != "" || link == "" {
+		return ev
+	}
+	c := *ev
+	c.ReportURL = link
+	return &c
+}
+
+// viewerReportURL links the scan's HTML report through the report viewer at
+// viewerURL, if the report is kept where the viewer can serve it.
+func viewerReportURL(viewerURL string, scan *zapv1alpha1.ZapScan) string {
+	ref := scan.Status.ReportRef
+	if viewerURL == "" || ref == nil || (ref.Type != zapv1alpha1.ReportStorageConfigMap && ref.Type != zapv1alpha1.ReportStorageSecret) {
+		return ""
+	}
+	if !slices.Contains(ref.Files, "zap.html") {
+		return ""
+	}
+	return strings.TrimSuffix(viewerURL, "/") + "/scans/" + url.PathEscape(scan.Namespace) + "/" + url.PathEscape(scan.Name) + "/zap.html"
+}
+
+func (r *ScanReconciler) slackNotifier(ctx context.Context, namespace string, cfg *zapv1alpha1.SlackNotifier) (notify.Notifier, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: cfg.WebhookSecret}, &secret); err != nil {
+		return nil, fmt.Errorf("get webhook secret: %w", err)
+	}
+	u := strings.TrimSpace(string(secret.Data[slackWebhookURLKey]))
+	if u == "" {
+		return nil, fmt.Errorf("secret %s has no %s key", cfg.WebhookSecret, slackWebhookURLKey)
+	}
+	return &notify.Slack{URL: u, Channel: cfg.Channel, Username: cfg.Username}, nil
+}
+
+// smtpNotifier returns the mailer of an SMTP notifier, reading its credentials
+// Secret from namespace.
+func smtpNotifier(ctx context.Context, c client.Reader, namespace string, cfg *zapv1alpha1.SMTPNotifier) (*notify.SMTP, error) {
+	m := &notify.SMTP{Host: cfg.Host, Port: int(cfg.Port), StartTLS: cfg.StartTLS, From: cfg.From, To: cfg.To}
+	if cfg.CredentialsSecret != "" {
+		var secret corev1.Secret
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: cfg.CredentialsSecret}, &secret); err != nil {
+			return nil, fmt.Errorf("get credentials secret: %w", err)
+		}
+		m.Username, m.Password = string(secret.Data["username"]), string(secret.Data["password"])
+		if m.Username == "" {
+			return nil, fmt.Errorf("secret %s has no username key", cfg.CredentialsSecret)
+		}
+	}
+	return m, nil
+}
+
+// reportAttachments returns the scan's HTML report as a mail attachment named
+// prefix + "zap.html". Only reports kept in ConfigMap or Secret storage can be
+// read back; otherwise, or if they're too large to mail, nothing is attached
+// and the mail only links the report.
+func reportAttachments(ctx context.Context, c client.Reader, scan *zapv1alpha1.ZapScan, prefix string) []notify.Attachment {
+	ref := scan.Status.ReportRef
+	if ref == nil || (ref.Type != zapv1alpha1.ReportStorageConfigMap && ref.Type != zapv1alpha1.ReportStorageSecret) || !slices.Contains(ref.Files, "zap.html") {
+		return nil
+	}
+	files, err := reportstore.Load(ctx, c, scan.Namespace, ref, maxMailReportBytes)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Info("not attaching the report", "scan", scan.Name, "error", err.Error())
+		return nil
+	}
+	return []notify.Attachment{{Name: prefix + "zap.html", ContentType: "text/html", Data: files["zap.html"]}}
+}
+
+func (r *ScanReconciler) webhookNotifier(ctx context.Context, namespace string, wh *zapv1alpha1.WebhookNotification) (notify.Notifier, error) {
+	w := &notify.Webhook{URL: wh.URL, ContentType: wh.ContentType, Headers: wh.Headers}
+	if wh.BodyTemplate != "" {
+		body, err := notify.ParseBodyTemplate(wh.BodyTemplate)
+		if err != nil {
+			return nil, err
+		}
+		w.Body = body
+	}
+	if wh.SigningSecret != "" {
+		var secret corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: wh.SigningSecret}, &secret); err != nil {
+			return nil, fmt.Errorf("get signing secret: %w", err)
+		}
+		w.SigningKey = secret.Data[webhookSigningKey]
+		if len(w.SigningKey) == 0 {
+			return nil, fmt.Errorf("secret %s has no %s key", wh.SigningSecret, webhookSigningKey)
+		}
+	}
+	return w, nil
+}
+
+// sendNotifications delivers the notifications of a finished scan that are
+// due and records their state in status. Failed deliveries are retried with
+// backoff until notify.MaxAttempts; the result requeues the scan for the next one.
+func (r *ScanReconciler) sendNotifications(ctx context.Context, scan *zapv1alpha1.ZapScan) (ctrl.Result, error) {
+	targets, err := r.notificationTargets(ctx, scan)
+	if err != nil || len(targets) == 0 {
+		return ctrl.Result{}, err
+	}
+	log := ctrl.LoggerFrom(ctx)
+
+	var requeue time.Duration
+	changed := false
+	var counts []rateLimitCount
+	now := time.Now()
+	for _, t := range targets {
+		st := notificationStatusFor(scan, t.name, t.typ)
+		if st.State != zapv1alpha1.NotificationPending {
+			continue
+		}
+		if st.LastAttemptTime != nil {
+			if wait := time.Until(st.LastAttemptTime.Add(notify.Backoff(st.Attempts))); wait > 0 {
+				requeue = earliest(requeue, wait)
+				continue
+			}
+		}
+		if t.policy != nil && st.Attempts == 0 {
+			allowed, err := r.rateLimitAllows(ctx, t.policy, t.notifierKey, now)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			counts = append(counts, rateLimitCount{policy: t.policy, notifierKey: t.notifierKey, sent: allowed})
+			if !allowed {
+				st.State = zapv1alpha1.NotificationRateLimited
+				changed = true
+				log.Info("notification dropped by rate limit", "notification", t.name, "type", t.typ, "policy", t.policy.Name)
+				continue
+			}
+		}
+
+		err := deliver(ctx, t)
+		attempted := metav1.Now()
+		st.Attempts++
+		st.LastAttemptTime = &attempted
+		changed = true
+		switch {
+		case err == nil:
+			st.State, st.LastError = zapv1alpha1.NotificationDelivered, ""
+			log.Info("delivered notification", "notification", t.name, "type", t.typ)
+		case st.Attempts >= notify.MaxAttempts:
+			st.State, st.LastError = zapv1alpha1.NotificationFailed, err.Error()
+			log.Error(err, "giving up on notification", "notification", t.name, "type", t.typ, "attempts", st.Attempts)
+		default:
+			st.LastError = err.Error()
+			requeue = earliest(requeue, notify.Backoff(st.Attempts))
+			log.Error(err, "failed to deliver notification, will retry", "notification", t.name, "type", t.typ, "attempts", st.Attempts)
+		}
+	}
+
+	if changed {
+		if err := r.Status().Update(ctx, scan); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	for _, c := range counts {
+		if err := r.countRateLimit(ctx, c.policy, c.notifierKey, now, c.sent); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+func deliver(ctx context.Context, t notificationTarget) error {
+	n, err := t.notifier(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, notify.DefaultTimeout)
+	defer cancel()
+	return n.Notify(ctx, t.ev)
+}
+
+// notificationStatusFor returns the status entry of a notification, adding a
+// pending one if it has none yet.
+func notificationStatusFor(scan *zapv1alpha1.ZapScan, name, typ string) *zapv1alpha1.NotificationStatus {
+	for i := range scan.Status.Notifications {
+		if st := &scan.Status.Notifications[i]; st.Type == typ && st.Name == name {
+			return st
+		}
+	}
+	scan.Status.Notifications = append(scan.Status.Notifications, zapv1alpha1.NotificationStatus{
+		Name:  name,
+		Type:  typ,
+		State: zapv1alpha1.NotificationPending,
+	})
+	return &scan.Status.Notifications[len(scan.Status.Notifications)-1]
+}
+
+// earliest returns the sooner of two requeue delays, where zero means none.
+func earliest(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
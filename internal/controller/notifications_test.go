@@ -0,0 +1,385 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/notify"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+)
+
+func TestScanReconciler_SendsWebhookNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	var got notify.Event
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails so it has to be retried.
+		if calls.Add(1) == 1 {
+			http.Error(w, "try again", http.StatusBadGateway)
+			return
+		}
+		signature = r.Header.Get(notify.SignatureHeader)
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, UID: "uid-1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target: "https://example.com",
+			Notifications: &zapv1alpha1.Notifications{Webhooks: []zapv1alpha1.WebhookNotification{
+				{Name: "tracker", URL: srv.URL, SigningSecret: "webhook"},
+				{Name: "unsigned", URL: srv.URL, SigningSecret: "missing"},
+			}},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: "ns1"},
+		Data:       map[string][]byte{webhookSigningKey: []byte("s3cret")},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod, secret).Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+	lctx := ctrl.LoggerInto(ctx, ctrl.Log.WithName("test"))
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scan)}
+
+	res, err := r.Reconcile(lctx, req)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if res.RequeueAfter != notify.Backoff(1) {
+		t.Errorf("expected a retry after %v, got %v", notify.Backoff(1), res.RequeueAfter)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, req.NamespacedName, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if len(updated.Status.Notifications) != 2 {
+		t.Fatalf("expected both notifications in status, got %+v", updated.Status.Notifications)
+	}
+	for _, st := range updated.Status.Notifications {
+		if st.Type != zapv1alpha1.NotificationWebhook || st.State != zapv1alpha1.NotificationPending || st.Attempts != 1 || st.LastError == "" {
+			t.Errorf("expected a pending retry, got %+v", st)
+		}
+	}
+
+	// A retry that isn't due yet only requeues.
+	if res, err := r.Reconcile(lctx, req); err != nil || res.RequeueAfter <= 0 || calls.Load() != 1 {
+		t.Fatalf("expected no delivery before the backoff passed, got %v %v after %d calls", res, err, calls.Load())
+	}
+
+	past := metav1.NewTime(time.Now().Add(-time.Minute))
+	for i := range updated.Status.Notifications {
+		updated.Status.Notifications[i].LastAttemptTime = &past
+	}
+	if err := r.Status().Update(ctx, &updated); err != nil {
+		t.Fatalf("update scan: %v", err)
+	}
+	if _, err := r.Reconcile(lctx, req); err != nil {
+		t.Fatalf("reconcile retry: %v", err)
+	}
+	if err := r.Get(ctx, req.NamespacedName, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	tracker, unsigned := updated.Status.Notifications[0], updated.Status.Notifications[1]
+	if tracker.State != zapv1alpha1.NotificationDelivered || tracker.Attempts != 2 || tracker.LastError != "" {
+		t.Errorf("expected the webhook to be delivered on retry, got %+v", tracker)
+	}
+	if unsigned.State != zapv1alpha1.NotificationPending || unsigned.Attempts != 2 {
+		t.Errorf("expected the webhook without a signing secret to keep retrying, got %+v", unsigned)
+	}
+	if got.Scan != "s1" || got.Phase != "Succeeded" || got.AlertsFound != 2 {
+		t.Errorf("unexpected event %+v", got)
+	}
+	if signature == "" {
+		t.Errorf("expected a signed request")
+	}
+}
+
+func TestSendNotifications_GivesUp(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target: "https://example.com",
+			Notifications: &zapv1alpha1.Notifications{Webhooks: []zapv1alpha1.WebhookNotification{
+				{Name: "broken", URL: "http://127.0.0.1:0", BodyTemplate: "{{"},
+			}},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Failed", Notifications: []zapv1alpha1.NotificationStatus{{
+			Name: "broken", Type: zapv1alpha1.NotificationWebhook, State: zapv1alpha1.NotificationPending,
+			Attempts: notify.MaxAttempts - 1, LastAttemptTime: &past,
+		}}},
+	}
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan).Build(),
+		Scheme: s,
+	}
+
+	res, err := r.sendNotifications(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), scan)
+	if err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+	if res.RequeueAfter != 0 {
+		t.Errorf("expected no more retries, got %v", res.RequeueAfter)
+	}
+	st := scan.Status.Notifications[0]
+	if st.State != zapv1alpha1.NotificationFailed || st.Attempts != notify.MaxAttempts || st.LastError == "" {
+		t.Errorf("expected the notification to be given up, got %+v", st)
+	}
+}
+
+func TestSendNotifications_ZapNotifiers(t *testing.T) {
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	var text string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var msg struct {
+			Blocks []struct {
+				Text *struct{ Text string } `json:"text"`
+			} `json:"blocks"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		for _, b := range msg.Blocks {
+			if b.Text != nil {
+				text += b.Text.Text + "\n"
+			}
+		}
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	finished := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	before := metav1.NewTime(finished.Add(-time.Hour))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", Labels: map[string]string{"team": "web"}},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status: zapv1alpha1.ZapScanStatus{
+			Phase:        "Succeeded",
+			FinishedAt:   &finished,
+			AlertsFound:  2,
+			AlertsByRisk: &zapv1alpha1.RiskCounts{Medium: 2},
+			ReportRef:    &zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStorageConfigMap, Names: []string{"s1-report"}, Files: []string{"zap.html", "zap.json"}},
+		},
+	}
+	notifier := func(name string, created metav1.Time, spec zapv1alpha1.ZapNotifierSpec) *zapv1alpha1.ZapNotifier {
+		if spec.Slack == nil {
+			spec.Slack = &zapv1alpha1.SlackNotifier{WebhookSecret: "slack"}
+		}
+		return &zapv1alpha1.ZapNotifier{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", CreationTimestamp: created}, Spec: spec}
+	}
+	objs := []client.Object{
+		scan,
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "ns1"}, Data: map[string][]byte{slackWebhookURLKey: []byte(srv.URL + "\n")}},
+		notifier("security", before, zapv1alpha1.ZapNotifierSpec{
+			MinRisk:   "medium",
+			ViewerURL: "https://zap.example.com/",
+			Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+		}),
+		notifier("high-only", before, zapv1alpha1.ZapNotifierSpec{MinRisk: "high"}),
+		notifier("other-team", before, zapv1alpha1.ZapNotifierSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "api"}}}),
+		notifier("added-later", metav1.NewTime(time.Now()), zapv1alpha1.ZapNotifierSpec{}),
+		notifier("other-namespace", before, zapv1alpha1.ZapNotifierSpec{}),
+	}
+	objs[len(objs)-1].SetNamespace("ns2")
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(objs...).Build(),
+		Scheme: s,
+	}
+	res, err := r.sendNotifications(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), scan)
+	if err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+	if res.RequeueAfter != 0 {
+		t.Errorf("expected no retries, got %v", res.RequeueAfter)
+	}
+
+	if calls.Load() != 1 || len(scan.Status.Notifications) != 1 {
+		t.Fatalf("expected only the security notifier, got %d calls and %+v", calls.Load(), scan.Status.Notifications)
+	}
+	st := scan.Status.Notifications[0]
+	if st.Name != "security" || st.Type != zapv1alpha1.NotificationSlack || st.State != zapv1alpha1.NotificationDelivered {
+		t.Errorf("unexpected status %+v", st)
+	}
+	if want := "<https://zap.example.com/scans/ns1/s1/zap.html|View report>"; !strings.Contains(text, want) {
+		t.Errorf("expected the viewer link %q in:\n%s", want, text)
+	}
+
+	// A failed scan is notified regardless of the threshold.
+	scan.Status.Phase, scan.Status.AlertsByRisk, scan.Status.Notifications = "Failed", nil, nil
+	if _, err := r.sendNotifications(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), scan); err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+	if len(scan.Status.Notifications) != 2 {
+		t.Errorf("expected failed scans to reach every selecting notifier, got %+v", scan.Status.Notifications)
+	}
+}
+
+func TestSendNotifications_SameNameDifferentType(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	var webhooks, slacks atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { webhooks.Add(1) }))
+	defer hook.Close()
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { slacks.Add(1) }))
+	defer slack.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	finished := metav1.NewTime(time.Now().Add(-time.Minute))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:        "https://example.com",
+			Notifications: &zapv1alpha1.Notifications{Webhooks: []zapv1alpha1.WebhookNotification{{Name: "security", URL: hook.URL}}},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Failed", FinishedAt: &finished},
+	}
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(
+			scan,
+			&zapv1alpha1.ZapNotifier{
+				ObjectMeta: metav1.ObjectMeta{Name: "security", Namespace: "ns1", CreationTimestamp: metav1.NewTime(finished.Add(-time.Hour))},
+				Spec:       zapv1alpha1.ZapNotifierSpec{Slack: &zapv1alpha1.SlackNotifier{WebhookSecret: "slack"}},
+			},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "ns1"}, Data: map[string][]byte{slackWebhookURLKey: []byte(slack.URL)}},
+		).Build(),
+		Scheme: s,
+	}
+	if _, err := r.sendNotifications(ctx, scan); err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+
+	if webhooks.Load() != 1 || slacks.Load() != 1 {
+		t.Errorf("expected the webhook and the notifier to be sent once each, got %d and %d", webhooks.Load(), slacks.Load())
+	}
+	types := map[string]bool{}
+	for _, st := range scan.Status.Notifications {
+		if st.Name != "security" || st.State != zapv1alpha1.NotificationDelivered {
+			t.Errorf("unexpected status %+v", st)
+		}
+		types[st.Type] = true
+	}
+	if len(scan.Status.Notifications) != 2 || !types[zapv1alpha1.NotificationWebhook] || !types[zapv1alpha1.NotificationSlack] {
+		t.Errorf("expected a status per type, got %+v", scan.Status.Notifications)
+	}
+}
+
+func TestSendNotifications_SMTP(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	port, messages := fakeSMTPServer(t)
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	finished := metav1.NewTime(time.Now().Add(-time.Minute))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", UID: "uid-1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Succeeded", FinishedAt: &finished, AlertsFound: 1, AlertsByRisk: &zapv1alpha1.RiskCounts{Low: 1}},
+	}
+	notifiers := []client.Object{
+		&zapv1alpha1.ZapNotifier{
+			ObjectMeta: metav1.ObjectMeta{Name: "owners", Namespace: "ns1"},
+			Spec: zapv1alpha1.ZapNotifierSpec{SMTP: &zapv1alpha1.SMTPNotifier{
+				Host: "127.0.0.1", Port: port, From: "zap@example.com", To: []string{"owners@example.com"},
+			}},
+		},
+		&zapv1alpha1.ZapNotifier{
+			ObjectMeta: metav1.ObjectMeta{Name: "digest", Namespace: "ns1"},
+			Spec: zapv1alpha1.ZapNotifierSpec{SMTP: &zapv1alpha1.SMTPNotifier{
+				Host: "127.0.0.1", Port: port, From: "zap@example.com", To: []string{"compliance@example.com"},
+				DigestInterval: &metav1.Duration{Duration: 24 * time.Hour},
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(append(notifiers, scan)...).Build()
+	ref, err := reportstore.Save(ctx, c, s, scan, zapv1alpha1.ReportStorageSecret, map[string][]byte{"zap.html": []byte("<html>report</html>"), "zap.json": []byte("{}")})
+	if err != nil {
+		t.Fatalf("save reports: %v", err)
+	}
+	scan.Status.ReportRef = ref
+
+	r := &ScanReconciler{Client: c, Scheme: s}
+	if _, err := r.sendNotifications(ctx, scan); err != nil {
+		t.Fatalf("send notifications: %v", err)
+	}
+	if len(scan.Status.Notifications) != 1 || scan.Status.Notifications[0].Type != zapv1alpha1.NotificationSMTP || scan.Status.Notifications[0].State != zapv1alpha1.NotificationDelivered {
+		t.Fatalf("expected only the per-scan mail to be delivered, got %+v", scan.Status.Notifications)
+	}
+	subject, _, attachments := parseMail(t, <-messages)
+	if subject != "ZAP scan ns1/s1 succeeded: 1 alerts" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if attachments["zap.html"] != "<html>report</html>" || len(attachments) != 1 {
+		t.Errorf("expected the HTML report to be attached, got %v", attachments)
+	}
+}
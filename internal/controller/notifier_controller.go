@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/notify"
+)
+
+// ZapNotifierReconciler sends the periodic digests of SMTP notifiers. Per-scan
+// notifications are sent by the ScanReconciler.
+type ZapNotifierReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *ZapNotifierReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var n zapv1alpha1.ZapNotifier
+	if err := r.Get(ctx, req.NamespacedName, &n); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if n.Spec.SMTP == nil || n.Spec.SMTP.DigestInterval == nil || n.Spec.SMTP.DigestInterval.Duration <= 0 {
+		return ctrl.Result{}, nil
+	}
+	interval := n.Spec.SMTP.DigestInterval.Duration
+
+	since := n.CreationTimestamp.Time
+	if n.Status.LastDigestTime != nil {
+		since = n.Status.LastDigestTime.Time
+	}
+	now := time.Now()
+	if wait := since.Add(interval).Sub(now); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	evs, attachments, digested, err := r.digestFor(ctx, &n, now)
+	if err == nil && len(evs) > 0 {
+		var m *notify.SMTP
+		m, err = smtpNotifier(ctx, r.Client, n.Namespace, n.Spec.SMTP)
+		if err == nil {
+			m.Attachments = attachments
+			sendCtx, cancel := context.WithTimeout(ctx, notify.DefaultTimeout)
+			err = m.SendDigest(sendCtx, n.Namespace, evs)
+			cancel()
+		}
+	}
+	if err != nil {
+		// Keep the status so the failed digest's scans are included in the retry.
+		if n.Status.LastError != err.Error() {
+			n.Status.LastError = err.Error()
+			if uerr := r.Status().Update(ctx, &n); uerr != nil {
+				return ctrl.Result{}, uerr
+			}
+		}
+		return ctrl.Result{}, err
+	}
+
+	if len(evs) > 0 {
+		ctrl.LoggerFrom(ctx).Info("sent notification digest", "notifier", n.Name, "scans", len(evs))
+	}
+	t := metav1.NewTime(now)
+	n.Status.LastDigestTime, n.Status.DigestedScans, n.Status.LastError = &t, digested, ""
+	if err := r.Status().Update(ctx, &n); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// digestFor returns the events and HTML reports of the scans selected by n
+// that finished after n was created and up to now and no digest covered yet,
+// oldest first. It also returns the UIDs of every finished scan covered once
+// the digest is sent, selected or not, for n's status.
+func (r *ZapNotifierReconciler) digestFor(ctx context.Context, n *zapv1alpha1.ZapNotifier, now time.Time) ([]*notify.Event, []notify.Attachment, []string, error) {
+	var list zapv1alpha1.ZapScanList
+	if err := r.List(ctx, &list, client.InNamespace(n.Namespace)); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// A scan is covered by the first digest after its status is saved,
+	// whenever its Job finished, so scans are tracked by UID rather than
+	// by a time window. Deleted scans drop out of the list.
+	var scans []*zapv1alpha1.ZapScan
+	var digested []string
+	for i := range list.Items {
+		scan := &list.Items[i]
+		finished := scan.Status.FinishedAt
+		if finished == nil || !finished.After(n.CreationTimestamp.Time) || finished.After(now) {
+			continue
+		}
+		if scan.Status.Phase != "Succeeded" && scan.Status.Phase != "Failed" {
+			continue
+		}
+		digested = append(digested, string(scan.UID))
+		if !slices.Contains(n.Status.DigestedScans, string(scan.UID)) {
+			scans = append(scans, scan)
+		}
+	}
+	sort.Strings(digested)
+	sort.Slice(scans, func(i, j int) bool {
+		return scans[i].Status.FinishedAt.Before(scans[j].Status.FinishedAt)
+	})
+
+	var evs []*notify.Event
+	var attachments []notify.Attachment
+	var size int
+	for _, scan := range scans {
+		ev := notify.NewEvent(scan)
+		if !notifierSelects(ctx, n, scan, ev) {
+			continue
+		}
+		evs = append(evs, withViewerLink(ev, n.Spec.ViewerURL, scan))
+		// Later reports are only linked once the mail would get too large,
+		// and aren't loaded once nothing more fits.
+		if size >= maxMailReportBytes {
+			continue
+		}
+		for _, a := range reportAttachments(ctx, r.Client, scan, scan.Name+"-") {
+			if size += len(a.Data); size <= maxMailReportBytes {
+				attachments = append(attachments, a)
+			}
+		}
+	}
+	return evs, attachments, digested, nil
+}
+
+func (r *ZapNotifierReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&zapv1alpha1.ZapNotifier{}).
+		Complete(r)
+}
@@ -0,0 +1,330 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+)
+
+// fakeSMTPServer accepts mail without authentication and sends every
+// received message to the returned channel.
+func fakeSMTPServer(t *testing.T) (int32, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	messages := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				r := bufio.NewReader(conn)
+				_, _ = conn.Write([]byte("220 localhost ESMTP\r\n"))
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(strings.Fields(line)[0]) {
+					case "DATA":
+						_, _ = conn.Write([]byte("354 go ahead\r\n"))
+						var data strings.Builder
+						for {
+							l, err := r.ReadString('\n')
+							if err != nil || l == ".\r\n" {
+								break
+							}
+							data.WriteString(l)
+						}
+						messages <- data.String()
+						_, _ = conn.Write([]byte("250 queued\r\n"))
+					case "QUIT":
+						_, _ = conn.Write([]byte("221 bye\r\n"))
+						return
+					default:
+						_, _ = conn.Write([]byte("250 ok\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return int32(ln.Addr().(*net.TCPAddr).Port), messages
+}
+
+func TestZapNotifierReconciler_Digest(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	port, messages := fakeSMTPServer(t)
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	now := time.Now()
+	finished := func(ago time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(-ago).Truncate(time.Second))
+		return &t
+	}
+	scan := func(name, phase string, ago time.Duration, risk zapv1alpha1.RiskCounts) *zapv1alpha1.ZapScan {
+		return &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", UID: types.UID("uid-" + name)},
+			Spec:       zapv1alpha1.ZapScanSpec{Target: "https://" + name + ".example.com"},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: phase, FinishedAt: finished(ago), AlertsByRisk: &risk},
+		}
+	}
+	reported := scan("web", "Succeeded", 30*time.Minute, zapv1alpha1.RiskCounts{High: 1})
+	broken := scan("api", "Failed", 20*time.Minute, zapv1alpha1.RiskCounts{})
+	clean := scan("docs", "Succeeded", 10*time.Minute, zapv1alpha1.RiskCounts{Low: 3})
+	old := scan("old", "Succeeded", 3*time.Hour, zapv1alpha1.RiskCounts{High: 2})
+	running := scan("next", "Running", 0, zapv1alpha1.RiskCounts{})
+	running.Status.FinishedAt = nil
+
+	notifier := &zapv1alpha1.ZapNotifier{
+		ObjectMeta: metav1.ObjectMeta{Name: "compliance", Namespace: "ns1", CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+		Spec: zapv1alpha1.ZapNotifierSpec{
+			MinRisk: "medium",
+			SMTP: &zapv1alpha1.SMTPNotifier{
+				Host: "127.0.0.1", Port: port, From: "zap@example.com", To: []string{"compliance@example.com"},
+				DigestInterval: &metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithStatusSubresource(&zapv1alpha1.ZapScan{}, &zapv1alpha1.ZapNotifier{}).
+		WithObjects(reported, broken, clean, old, running, notifier).Build()
+
+	ref, err := reportstore.Save(ctx, c, s, reported, zapv1alpha1.ReportStorageConfigMap, map[string][]byte{"zap.html": []byte("<html>web report</html>")})
+	if err != nil {
+		t.Fatalf("save reports: %v", err)
+	}
+	reported.Status.ReportRef = ref
+	if err := c.Status().Update(ctx, reported); err != nil {
+		t.Fatalf("update scan: %v", err)
+	}
+
+	r := &ZapNotifierReconciler{Client: c, Scheme: s}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(notifier)}
+	res, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if res.RequeueAfter != time.Hour {
+		t.Errorf("expected the next digest in an hour, got %v", res.RequeueAfter)
+	}
+
+	var msg string
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no digest received")
+	}
+	subject, body, attachments := parseMail(t, msg)
+	if subject != "ZAP scan digest for ns1: 2 scans, 1 high alerts" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if !strings.Contains(body, "<td>web</td>") || !strings.Contains(body, "<td>api</td>") || strings.Contains(body, "<td>docs</td>") || strings.Contains(body, "<td>old</td>") {
+		t.Errorf("expected the high-risk and the failed scan only:\n%s", body)
+	}
+	if attachments["web-zap.html"] != "<html>web report</html>" || len(attachments) != 1 {
+		t.Errorf("expected the stored report to be attached, got %v", attachments)
+	}
+
+	var updated zapv1alpha1.ZapNotifier
+	if err := c.Get(ctx, req.NamespacedName, &updated); err != nil {
+		t.Fatalf("get notifier: %v", err)
+	}
+	if updated.Status.LastDigestTime == nil || updated.Status.LastError != "" {
+		t.Errorf("unexpected status %+v", updated.Status)
+	}
+
+	// The next digest isn't due yet.
+	if res, err := r.Reconcile(ctx, req); err != nil || res.RequeueAfter <= 0 || res.RequeueAfter > time.Hour {
+		t.Errorf("expected to wait for the next digest, got %v %v", res, err)
+	}
+	select {
+	case <-messages:
+		t.Errorf("expected no second digest")
+	default:
+	}
+
+	// A scan whose Job finished before the digest but whose status was saved
+	// after it goes into the next digest.
+	late := scan("late", "Failed", 40*time.Minute, zapv1alpha1.RiskCounts{})
+	if err := c.Create(ctx, late); err != nil {
+		t.Fatalf("create scan: %v", err)
+	}
+	due := metav1.NewTime(now.Add(-2 * time.Hour))
+	updated.Status.LastDigestTime = &due
+	if err := c.Status().Update(ctx, &updated); err != nil {
+		t.Fatalf("update notifier: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no digest received")
+	}
+	if _, body, _ := parseMail(t, msg); !strings.Contains(body, "<td>late</td>") || strings.Contains(body, "<td>web</td>") || strings.Contains(body, "<td>api</td>") {
+		t.Errorf("expected only the late scan:\n%s", body)
+	}
+}
+
+func TestZapNotifierReconciler_DigestStopsLoadingReportsOverBudget(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	now := time.Now()
+	var scans []*zapv1alpha1.ZapScan
+	for i, name := range []string{"big", "web"} {
+		finished := metav1.NewTime(now.Add(-time.Duration(2-i) * time.Minute))
+		scans = append(scans, &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", UID: types.UID("uid-" + name)},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: "Succeeded", FinishedAt: &finished},
+		})
+	}
+	var loaded []string
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithStatusSubresource(&zapv1alpha1.ZapScan{}).
+		WithObjects(scans[0], scans[1]).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*corev1.ConfigMap); ok {
+					loaded = append(loaded, key.Name)
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	for i, html := range []string{strings.Repeat("x", maxMailReportBytes), "<html>web report</html>"} {
+		ref, err := reportstore.Save(ctx, c, s, scans[i], zapv1alpha1.ReportStorageConfigMap, map[string][]byte{"zap.html": []byte(html)})
+		if err != nil {
+			t.Fatalf("save reports: %v", err)
+		}
+		scans[i].Status.ReportRef = ref
+		if err := c.Status().Update(ctx, scans[i]); err != nil {
+			t.Fatalf("update scan: %v", err)
+		}
+	}
+	loaded = nil
+
+	n := &zapv1alpha1.ZapNotifier{ObjectMeta: metav1.ObjectMeta{Name: "digest", Namespace: "ns1"}}
+	r := &ZapNotifierReconciler{Client: c, Scheme: s}
+	evs, attachments, _, err := r.digestFor(ctx, n, now)
+	if err != nil {
+		t.Fatalf("digest: %v", err)
+	}
+	if len(evs) != 2 || len(attachments) != 1 || attachments[0].Name != "big-zap.html" {
+		t.Errorf("expected both scans and the first report only, got %d scans and %d reports", len(evs), len(attachments))
+	}
+	if len(loaded) != 1 || loaded[0] != reportstore.ChunkName(scans[0].Name, 0) {
+		t.Errorf("expected only the first report to be loaded, got %v", loaded)
+	}
+}
+
+func TestZapNotifierReconciler_DigestFailure(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	done := metav1.NewTime(time.Now().Add(-time.Minute))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Failed", FinishedAt: &done},
+	}
+	notifier := &zapv1alpha1.ZapNotifier{
+		ObjectMeta: metav1.ObjectMeta{Name: "compliance", Namespace: "ns1", CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour))},
+		Spec: zapv1alpha1.ZapNotifierSpec{SMTP: &zapv1alpha1.SMTPNotifier{
+			Host: "127.0.0.1", Port: 1, From: "zap@example.com", To: []string{"compliance@example.com"},
+			CredentialsSecret: "smtp", DigestInterval: &metav1.Duration{Duration: time.Hour},
+		}},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "smtp", Namespace: "ns1"}, Data: map[string][]byte{"password": []byte("x")}}
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapNotifier{}).WithObjects(scan, notifier, secret).Build()
+
+	r := &ZapNotifierReconciler{Client: c, Scheme: s}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(notifier)}); err == nil {
+		t.Fatalf("expected the digest to fail")
+	}
+	var updated zapv1alpha1.ZapNotifier
+	if err := c.Get(ctx, client.ObjectKeyFromObject(notifier), &updated); err != nil {
+		t.Fatalf("get notifier: %v", err)
+	}
+	if updated.Status.LastDigestTime != nil || !strings.Contains(updated.Status.LastError, "username") {
+		t.Errorf("expected the failure to be recorded without advancing the digest, got %+v", updated.Status)
+	}
+}
+
+// parseMail returns the subject, the HTML body and the attachments of a mail.
+func parseMail(t *testing.T, data string) (subject, body string, attachments map[string]string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	subject, _ = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parse content type: %v", err)
+	}
+	attachments = map[string]string{}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		b, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if name := p.FileName(); name != "" {
+			attachments[name] = string(b)
+		} else {
+			body = string(b)
+		}
+	}
+	return subject, body, attachments
+}
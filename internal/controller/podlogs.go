@@ -22,7 +22,8 @@ func (r *ScanReconciler) podsForJob(ctx context.Context, job *batchv1.Job) (*cor
 	return &pods, nil
 }
 
-func (r *ScanReconciler) getPodLogs(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+// getPodLogs returns a stream of the container's logs. Callers must close it.
+func (r *ScanReconciler) getPodLogs(ctx context.Context, namespace, podName, container string) (io.ReadCloser, error) {
 	cfg, err := ctrl.GetConfig()
 	if err != nil {
 		return nil, err
@@ -33,15 +34,5 @@ func (r *ScanReconciler) getPodLogs(ctx context.Context, namespace, podName, con
 	}
 
 	req := cs.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{Container: container})
-	stream, err := req.Stream(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = stream.Close() }()
-
-	b, err := io.ReadAll(stream)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
+	return req.Stream(ctx)
 }
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/pullrequest"
+)
+
+// pullRequestTokenKey is the key of the pull request token Secret holding the API token.
+const pullRequestTokenKey = "token"
+
+// commentOnPullRequest posts or updates the summary comment of a finished
+// scan on the pull request named by its annotations, and records the outcome
+// in status. Failures don't fail the scan.
+func (r *ScanReconciler) commentOnPullRequest(ctx context.Context, scan *zapv1alpha1.ZapScan) {
+	if !hasPullRequest(scan) {
+		return
+	}
+	repo := scan.Annotations[zapv1alpha1.AnnotationPullRequestRepository]
+	number := scan.Annotations[zapv1alpha1.AnnotationPullRequestNumber]
+
+	created, err := r.postPullRequestComment(ctx, scan)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to comment on pull request", "repository", repo, "number", number)
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionPullRequestCommented,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonPullRequestCommentFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+	verb := "updated"
+	if created {
+		verb = "created"
+	}
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionPullRequestCommented,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonPullRequestCommented,
+		Message: fmt.Sprintf("%s the summary comment on %s#%s", verb, repo, number),
+	})
+}
+
+// hasPullRequest reports whether scan is annotated with a pull request to comment on.
+func hasPullRequest(scan *zapv1alpha1.ZapScan) bool {
+	return scan.Annotations[zapv1alpha1.AnnotationPullRequestRepository] != "" ||
+		scan.Annotations[zapv1alpha1.AnnotationPullRequestNumber] != ""
+}
+
+func (r *ScanReconciler) postPullRequestComment(ctx context.Context, scan *zapv1alpha1.ZapScan) (bool, error) {
+	number, err := strconv.Atoi(scan.Annotations[zapv1alpha1.AnnotationPullRequestNumber])
+	if err != nil || number <= 0 {
+		return false, fmt.Errorf("annotation %s must be a pull request number", zapv1alpha1.AnnotationPullRequestNumber)
+	}
+	secretName := scan.Annotations[zapv1alpha1.AnnotationPullRequestTokenSecret]
+	if secretName == "" {
+		return false, fmt.Errorf("annotation %s is required", zapv1alpha1.AnnotationPullRequestTokenSecret)
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: scan.Namespace, Name: secretName}, &secret); err != nil {
+		return false, fmt.Errorf("get pull request token: %w", err)
+	}
+	token := string(secret.Data[pullRequestTokenKey])
+	if token == "" {
+		return false, fmt.Errorf("secret %s has no %s key", secretName, pullRequestTokenKey)
+	}
+
+	c := &pullrequest.Client{
+		Provider:   scan.Annotations[zapv1alpha1.AnnotationPullRequestProvider],
+		URL:        scan.Annotations[zapv1alpha1.AnnotationPullRequestAPIURL],
+		Repository: scan.Annotations[zapv1alpha1.AnnotationPullRequestRepository],
+		Number:     number,
+		Token:      token,
+	}
+	if err := checkSecretURL(&secret, c.APIURL()); err != nil {
+		return false, err
+	}
+	marker := pullRequestCommentMarker(scan)
+	return c.UpsertComment(ctx, marker, marker+"\n"+pullRequestComment(scan))
+}
+
+// pullRequestCommentMarker identifies the summary comment of a target, so
+// later scans of the same preview environment update it instead of adding
+// another.
+func pullRequestCommentMarker(scan *zapv1alpha1.ZapScan) string {
+	h := sha256.Sum256([]byte(scan.Namespace + "\x00" + normalizeFindingURL(scan.Spec.Target)))
+	return "<!-- zap-operator summary " + hex.EncodeToString(h[:])[:12] + " -->"
+}
+
+// pullRequestComment renders the markdown summary of a finished scan.
+func pullRequestComment(scan *zapv1alpha1.ZapScan) string {
+	var b strings.Builder
+	icon := ":white_check_mark:"
+	if scan.Status.Phase != "Succeeded" {
+		icon = ":x:"
+	}
+	if c := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionGatePassed); c != nil && c.Status == metav1.ConditionFalse {
+		icon = ":x:"
+	}
+	fmt.Fprintf(&b, "### %s ZAP scan %s\n\n", icon, scan.Status.Phase)
+	fmt.Fprintf(&b, "Target `%s`, scanned by ZapScan `%s/%s`.\n\n", scan.Spec.Target, scan.Namespace, scan.Name)
+
+	risk := scan.Status.AlertsByRisk
+	if risk == nil {
+		b.WriteString("The scan report couldn't be parsed, so there are no alert counts.\n")
+		if scan.Status.LastError != "" {
+			fmt.Fprintf(&b, "\n> %s\n", markdownLine(scan.Status.LastError))
+		}
+		return b.String()
+	}
+
+	b.WriteString("| Risk | Alerts |\n| --- | ---: |\n")
+	fmt.Fprintf(&b, "| High | %d |\n| Medium | %d |\n| Low | %d |\n| Informational | %d |\n", risk.High, risk.Medium, risk.Low, risk.Informational)
+	if c := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionGatePassed); c != nil {
+		result := "passed"
+		if c.Status != metav1.ConditionTrue {
+			result = "failed"
+		}
+		fmt.Fprintf(&b, "\nQuality gate **%s**: %s.\n", result, markdownLine(c.Message))
+	}
+	if len(scan.Status.TopAlerts) > 0 {
+		b.WriteString("\n**Top alerts**\n\n| Alert | Risk | Instances |\n| --- | --- | ---: |\n")
+		for _, a := range scan.Status.TopAlerts {
+			fmt.Fprintf(&b, "| %s | %s | %d |\n", markdownLine(a.Name), a.Risk, a.Count)
+		}
+	}
+	if scan.Status.Phase == "Failed" && scan.Status.LastError != "" {
+		fmt.Fprintf(&b, "\n> %s\n", markdownLine(scan.Status.LastError))
+	}
+	return b.String()
+}
+
+// markdownLine keeps s on one line and out of table syntax.
+func markdownLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(s, "|", `\|`)
+}
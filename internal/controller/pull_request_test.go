@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestScanReconciler_CommentsOnPullRequest(t *testing.T) {
+	var mu sync.Mutex
+	var comments []string
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer ghp_t0ken" {
+			http.Error(w, "Bad credentials", http.StatusUnauthorized)
+			return
+		}
+		var in struct {
+			Body string `json:"body"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		switch r.Method {
+		case http.MethodGet:
+			out := []map[string]any{}
+			for i, c := range comments {
+				out = append(out, map[string]any{"id": i + 1, "body": c})
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case http.MethodPost:
+			comments = append(comments, in.Body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case http.MethodPatch:
+			comments[0] = in.Body
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "ns1", Annotations: map[string]string{zapv1alpha1.AnnotationAllowedURLs: srv.URL}},
+		Data:       map[string][]byte{pullRequestTokenKey: []byte("ghp_t0ken")},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(secret).Build()
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	// runScan completes a scan of the preview environment with the sample report.
+	runScan := func(name string) *metav1.Condition {
+		t.Helper()
+		creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+		jobName := scanJobNameWithTimestamp(name, creationTime.Time)
+		objs := []client.Object{
+			&zapv1alpha1.ZapScan{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", CreationTimestamp: creationTime, Annotations: map[string]string{
+					zapv1alpha1.AnnotationPullRequestAPIURL:      srv.URL,
+					zapv1alpha1.AnnotationPullRequestRepository:  "acme/shop",
+					zapv1alpha1.AnnotationPullRequestNumber:      "42",
+					zapv1alpha1.AnnotationPullRequestTokenSecret: "github",
+				}},
+				Spec:   zapv1alpha1.ZapScanSpec{Target: "https://pr-42.preview.example.com"},
+				Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+			},
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+				},
+			},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name + "-pod", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}},
+		}
+		for _, o := range objs {
+			if err := c.Create(ctx, o); err != nil {
+				t.Fatalf("create %s: %v", o.GetName(), err)
+			}
+		}
+		r := &ScanReconciler{
+			Client: c,
+			Scheme: s,
+			logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+				return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+			}),
+		}
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "ns1"}}); err != nil {
+			t.Fatalf("reconcile %s: %v", name, err)
+		}
+		var updated zapv1alpha1.ZapScan
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: "ns1"}, &updated); err != nil {
+			t.Fatalf("get scan: %v", err)
+		}
+		return meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionPullRequestCommented)
+	}
+
+	cond := runScan("s1")
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != "created the summary comment on acme/shop#42" {
+		t.Fatalf("unexpected condition %+v", cond)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("expected one comment, got %q", comments)
+	}
+	for _, want := range []string{
+		"ZAP scan Succeeded",
+		"ZapScan `ns1/s1`",
+		"| Medium | 1 |",
+		"| Content Security Policy (CSP) Header Not Set | medium | 5 |",
+	} {
+		if !strings.Contains(comments[0], want) {
+			t.Errorf("expected the comment to contain %q, got\n%s", want, comments[0])
+		}
+	}
+	if requests[0] != "GET /repos/acme/shop/issues/42/comments" {
+		t.Errorf("unexpected requests %v", requests)
+	}
+
+	// A scan of the same preview environment after a new push updates the comment.
+	cond = runScan("s2")
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != "updated the summary comment on acme/shop#42" {
+		t.Fatalf("unexpected condition %+v", cond)
+	}
+	if len(comments) != 1 || !strings.Contains(comments[0], "ZapScan `ns1/s2`") {
+		t.Errorf("expected the comment to be updated, got %q", comments)
+	}
+}
+
+func TestScanReconciler_PullRequestCommentFailure(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "ns1", Annotations: map[string]string{zapv1alpha1.AnnotationAllowedURLs: "https://github.example.com"}},
+		Data:       map[string][]byte{pullRequestTokenKey: []byte("ghp_t0ken")},
+	}
+	r := &ScanReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(secret).Build(), Scheme: s}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	for msg, annotations := range map[string]map[string]string{
+		"must be a pull request number": {
+			zapv1alpha1.AnnotationPullRequestRepository: "acme/shop",
+		},
+		"is required": {
+			zapv1alpha1.AnnotationPullRequestRepository: "acme/shop",
+			zapv1alpha1.AnnotationPullRequestNumber:     "42",
+		},
+		"get pull request token": {
+			zapv1alpha1.AnnotationPullRequestRepository:  "acme/shop",
+			zapv1alpha1.AnnotationPullRequestNumber:      "42",
+			zapv1alpha1.AnnotationPullRequestTokenSecret: "missing",
+		},
+		"doesn't allow sending its credentials to https://attacker.example.com": {
+			zapv1alpha1.AnnotationPullRequestAPIURL:      "https://attacker.example.com",
+			zapv1alpha1.AnnotationPullRequestRepository:  "acme/shop",
+			zapv1alpha1.AnnotationPullRequestNumber:      "42",
+			zapv1alpha1.AnnotationPullRequestTokenSecret: "github",
+		},
+		"doesn't allow sending its credentials to https://api.github.com": {
+			zapv1alpha1.AnnotationPullRequestRepository:  "acme/shop",
+			zapv1alpha1.AnnotationPullRequestNumber:      "42",
+			zapv1alpha1.AnnotationPullRequestTokenSecret: "github",
+		},
+	} {
+		scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", Annotations: annotations}}
+		r.commentOnPullRequest(ctx, scan)
+		cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionPullRequestCommented)
+		if cond == nil || cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, msg) {
+			t.Errorf("expected a failed condition mentioning %q, got %+v", msg, cond)
+		}
+	}
+
+	// Scans without the annotations aren't commented on.
+	scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"}}
+	r.commentOnPullRequest(ctx, scan)
+	if len(scan.Status.Conditions) != 0 {
+		t.Errorf("expected no condition, got %+v", scan.Status.Conditions)
+	}
+}
+
+func TestPullRequestComment(t *testing.T) {
+	scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"}}
+	scan.Spec.Target = "https://example.com"
+	scan.Status.Phase = "Failed"
+	scan.Status.LastError = "report too large\nsee logs"
+	got := pullRequestComment(scan)
+	if !strings.Contains(got, ":x: ZAP scan Failed") || !strings.Contains(got, "couldn't be parsed") || !strings.Contains(got, "> report too large see logs") {
+		t.Errorf("unexpected comment\n%s", got)
+	}
+
+	scan.Status.Phase = "Succeeded"
+	scan.Status.AlertsByRisk = &zapv1alpha1.RiskCounts{High: 1}
+	scan.Status.TopAlerts = []zapv1alpha1.AlertSummary{{Name: "A | B", Risk: "high", Count: 3}}
+	scan.Status.Conditions = []metav1.Condition{{Type: zapv1alpha1.ConditionGatePassed, Status: metav1.ConditionFalse, Message: "found 1 high alerts (max 0)"}}
+	got = pullRequestComment(scan)
+	if !strings.Contains(got, ":x: ZAP scan Succeeded") || !strings.Contains(got, "Quality gate **failed**") || !strings.Contains(got, `| A \| B | high | 3 |`) {
+		t.Errorf("unexpected comment\n%s", got)
+	}
+
+	other := scan.DeepCopy()
+	other.Spec.Target = "https://EXAMPLE.com:443/"
+	if pullRequestCommentMarker(scan) != pullRequestCommentMarker(other) {
+		t.Errorf("expected scans of the same target to share a comment")
+	}
+	other.Namespace = "ns2"
+	if pullRequestCommentMarker(scan) == pullRequestCommentMarker(other) {
+		t.Errorf("expected scans in other namespaces not to share a comment")
+	}
+}
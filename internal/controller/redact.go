@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// redactionConfigMap is the ConfigMap holding a namespace's own redaction patterns.
+	redactionConfigMap = "zap-redaction"
+
+	// redactionPatternsKey holds one regular expression per line. Blank lines
+	// and lines starting with # are ignored.
+	redactionPatternsKey = "patterns"
+
+	// redactedValue replaces every redacted value.
+	redactedValue = "[REDACTED]"
+
+	redactScriptEnv = "ZAP_OPERATOR_REDACT_SCRIPT"
+)
+
+// redactionRule redacts what re matches, keeping its first group if keep is set.
+// sed is the same rule as a sed -E substitution for the reporter sidecar, which
+// sees the JSON-encoded report rather than decoded strings.
+type redactionRule struct {
+	re   *regexp.Regexp
+	keep bool
+	sed  string
+}
+
+// builtinRedactions cover credentials ZAP commonly reports as evidence.
+var builtinRedactions = []redactionRule{
+	{
+		re:   regexp.MustCompile(`(?i)((?:proxy-)?authorization:[ \t]*)[^\r\n]+`),
+		keep: true,
+		sed:  `s#((Proxy-)?Authorization:[ ]*)[^"\\<]+#\1` + redactedValue + `#gI`,
+	},
+	{
+		re:   regexp.MustCompile(`(?i)(bearer[ \t]+)[A-Za-z0-9._~+/=-]+`),
+		keep: true,
+		sed:  `s#(Bearer[ ]+)[A-Za-z0-9._~+/=-]+#\1` + redactedValue + `#gI`,
+	},
+	{
+		re:   regexp.MustCompile(`(?i)((?:set-)?cookie:[ \t]*)[^\r\n]+`),
+		keep: true,
+		sed:  `s#((Set-)?Cookie:[ ]*)[^"\\<]+#\1` + redactedValue + `#gI`,
+	},
+	{
+		re:  regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		sed: `s#eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*#` + redactedValue + `#g`,
+	},
+}
+
+// redactor removes sensitive values from report fields. A nil redactor only
+// applies the built-in rules.
+type redactor struct {
+	rules []redactionRule
+}
+
+// newRedactor returns a redactor applying the built-in rules and patterns.
+// Patterns must be valid in both Go and POSIX extended regular expressions,
+// as the reporter sidecar applies them with sed, so patterns using Go-only
+// syntax are rejected.
+func newRedactor(patterns []string) (*redactor, error) {
+	rd := &redactor{rules: append([]redactionRule{}, builtinRedactions...)}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err == nil {
+			err = checkERE(p)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		sed := "s#" + strings.ReplaceAll(p, "#", `\#`) + "#" + redactedValue + "#g"
+		rd.rules = append(rd.rules, redactionRule{re: re, sed: sed})
+	}
+	return rd, nil
+}
+
+// checkERE returns an error if p uses syntax Go's regexp accepts but sed -E
+// doesn't, or reads differently: escapes such as \d or \b, backslashes in
+// bracket expressions, (? groups and flags, and lazy quantifiers.
+func checkERE(p string) error {
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '\\':
+			if i+1 == len(p) {
+				return fmt.Errorf("trailing backslash")
+			}
+			i++
+			if e := p[i]; e >= '0' && e <= '9' || e >= 'A' && e <= 'Z' || e >= 'a' && e <= 'z' {
+				return fmt.Errorf("escape \\%c isn't supported by sed -E", e)
+			}
+		case '[':
+			j := i + 1
+			if j < len(p) && p[j] == '^' {
+				j++
+			}
+			if j < len(p) && p[j] == ']' {
+				j++
+			}
+			for ; j < len(p) && p[j] != ']'; j++ {
+				if p[j] == '\\' {
+					return fmt.Errorf("backslashes in bracket expressions aren't supported by sed -E")
+				}
+				if p[j] == '[' && j+1 < len(p) && p[j+1] == ':' {
+					if k := strings.Index(p[j+2:], ":]"); k >= 0 {
+						j += k + 3
+					}
+				}
+			}
+			i = j
+		case '(':
+			if i+1 < len(p) && p[i+1] == '?' {
+				return fmt.Errorf("(? groups and flags aren't supported by sed -E")
+			}
+		case '*', '+', '?', '}':
+			if i+1 < len(p) && p[i+1] == '?' {
+				return fmt.Errorf("lazy quantifiers aren't supported by sed -E")
+			}
+		}
+	}
+	return nil
+}
+
+// redactorFor returns the redactor for scans in namespace, including the
+// patterns of its zap-redaction ConfigMap if there is one.
+func (r *ScanReconciler) redactorFor(ctx context.Context, namespace string) (*redactor, error) {
+	var cm corev1.ConfigMap
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: redactionConfigMap}, &cm)
+	if errors.IsNotFound(err) {
+		return newRedactor(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(cm.Data[redactionPatternsKey], "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	rd, err := newRedactor(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("ConfigMap %s/%s: %w", namespace, redactionConfigMap, err)
+	}
+	return rd, nil
+}
+
+// redact returns s with every sensitive value replaced and the number of replaced values.
+func (rd *redactor) redact(s string) (string, int) {
+	rules := builtinRedactions
+	if rd != nil {
+		rules = rd.rules
+	}
+	n := 0
+	for _, rule := range rules {
+		s = rule.re.ReplaceAllStringFunc(s, func(m string) string {
+			n++
+			if rule.keep {
+				return rule.re.FindStringSubmatch(m)[1] + redactedValue
+			}
+			return redactedValue
+		})
+	}
+	return s, n
+}
+
+// redactAlert redacts the instance fields of a that may hold request data and
+// returns the number of replaced values.
+func (rd *redactor) redactAlert(a *zapJSONAlert) int {
+	n := 0
+	for i := range a.Instances {
+		in := &a.Instances[i]
+		for _, field := range []*string{&in.URI, &in.Attack, &in.Evidence} {
+			var c int
+			*field, c = rd.redact(*field)
+			n += c
+		}
+	}
+	return n
+}
+
+// sedScript returns the sed -E script the reporter sidecar runs over the
+// report files before they leave the pod.
+func (rd *redactor) sedScript() string {
+	rules := builtinRedactions
+	if rd != nil {
+		rules = rd.rules
+	}
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		lines = append(lines, rule.sed)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestRedactor_Builtins(t *testing.T) {
+	var rd *redactor
+	cases := map[string]string{
+		"GET / HTTP/1.1\r\nAuthorization: Basic dXNlcjpwYXNz\r\nHost: example.com":  "GET / HTTP/1.1\r\nAuthorization: " + redactedValue + "\r\nHost: example.com",
+		"proxy-authorization: Negotiate abc":                                        "proxy-authorization: " + redactedValue,
+		"token was Bearer abc.def-ghi":                                              "token was Bearer " + redactedValue,
+		"Set-Cookie: JSESSIONID=1234; Path=/; HttpOnly":                             "Set-Cookie: " + redactedValue,
+		"https://example.com/cb?id_token=eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln": "https://example.com/cb?id_token=" + redactedValue,
+		"X-Frame-Options: DENY":                                                     "X-Frame-Options: DENY",
+	}
+	for in, want := range cases {
+		got, n := rd.redact(in)
+		if got != want {
+			t.Errorf("redact(%q) = %q, want %q", in, got, want)
+		}
+		if (n > 0) != (got != in) {
+			t.Errorf("redact(%q) reported %d redactions", in, n)
+		}
+	}
+}
+
+func TestNewRedactor(t *testing.T) {
+	rd, err := newRedactor([]string{`[a-z0-9.]+@example\.com`, `ref#[0-9]+`})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+	got, n := rd.redact("contact jane.doe@example.com or john@example.com, ref#42")
+	if got != "contact "+redactedValue+" or "+redactedValue+", "+redactedValue || n != 3 {
+		t.Errorf("unexpected redaction %q (%d)", got, n)
+	}
+
+	script := rd.sedScript()
+	if !strings.Contains(script, `s#[a-z0-9.]+@example\.com#`+redactedValue+`#g`) || !strings.Contains(script, `s#ref\#[0-9]+#`) {
+		t.Errorf("expected user patterns in the sed script, got %q", script)
+	}
+	if lines := strings.Split(script, "\n"); len(lines) != len(builtinRedactions)+2 {
+		t.Errorf("expected one sed command per rule, got %d", len(lines))
+	}
+
+	if _, err := newRedactor([]string{"("}); err == nil {
+		t.Errorf("expected an invalid pattern to be rejected")
+	}
+	// Valid in Go, but sed -E can't run these the same way.
+	for _, p := range []string{`token=\d+`, `(?i)secret`, `(?:a|b)c`, `key=[\w-]+`, `\bpin\b`, `a.*?b`} {
+		if _, err := newRedactor([]string{p}); err == nil {
+			t.Errorf("expected %q to be rejected", p)
+		}
+	}
+	if _, err := newRedactor([]string{`[[:alnum:]]+@[^]@]+\.com`, `a{2,3}\?`, `x\(y\)`}); err != nil {
+		t.Errorf("expected POSIX patterns to be accepted, got %v", err)
+	}
+}
+
+func TestRedactorFor(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	cm := func(ns, patterns string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: redactionConfigMap, Namespace: ns},
+			Data:       map[string]string{redactionPatternsKey: patterns},
+		}
+	}
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+			cm("team-a", "# customer emails\n[a-z0-9.]+@example\\.com\n\n"),
+			cm("team-b", "(")).Build(),
+		Scheme: s,
+	}
+
+	rd, err := r.redactorFor(ctx, "team-a")
+	if err != nil {
+		t.Fatalf("redactor for team-a: %v", err)
+	}
+	if got, _ := rd.redact("mail jane@example.com"); got != "mail "+redactedValue {
+		t.Errorf("expected the namespace's pattern to apply, got %q", got)
+	}
+
+	rd, err = r.redactorFor(ctx, "other")
+	if err != nil || len(rd.rules) != len(builtinRedactions) {
+		t.Errorf("expected only built-in rules without a ConfigMap, got %v %v", rd, err)
+	}
+
+	if _, err := r.redactorFor(ctx, "team-b"); err == nil || !strings.Contains(err.Error(), "team-b/"+redactionConfigMap) {
+		t.Errorf("expected an error naming the ConfigMap, got %v", err)
+	}
+}
+
+func TestCollectAlertsFromJobLogs_Redacts(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": "test-job"}}}
+	report := `{"site":[{"alerts":[{"pluginid":"10112","alert":"Session Management Response Identified","riskcode":"0","confidence":"2",` +
+		`"instances":[{"uri":"https://example.com/login?next=/","method":"POST","evidence":"Set-Cookie: sid=s3cr3t"},` +
+		`{"uri":"https://example.com/api","method":"GET","attack":"Authorization: Bearer abc","evidence":"jane@example.com"}]}]}]}`
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(job, pod).Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + report + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+	rd, err := newRedactor([]string{`[a-z]+@example\.com`})
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, rd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alerts.Findings) != 1 || len(alerts.Findings[0].Instances) != 2 {
+		t.Fatalf("unexpected findings %+v", alerts.Findings)
+	}
+	in := alerts.Findings[0].Instances
+	if in[0].Evidence != "Set-Cookie: "+redactedValue || in[1].Attack != "Authorization: "+redactedValue || in[1].Evidence != redactedValue {
+		t.Errorf("expected sensitive values to be redacted, got %+v", in)
+	}
+	if in[0].URL != "https://example.com/login?next=/" {
+		t.Errorf("expected the URL to be kept, got %q", in[0].URL)
+	}
+}
+
+func TestScanReconciler_FallsBackToBuiltinRedactions(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	report := `{"site":[{"alerts":[{"pluginid":"10112","alert":"Session Management Response Identified","riskcode":"0","confidence":"2",` +
+		`"instances":[{"uri":"https://example.com/login","method":"POST","evidence":"Set-Cookie: sid=s3cr3t"}]}]}]}`
+
+	// The ConfigMap was broken after the job started.
+	rec := events.NewFakeRecorder(10)
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(
+			&zapv1alpha1.ZapScan{
+				ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+				Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+				Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+			},
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+				},
+			},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: redactionConfigMap, Namespace: "ns1"},
+				Data:       map[string]string{redactionPatternsKey: "(unclosed"},
+			},
+		).Build(),
+		Scheme:   s,
+		Recorder: rec,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + report + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	key := types.NamespacedName{Name: "s1", Namespace: "ns1"}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, key, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if updated.Status.Phase != "Succeeded" || updated.Status.AlertsFound != 1 {
+		t.Errorf("expected the scan to complete, got phase %q with %d alerts", updated.Status.Phase, updated.Status.AlertsFound)
+	}
+	expectEvents(t, rec,
+		"Warning RedactionFallback Redacted the report of job "+jobName+" with the built-in rules only: ConfigMap ns1/"+redactionConfigMap,
+		"Normal ScanSucceeded",
+	)
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/objectstore"
+)
+
+const (
+	defaultObjectPrefix = "{{.Namespace}}/{{.Name}}/"
+	reportMetadataFile  = "metadata.json"
+)
+
+// reportMetadata is uploaded next to the reports to describe the scan they belong to.
+type reportMetadata struct {
+	Scan         string                  `json:"scan"`
+	Namespace    string                  `json:"namespace"`
+	Target       string                  `json:"target"`
+	JobName      string                  `json:"jobName"`
+	Phase        string                  `json:"phase"`
+	Outcome      string                  `json:"outcome,omitempty"`
+	StartedAt    *metav1.Time            `json:"startedAt,omitempty"`
+	FinishedAt   *metav1.Time            `json:"finishedAt,omitempty"`
+	AlertsFound  int64                   `json:"alertsFound"`
+	AlertsByRisk *zapv1alpha1.RiskCounts `json:"alertsByRisk,omitempty"`
+	Files        []string                `json:"files"`
+}
+
+// objectStorageFor returns the bucket a scan's reports are uploaded to and the
+// namespace of its credentials Secret. A scan's own configuration wins over the
+// operator-wide one.
+func (r *ScanReconciler) objectStorageFor(scan *zapv1alpha1.ZapScan) (*zapv1alpha1.ObjectStorage, string) {
+	if scan.Spec.ObjectStorage != nil {
+		return scan.Spec.ObjectStorage, scan.Namespace
+	}
+	if r.ObjectStorage != nil {
+		return r.ObjectStorage, r.ObjectStorageNamespace
+	}
+	return nil, ""
+}
+
+// exportReports uploads the reports of a finished scan to object storage and
+// records the object URLs in status. Failures don't fail the scan.
+func (r *ScanReconciler) exportReports(ctx context.Context, scan *zapv1alpha1.ZapScan, job *batchv1.Job, generated map[string][]byte) {
+	cfg, secretNS := r.objectStorageFor(scan)
+	if cfg == nil {
+		return
+	}
+
+	urls, err := r.uploadReports(ctx, scan, job, generated, cfg, secretNS)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to export scan reports", "bucket", cfg.Bucket)
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionReportExported,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonReportExportFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	scan.Status.ReportURLs = urls
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionReportExported,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonReportExported,
+		Message: fmt.Sprintf("uploaded %d objects to bucket %s", len(urls), cfg.Bucket),
+	})
+}
+
+// newObjectStore returns a client for cfg's bucket using the credentials Secret in secretNS.
+func (r *ScanReconciler) newObjectStore(ctx context.Context, cfg *zapv1alpha1.ObjectStorage, secretNS string) (*objectstore.Client, error) {
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Namespace: secretNS, Name: cfg.CredentialsSecret}, &secret); err != nil {
+		return nil, fmt.Errorf("get object storage credentials: %w", err)
+	}
+	return objectstore.New(objectstore.Config{
+		Endpoint:        cfg.Endpoint,
+		Region:          cfg.Region,
+		Bucket:          cfg.Bucket,
+		AccessKeyID:     string(secret.Data["accessKeyID"]),
+		SecretAccessKey: string(secret.Data["secretAccessKey"]),
+		SessionToken:    string(secret.Data["sessionToken"]),
+		PathStyle:       cfg.PathStyle,
+	})
+}
+
+func (r *ScanReconciler) uploadReports(ctx context.Context, scan *zapv1alpha1.ZapScan, job *batchv1.Job, generated map[string][]byte, cfg *zapv1alpha1.ObjectStorage, secretNS string) ([]string, error) {
+	store, err := r.newObjectStore(ctx, cfg, secretNS)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := objectKeyPrefix(cfg.Prefix, scan)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := r.reportFiles(ctx, job, generated)
+	if err != nil {
+		return nil, err
+	}
+	names := sortedFileNames(files)
+
+	metadata, err := json.MarshalIndent(newReportMetadata(scan, names), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, name := range names {
+		key := prefix + name
+		if err := store.Put(ctx, key, contentTypeFor(name), files[name]); err != nil {
+			return nil, err
+		}
+		urls = append(urls, store.ObjectURL(key))
+	}
+	// The metadata goes last so its presence means the upload is complete.
+	key := prefix + reportMetadataFile
+	if err := store.Put(ctx, key, "application/json", metadata); err != nil {
+		return nil, err
+	}
+	return append(urls, store.ObjectURL(key)), nil
+}
+
+func newReportMetadata(scan *zapv1alpha1.ZapScan, files []string) reportMetadata {
+	return reportMetadata{
+		Scan:         scan.Name,
+		Namespace:    scan.Namespace,
+		Target:       scan.Spec.Target,
+		JobName:      scan.Status.JobName,
+		Phase:        scan.Status.Phase,
+		Outcome:      scan.Status.Outcome,
+		StartedAt:    scan.Status.StartedAt,
+		FinishedAt:   scan.Status.FinishedAt,
+		AlertsFound:  scan.Status.AlertsFound,
+		AlertsByRisk: scan.Status.AlertsByRisk,
+		Files:        files,
+	}
+}
+
+// objectKeyPrefix renders the key prefix template for a scan.
+func objectKeyPrefix(tmpl string, scan *zapv1alpha1.ZapScan) (string, error) {
+	if tmpl == "" {
+		tmpl = defaultObjectPrefix
+	}
+	t, err := template.New("prefix").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid object storage prefix: %w", err)
+	}
+
+	date := time.Now()
+	if scan.Status.FinishedAt != nil {
+		date = scan.Status.FinishedAt.Time
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, map[string]string{
+		"Namespace": scan.Namespace,
+		"Name":      scan.Name,
+		"Target":    scan.Spec.Target,
+		"JobName":   scan.Status.JobName,
+		"Date":      date.UTC().Format("2006-01-02"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid object storage prefix: %w", err)
+	}
+
+	prefix := strings.TrimPrefix(buf.String(), "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix, nil
+}
+
+func contentTypeFor(name string) string {
+	switch path.Ext(name) {
+	case ".json":
+		return "application/json"
+	case ".sarif":
+		return "application/sarif+json"
+	case ".html":
+		return "text/html"
+	case ".xml":
+		return "application/xml"
+	case ".md":
+		return "text/markdown"
+	case ".gz":
+		return "application/gzip"
+	default:
+		return "application/octet-stream"
+	}
+}
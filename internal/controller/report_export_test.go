@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+func TestScanReconciler_ExportsReportsToObjectStorage(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		objects[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target: "https://example.com",
+			ObjectStorage: &zapv1alpha1.ObjectStorage{
+				Endpoint:          srv.URL,
+				Bucket:            "reports",
+				CredentialsSecret: "minio",
+				PathStyle:         true,
+			},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "minio", Namespace: "ns1"},
+		Data:       map[string][]byte{"accessKeyID": []byte("minio"), "secretAccessKey": []byte("minio123")},
+	}
+
+	store := results.NewStore()
+	jobNN := types.NamespacedName{Name: jobName, Namespace: "ns1"}
+	store.Put(jobNN, "zap.json", []byte(`{"site":[{"alerts":[{"pluginid":"1","riskcode":"3"},{"pluginid":"2","riskcode":"1"}]}]}`))
+	store.Put(jobNN, "zap.html", []byte("<html></html>"))
+
+	r := &ScanReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, secret).Build(),
+		Scheme:  s,
+		Results: store,
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	for _, p := range []string{"/reports/ns1/s1/zap.json", "/reports/ns1/s1/zap.html", "/reports/ns1/s1/zap.sarif", "/reports/ns1/s1/junit.xml", "/reports/ns1/s1/metadata.json"} {
+		if _, ok := objects[p]; !ok {
+			t.Errorf("expected object %s to be uploaded, got %v", p, objects)
+		}
+	}
+
+	var md reportMetadata
+	if err := json.Unmarshal(objects["/reports/ns1/s1/metadata.json"], &md); err != nil {
+		t.Fatalf("decode metadata: %v", err)
+	}
+	if md.Scan != "s1" || md.Target != "https://example.com" || md.Phase != "Succeeded" || md.AlertsFound != 2 {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+	if md.AlertsByRisk == nil || md.AlertsByRisk.High != 1 || md.AlertsByRisk.Low != 1 {
+		t.Errorf("unexpected alert counts %v", md.AlertsByRisk)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if len(updated.Status.ReportURLs) != 5 || updated.Status.ReportURLs[4] != srv.URL+"/reports/ns1/s1/metadata.json" {
+		t.Errorf("unexpected report URLs %v", updated.Status.ReportURLs)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, zapv1alpha1.ConditionReportExported) {
+		t.Errorf("expected ReportExported condition to be true")
+	}
+}
+
+func TestExportReports_OperatorWideMissingCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+	}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+
+	r := &ScanReconciler{
+		Client:                 fake.NewClientBuilder().WithScheme(s).Build(),
+		Scheme:                 s,
+		ObjectStorage:          &zapv1alpha1.ObjectStorage{Endpoint: "http://minio:9000", Bucket: "reports", CredentialsSecret: "minio"},
+		ObjectStorageNamespace: "zap-system",
+	}
+
+	r.exportReports(ctx, scan, job, nil)
+	cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionReportExported)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != zapv1alpha1.ReasonReportExportFailed {
+		t.Errorf("expected ExportFailed condition, got %+v", cond)
+	}
+	if len(scan.Status.ReportURLs) != 0 {
+		t.Errorf("expected no report URLs, got %v", scan.Status.ReportURLs)
+	}
+
+	// Without any configuration nothing is exported.
+	r.ObjectStorage = nil
+	scan.Status.Conditions = nil
+	r.exportReports(ctx, scan, job, nil)
+	if len(scan.Status.Conditions) != 0 {
+		t.Errorf("expected no condition without object storage, got %v", scan.Status.Conditions)
+	}
+}
+
+func TestObjectKeyPrefix(t *testing.T) {
+	finished := metav1.NewTime(time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{JobName: "zap-scan-s1", FinishedAt: &finished},
+	}
+
+	cases := map[string]string{
+		"":                               "ns1/s1/",
+		"audit/{{.Date}}/{{.JobName}}":   "audit/2026-03-04/zap-scan-s1/",
+		"/{{.Namespace}}/":               "ns1/",
+		"{{if .Target}}flat{{end}}/":     "flat/",
+		"{{.Namespace}}-{{.Name}}-flat/": "ns1-s1-flat/",
+	}
+	for tmpl, want := range cases {
+		got, err := objectKeyPrefix(tmpl, scan)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tmpl, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: expected %q, got %q", tmpl, want, got)
+		}
+	}
+
+	for _, tmpl := range []string{"{{.Namespace", "{{.Unknown}}"} {
+		if _, err := objectKeyPrefix(tmpl, scan); err == nil {
+			t.Errorf("%q: expected error", tmpl)
+		}
+	}
+}
+
+func TestContentTypeFor(t *testing.T) {
+	cases := map[string]string{
+		"zap.json": "application/json",
+		"zap.html": "text/html",
+		"zap.xml":  "application/xml",
+		"zap.md":   "text/markdown",
+		"session":  "application/octet-stream",
+	}
+	for name, want := range cases {
+		if got := contentTypeFor(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
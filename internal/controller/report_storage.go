@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+)
+
+// reportPathFor returns the directory, relative to the claim root, a scan's reports are copied to.
+func reportPathFor(scan *zapv1alpha1.ZapScan) string {
+	return scan.Namespace + "/" + scan.Name
+}
+
+// reportFiles returns the report files of a finished job together with the
+// reports the operator generated. Uploaded files are preferred; otherwise only
+// zap.json can be recovered from the reporter's logs.
+func (r *ScanReconciler) reportFiles(ctx context.Context, job *batchv1.Job, generated map[string][]byte) (map[string][]byte, error) {
+	files, err := r.rawReportFiles(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		// Generated reports alone are not worth keeping.
+		return files, nil
+	}
+	for name, data := range generated {
+		files[name] = data
+	}
+	return files, nil
+}
+
+func (r *ScanReconciler) rawReportFiles(ctx context.Context, job *batchv1.Job) (map[string][]byte, error) {
+	if r.Results != nil {
+		if files, ok := r.Results.Get(client.ObjectKeyFromObject(job)); ok {
+			// The saved ZAP session isn't a report.
+			delete(files, sessionArchiveFile)
+			if len(files) > 0 {
+				return files, nil
+			}
+		}
+	}
+
+	pods, err := r.podsForJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods.Items {
+		data, err := r.readReportFromLogs(ctx, &p)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			return map[string][]byte{"zap.json": data}, nil
+		}
+	}
+	return map[string][]byte{}, nil
+}
+
+// readReportFromLogs returns the zap.json printed by a pod's reporter, or nil if there is none.
+func (r *ScanReconciler) readReportFromLogs(ctx context.Context, pod *corev1.Pod) ([]byte, error) {
+	lg := r.logsGetter
+	if lg == nil {
+		lg = r
+	}
+	stream, err := lg.getPodLogs(ctx, pod.Namespace, pod.Name, "reporter")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stream.Close() }()
+
+	section, found, err := newMarkerReader(stream)
+	if err != nil || !found {
+		return nil, err
+	}
+	data, err := io.ReadAll(&limitedReader{r: section, limit: r.maxReportBytes()})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(data), nil
+}
+
+// storeReports persists the reports of a finished scan according to spec.reportStorage
+// and records the outcome in status. Failures don't fail the scan.
+func (r *ScanReconciler) storeReports(ctx context.Context, scan *zapv1alpha1.ZapScan, job *batchv1.Job, generated map[string][]byte) {
+	st := scan.Spec.ReportStorage
+	if st == nil {
+		return
+	}
+
+	ref, err := r.saveReports(ctx, scan, job, st, generated)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to store scan reports")
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionReportStored,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonReportStoreFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	scan.Status.ReportRef = ref
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionReportStored,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonReportStored,
+		Message: fmt.Sprintf("stored %d report files in %s", len(ref.Files), ref.Type),
+	})
+}
+
+func (r *ScanReconciler) saveReports(ctx context.Context, scan *zapv1alpha1.ZapScan, job *batchv1.Job, st *zapv1alpha1.ReportStorage, generated map[string][]byte) (*zapv1alpha1.ReportRef, error) {
+	if st.Type == zapv1alpha1.ReportStoragePVC {
+		if st.ClaimName == "" {
+			return nil, fmt.Errorf("reportStorage.claimName is required for %s storage", st.Type)
+		}
+		// The reporter sidecar copied ZAP's files; the operator only records where.
+		// Generated reports are not written to the claim.
+		now := metav1.Now()
+		ref := &zapv1alpha1.ReportRef{Type: st.Type, ClaimName: st.ClaimName, Path: reportPathFor(scan), StoredAt: &now}
+		if r.Results != nil {
+			if files, ok := r.Results.Get(client.ObjectKeyFromObject(job)); ok {
+				delete(files, sessionArchiveFile)
+				ref.Files = sortedFileNames(files)
+			}
+		}
+		return ref, nil
+	}
+
+	files, err := r.reportFiles(ctx, job, generated)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no reports found for job %s", job.Name)
+	}
+	return reportstore.Save(ctx, r.Client, r.Scheme, scan, st.Type, files)
+}
+
+// enforceReportRetention deletes stored reports and the saved ZAP session once
+// their TTL has passed and requeues the scan until then.
+func (r *ScanReconciler) enforceReportRetention(ctx context.Context, scan *zapv1alpha1.ZapScan) (ctrl.Result, error) {
+	st := scan.Spec.ReportStorage
+	ref, session := scan.Status.ReportRef, scan.Status.SessionRef
+	if ref != nil && ref.StoredAt == nil {
+		ref = nil
+	}
+	if session != nil && session.StoredAt == nil {
+		session = nil
+	}
+	if st == nil || st.TTL == nil || (ref == nil && session == nil) {
+		return ctrl.Result{}, nil
+	}
+
+	storedAt := func() *metav1.Time {
+		if ref != nil {
+			return ref.StoredAt
+		}
+		return session.StoredAt
+	}()
+	if wait := time.Until(storedAt.Add(st.TTL.Duration)); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	// The session goes first, as a session on a claim is removed with the reports.
+	if session != nil {
+		if err := r.deleteSession(ctx, scan, session); err != nil {
+			return ctrl.Result{}, err
+		}
+		scan.Status.SessionRef = nil
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionSessionStored,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonSessionExpired,
+			Message: fmt.Sprintf("session deleted after %s", st.TTL.Duration),
+		})
+	}
+	if ref != nil {
+		if err := r.deleteStoredReports(ctx, scan, ref); err != nil {
+			return ctrl.Result{}, err
+		}
+		scan.Status.ReportRef = nil
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionReportStored,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonReportExpired,
+			Message: fmt.Sprintf("reports deleted after %s", st.TTL.Duration),
+		})
+	}
+	if err := r.Status().Update(ctx, scan); err != nil {
+		return ctrl.Result{}, err
+	}
+	if ref != nil {
+		ctrl.LoggerFrom(ctx).Info("deleted expired scan reports", "type", ref.Type)
+	}
+	if session != nil {
+		ctrl.LoggerFrom(ctx).Info("deleted expired ZAP session", "type", session.Type)
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *ScanReconciler) deleteStoredReports(ctx context.Context, scan *zapv1alpha1.ZapScan, ref *zapv1alpha1.ReportRef) error {
+	if ref.Type != zapv1alpha1.ReportStoragePVC {
+		return reportstore.Delete(ctx, r.Client, scan.Namespace, ref)
+	}
+
+	return r.cleanupClaim(ctx, scan, ref.ClaimName, ref.Path)
+}
+
+// cleanupClaim removes path from a claim. Files on a claim can only be
+// removed by a pod mounting it, so this runs a Job.
+func (r *ScanReconciler) cleanupClaim(ctx context.Context, scan *zapv1alpha1.ZapScan, claim, path string) error {
+	job := buildReportCleanupJob(scan, jobNamespaceFor(scan.Namespace, scan.Spec.JobNamespace), claim, path)
+	if err := controllerutil.SetControllerReference(scan, job, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func buildReportCleanupJob(scan *zapv1alpha1.ZapScan, namespace, claim, path string) *batchv1.Job {
+	h := sha256.Sum256([]byte(scan.Namespace + "/" + scan.Name))
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "zap-report-cleanup-" + hex.EncodeToString(h[:])[:8],
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "zap-operator",
+				"app.kubernetes.io/component": "zap-report-cleanup",
+				"spaceship.com/scan-name":     scan.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr[int32](2),
+			TTLSecondsAfterFinished: ptr[int32](3600),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Volumes: []corev1.Volume{{
+						Name: "zap-reports",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+						},
+					}},
+					Containers: []corev1.Container{{
+						Name:            "cleanup",
+						Image:           "busybox:1.36",
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"rm", "-rf", reportsMount + "/" + path},
+						VolumeMounts:    []corev1.VolumeMount{{Name: "zap-reports", MountPath: reportsMount}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func sortedFileNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,330 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+func TestScanReconciler_StoresReportsInConfigMaps(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, UID: "uid-1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:        "https://example.com",
+			ReportStorage: &zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStorageConfigMap},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+
+	store := results.NewStore()
+	store.Put(types.NamespacedName{Name: jobName, Namespace: "ns1"}, "zap.json", []byte(`{"site":[]}`))
+	store.Put(types.NamespacedName{Name: jobName, Namespace: "ns1"}, "zap.html", []byte("<html></html>"))
+
+	r := &ScanReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job).Build(),
+		Scheme:  s,
+		Results: store,
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	ref := updated.Status.ReportRef
+	if ref == nil {
+		t.Fatalf("expected status.reportRef to be set")
+	}
+	// junit.xml and zap.sarif are generated by the operator and stored with ZAP's reports.
+	if ref.Type != zapv1alpha1.ReportStorageConfigMap || len(ref.Names) != 1 || len(ref.Files) != 4 ||
+		ref.Files[0] != junitReportFile || ref.Files[3] != sarifReportFile {
+		t.Errorf("unexpected reportRef %+v", ref)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, zapv1alpha1.ConditionReportStored) {
+		t.Errorf("expected ReportStored condition to be true")
+	}
+
+	files, err := reportstore.Load(ctx, r.Client, "ns1", ref, 1<<20)
+	if err != nil {
+		t.Fatalf("load reports: %v", err)
+	}
+	if string(files["zap.html"]) != "<html></html>" {
+		t.Errorf("expected html report to be stored, got %v", files)
+	}
+}
+
+func TestScanReconciler_StoresReportFromLogs(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", UID: "uid-1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:        "https://example.com",
+			ReportStorage: &zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStorageSecret},
+		},
+	}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": "test-job"}},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(scan, job, pod).Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n{\"site\":[]}\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+
+	r.storeReports(ctx, scan, job, nil)
+	if scan.Status.ReportRef == nil {
+		t.Fatalf("expected reportRef to be set, conditions: %+v", scan.Status.Conditions)
+	}
+
+	files, err := reportstore.Load(ctx, r.Client, "ns1", scan.Status.ReportRef, 1<<20)
+	if err != nil {
+		t.Fatalf("load reports: %v", err)
+	}
+	if string(files["zap.json"]) != `{"site":[]}` {
+		t.Errorf("unexpected stored zap.json %q", files["zap.json"])
+	}
+}
+
+func TestScanReconciler_StoreReportsFailureIsRecorded(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:        "https://example.com",
+			ReportStorage: &zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStorageConfigMap},
+		},
+	}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(scan, job).Build(),
+		Scheme: s,
+	}
+
+	r.storeReports(ctx, scan, job, nil)
+	if scan.Status.ReportRef != nil {
+		t.Errorf("expected no reportRef without reports")
+	}
+	cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionReportStored)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != zapv1alpha1.ReasonReportStoreFailed {
+		t.Errorf("expected StoreFailed condition, got %+v", cond)
+	}
+
+	// PVC storage without a claim is rejected too.
+	scan.Spec.ReportStorage = &zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStoragePVC}
+	scan.Status.Conditions = nil
+	r.storeReports(ctx, scan, job, nil)
+	if meta.IsStatusConditionTrue(scan.Status.Conditions, zapv1alpha1.ConditionReportStored) {
+		t.Errorf("expected PVC storage without claim to fail")
+	}
+}
+
+func TestScanReconciler_PVCReportStorage(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:        "https://example.com",
+			ReportStorage: &zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStoragePVC, ClaimName: "zap-reports"},
+		},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan).Build(),
+		Scheme: s,
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}}
+	if _, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var job batchv1.Job
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	if err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: "ns1"}, &job); err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	var mounted bool
+	for _, v := range job.Spec.Template.Spec.Volumes {
+		if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == "zap-reports" {
+			mounted = true
+		}
+	}
+	if !mounted {
+		t.Fatalf("expected report claim to be mounted in the scan job")
+	}
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+	if err := r.Status().Update(ctx, &job); err != nil {
+		t.Fatalf("update job: %v", err)
+	}
+	r.logsGetter = podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+		return nil, nil
+	})
+	if _, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, req.NamespacedName, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	ref := updated.Status.ReportRef
+	if ref == nil || ref.ClaimName != "zap-reports" || ref.Path != "ns1/s1" {
+		t.Errorf("unexpected reportRef %+v", ref)
+	}
+}
+
+func TestEnforceReportRetention(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	storedAt := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	newScan := func(name string, storage *zapv1alpha1.ReportStorage, ref *zapv1alpha1.ReportRef) *zapv1alpha1.ZapScan {
+		return &zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1", UID: types.UID("uid-" + name)},
+			Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com", ReportStorage: storage},
+			Status:     zapv1alpha1.ZapScanStatus{Phase: "Succeeded", ReportRef: ref},
+		}
+	}
+
+	expired := newScan("expired",
+		&zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStorageConfigMap, TTL: &metav1.Duration{Duration: time.Hour}},
+		&zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStorageConfigMap, Names: []string{"expired-report-0"}, StoredAt: &storedAt})
+	fresh := newScan("fresh",
+		&zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStorageConfigMap, TTL: &metav1.Duration{Duration: 24 * time.Hour}},
+		&zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStorageConfigMap, Names: []string{"fresh-report-0"}, StoredAt: &storedAt})
+	onClaim := newScan("on-claim",
+		&zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStoragePVC, ClaimName: "zap-reports", TTL: &metav1.Duration{Duration: time.Hour}},
+		&zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStoragePVC, ClaimName: "zap-reports", Path: "ns1/on-claim", StoredAt: &storedAt})
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "expired-report-0", Namespace: "ns1"}}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(expired, fresh, onClaim, cm).Build(),
+		Scheme: s,
+	}
+	lctx := ctrl.LoggerInto(ctx, ctrl.Log.WithName("test"))
+
+	// Fresh reports are kept until their TTL passes.
+	res, err := r.Reconcile(lctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(fresh)})
+	if err != nil {
+		t.Fatalf("reconcile fresh: %v", err)
+	}
+	if res.RequeueAfter <= 0 || res.RequeueAfter > 22*time.Hour {
+		t.Errorf("expected requeue at TTL expiry, got %v", res.RequeueAfter)
+	}
+
+	// Expired ConfigMaps are deleted and the ref cleared.
+	if _, err := r.Reconcile(lctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(expired)}); err != nil {
+		t.Fatalf("reconcile expired: %v", err)
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected expired report ConfigMap to be deleted, got %v", err)
+	}
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, client.ObjectKeyFromObject(expired), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if updated.Status.ReportRef != nil {
+		t.Errorf("expected reportRef to be cleared")
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionReportStored)
+	if cond == nil || cond.Reason != zapv1alpha1.ReasonReportExpired {
+		t.Errorf("expected Expired condition, got %+v", cond)
+	}
+
+	// Expired reports on a claim are removed by a cleanup Job.
+	if _, err := r.Reconcile(lctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(onClaim)}); err != nil {
+		t.Fatalf("reconcile on-claim: %v", err)
+	}
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace("ns1")); err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Fatalf("expected a cleanup job, got %d jobs", len(jobs.Items))
+	}
+	cmd := jobs.Items[0].Spec.Template.Spec.Containers[0].Command
+	if len(cmd) != 3 || cmd[2] != reportsMount+"/ns1/on-claim" {
+		t.Errorf("unexpected cleanup command %v", cmd)
+	}
+}
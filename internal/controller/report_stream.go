@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	reportBeginMarker = "zap-operator: begin zap.json"
+	reportEndMarker   = "zap-operator: end zap.json"
+
+	// defaultMaxReportBytes caps a single zap.json when ScanReconciler.MaxReportBytes is unset.
+	defaultMaxReportBytes int64 = 64 << 20
+)
+
+// reportTooLargeError is returned when a report exceeds the configured size cap.
+type reportTooLargeError struct {
+	limit int64
+}
+
+func (e *reportTooLargeError) Error() string {
+	return fmt.Sprintf("zap.json report exceeds the %d byte limit", e.limit)
+}
+
+// errReportNotFound is returned when a finished scan left no report to parse.
+// It is distinct from an empty report, which means ZAP found nothing.
+var errReportNotFound = errors.New("the scan left no zap.json report")
+
+func isReportTooLarge(err error) bool {
+	var tooLarge *reportTooLargeError
+	return errors.As(err, &tooLarge)
+}
+
+func isReportNotFound(err error) bool {
+	return errors.Is(err, errReportNotFound)
+}
+
+// limitedReader fails with reportTooLargeError once more than limit bytes were read.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return 0, &reportTooLargeError{limit: l.limit}
+	}
+	return n, err
+}
+
+// markerReader yields the bytes printed by the reporter sidecar between the
+// begin and end markers. Log lines are processed incrementally, so very long
+// lines never have to be held in memory at once.
+type markerReader struct {
+	br        *bufio.Reader
+	pending   []byte
+	lineStart bool
+	done      bool
+}
+
+// newMarkerReader skips the log up to and including the begin marker line.
+// found is false when the log contains no begin marker.
+func newMarkerReader(r io.Reader) (mr *markerReader, found bool, err error) {
+	mr = &markerReader{br: bufio.NewReader(r), lineStart: true}
+	for {
+		chunk, lineStart, err := mr.next()
+		if lineStart && bytes.Contains(chunk, []byte(reportBeginMarker)) {
+			// Keep anything following the marker on the same line.
+			idx := bytes.Index(chunk, []byte(reportBeginMarker)) + len(reportBeginMarker)
+			mr.pending = append([]byte(nil), chunk[idx:]...)
+			return mr, true, nil
+		}
+		if err == io.EOF {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// next returns the next piece of a log line and whether it starts a new line.
+func (m *markerReader) next() ([]byte, bool, error) {
+	lineStart := m.lineStart
+	chunk, err := m.br.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		err = nil
+	}
+	m.lineStart = len(chunk) > 0 && chunk[len(chunk)-1] == '\n'
+	return chunk, lineStart, err
+}
+
+func (m *markerReader) Read(p []byte) (int, error) {
+	for len(m.pending) == 0 {
+		if m.done {
+			return 0, io.EOF
+		}
+		chunk, lineStart, err := m.next()
+		if lineStart && bytes.HasPrefix(bytes.TrimSpace(chunk), []byte(reportEndMarker)) {
+			m.done = true
+			return 0, io.EOF
+		}
+		m.pending = chunk
+		if err == io.EOF {
+			m.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, m.pending)
+	m.pending = m.pending[n:]
+	return n, nil
+}
+
+// decodeZapReport walks a zap.json document as a stream, calling fn for every
+// alert of every site. Only one alert is decoded into memory at a time.
+func decodeZapReport(r io.Reader, fn func(a *zapJSONAlert)) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := objectKey(dec)
+		if err != nil {
+			return err
+		}
+		if key != "site" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := decodeSites(dec, fn); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func decodeSites(dec *json.Decoder, fn func(a *zapJSONAlert)) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			key, err := objectKey(dec)
+			if err != nil {
+				return err
+			}
+			if key != "alerts" {
+				if err := skipValue(dec); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var a zapJSONAlert
+				if err := dec.Decode(&a); err != nil {
+					return err
+				}
+				fn(&a)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("unexpected token %v, want %v", tok, want)
+	}
+	return nil
+}
+
+func objectKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected token %v, want object key", tok)
+	}
+	return key, nil
+}
+
+// skipValue consumes the next JSON value token by token.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
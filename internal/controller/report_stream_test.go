@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestMarkerReader_ExtractsSection(t *testing.T) {
+	log := "noise\n" + reportBeginMarker + "\n{\"a\":1}\n" + reportEndMarker + "\ntrailing noise\n"
+
+	mr, found, err := newMarkerReader(strings.NewReader(log))
+	if err != nil || !found {
+		t.Fatalf("expected marker to be found, found=%v err=%v", found, err)
+	}
+	b, err := io.ReadAll(mr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(b)) != `{"a":1}` {
+		t.Errorf("unexpected section %q", b)
+	}
+}
+
+func TestMarkerReader_NoBeginMarker(t *testing.T) {
+	_, found, err := newMarkerReader(strings.NewReader("just noise\nmore noise"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found {
+		t.Errorf("expected no marker to be found")
+	}
+}
+
+func TestMarkerReader_LongLines(t *testing.T) {
+	// Lines longer than the bufio buffer must be passed through in pieces and
+	// must not be mistaken for marker lines.
+	long := strings.Repeat("x", 20000) + reportEndMarker
+	log := strings.Repeat("n", 10000) + "\n" + reportBeginMarker + "\n" + long + "\n" + reportEndMarker + "\n"
+
+	mr, found, err := newMarkerReader(strings.NewReader(log))
+	if err != nil || !found {
+		t.Fatalf("expected marker to be found, found=%v err=%v", found, err)
+	}
+	b, err := io.ReadAll(mr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(b)) != long {
+		t.Errorf("expected long line to be passed through, got %d bytes", len(b))
+	}
+}
+
+func TestDecodeZapReport_SkipsUnknownFields(t *testing.T) {
+	report := `{
+		"@version": "2.14.0",
+		"@generated": "now",
+		"insights": [{"level": "info", "nested": {"a": [1, 2, {"b": null}]}}],
+		"site": [
+			{"@name": "https://example.com", "@ssl": "true", "alerts": [
+				{"pluginid": "1", "riskcode": "3", "instances": [{"uri": "https://example.com/a"}]},
+				{"pluginid": "2", "riskcode": "1"}
+			]},
+			{"@name": "https://other.example.com", "alerts": []}
+		]
+	}`
+
+	var got []string
+	err := decodeZapReport(strings.NewReader(report), func(a *zapJSONAlert) {
+		got = append(got, a.PluginID+":"+a.RiskCode)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "1:3,2:1" {
+		t.Errorf("unexpected alerts %v", got)
+	}
+}
+
+func TestDecodeZapReport_Errors(t *testing.T) {
+	cases := map[string]string{
+		"not an object":   `[]`,
+		"truncated":       `{"site":[{"alerts":[{"pluginid":"1"`,
+		"site not array":  `{"site":{}}`,
+		"site not object": `{"site":[1]}`,
+		"alerts not list": `{"site":[{"alerts":{}}]}`,
+		"empty":           ``,
+	}
+	for name, in := range cases {
+		if err := decodeZapReport(strings.NewReader(in), func(*zapJSONAlert) {}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLimitedReader_ReportTooLarge(t *testing.T) {
+	rd := &limitedReader{r: strings.NewReader(`{"site":[]}`), limit: 4}
+	err := decodeZapReport(rd, func(*zapJSONAlert) {})
+	if !isReportTooLarge(err) {
+		t.Fatalf("expected reportTooLargeError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "4 byte limit") {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestCollectAlertsFromJobLogs_PartialReportNotCounted(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns1", Labels: map[string]string{"job-name": "test-job"}},
+	}
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(job, pod).Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			// Log rotated away the end of the report.
+			return []byte(reportBeginMarker + "\n{\"site\":[{\"alerts\":[{\"pluginid\":\"1\",\"riskcode\":\"3\"},"), nil
+		}),
+	}
+
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
+	if err == nil || alerts != nil {
+		t.Errorf("expected truncated report not to be counted, got %v, %+v", err, alerts)
+	}
+}
+
+func TestScanReconciler_ReportTooLargeCondition(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}},
+	}
+
+	big := reportBeginMarker + "\n{\"site\":[{\"alerts\":[" + strings.Repeat(`{"pluginid":"1","riskcode":"3"},`, 100) + `{"pluginid":"1","riskcode":"3"}]}]}` + "\n"
+
+	r := &ScanReconciler{
+		Client:         fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod).Build(),
+		Scheme:         s,
+		MaxReportBytes: 256,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(big), nil
+		}),
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionReportParsed)
+	if cond == nil {
+		t.Fatalf("expected %s condition", zapv1alpha1.ConditionReportParsed)
+	}
+	if cond.Status != metav1.ConditionFalse || cond.Reason != zapv1alpha1.ReasonReportTooLarge {
+		t.Errorf("expected ReportTooLarge condition, got %s/%s", cond.Status, cond.Reason)
+	}
+	if updated.Status.AlertsFound != 0 {
+		t.Errorf("expected no alerts to be recorded, got %d", updated.Status.AlertsFound)
+	}
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+const (
+	scanDoneMarker = "/zap/wrk/.done"
+	reportsMount   = "/zap/reports"
+
+	resultsURLEnv   = "ZAP_OPERATOR_RESULTS_URL"
+	resultsTokenEnv = "ZAP_OPERATOR_RESULTS_TOKEN"
+)
+
+// reporterOptions configures what the reporter sidecar does once the scan finished.
+type reporterOptions struct {
+	// uploadURL and tokenSecret enable uploading report files to the results receiver.
+	uploadURL   string
+	tokenSecret string
+
+	// reportClaim and reportPath enable copying report files to a PersistentVolumeClaim.
+	reportClaim string
+	reportPath  string
+
+	// redactScript is a sed -E script applied to every report file before it
+	// is copied, uploaded or printed.
+	redactScript string
+
+	// copySession copies the ZAP session directory to reportClaim next to the reports.
+	copySession bool
+	// uploadSession uploads a gzipped tar of the ZAP session directory to the results receiver.
+	uploadSession bool
+}
+
+// reporterScript returns the shell script run by the reporter sidecar.
+// It waits for the scan to finish, redacts the reports, optionally copies and
+// uploads every report file and the ZAP session, and always prints zap.json between markers so the operator can fall
+// back to reading pod logs.
+func reporterScript(opts reporterOptions) string {
+	script := "set -eu; echo 'zap-operator: waiting for scan to finish'; while [ ! -f " + scanDoneMarker + " ]; do sleep 2; done; "
+	if opts.redactScript != "" {
+		// Reports that can't be redacted are discarded rather than leaked.
+		script += "printf '%s\\n' \"$" + redactScriptEnv + "\" > /zap/wrk/.redact.sed; " +
+			"for f in /zap/wrk/zap.*; do [ -f \"$f\" ] || continue; " +
+			"sed -E -i -f /zap/wrk/.redact.sed \"$f\" || { echo \"zap-operator: failed to redact $(basename \"$f\"), discarding it\"; rm -f \"$f\"; }; done; " +
+			"echo \"zap-operator: redacted $(grep -F -o '" + redactedValue + "' /zap/wrk/zap.json 2>/dev/null | wc -l) values in zap.json\"; "
+	}
+	if opts.reportClaim != "" {
+		dir := reportsMount + "/" + opts.reportPath
+		script += "mkdir -p " + dir + "; for f in /zap/wrk/zap.*; do [ -f \"$f\" ] && cp \"$f\" " + dir + "/; done; " +
+			"echo 'zap-operator: stored reports in " + dir + "'; "
+		if opts.copySession {
+			script += "if [ -d " + sessionDir + " ]; then mkdir -p " + dir + "/session; " +
+				"cp -r " + sessionDir + "/. " + dir + "/session/ && echo 'zap-operator: stored session in " + dir + "/session' || echo 'zap-operator: failed to store session'; fi; "
+		}
+	}
+	if opts.uploadURL != "" {
+		script += "for f in /zap/wrk/zap.*; do [ -f \"$f\" ] || continue; n=$(basename \"$f\"); " +
+			"if wget -q -T 30 -O /dev/null --header \"Authorization: Bearer $" + resultsTokenEnv + "\" --post-file \"$f\" \"$" + resultsURLEnv + "/$n\"; " +
+			"then echo \"zap-operator: uploaded $n\"; else echo \"zap-operator: failed to upload $n\"; fi; done; "
+		if opts.uploadSession {
+			archive := "/zap/wrk/" + sessionArchiveFile
+			script += "if [ -d " + sessionDir + " ] && tar -czf " + archive + " -C /zap/wrk session; then " +
+				"if wget -q -T 300 -O /dev/null --header \"Authorization: Bearer $" + resultsTokenEnv + "\" --post-file " + archive + " \"$" + resultsURLEnv + "/" + sessionArchiveFile + "\"; " +
+				"then echo 'zap-operator: uploaded session'; else echo 'zap-operator: failed to upload session'; fi; fi; "
+		}
+	}
+	script += "if [ -f /zap/wrk/zap.json ]; then echo '" + reportBeginMarker + "'; cat /zap/wrk/zap.json; echo; echo '" + reportEndMarker + "'; fi;"
+	return script
+}
+
+// configureReporter applies opts to the reporter sidecar of a scan Job.
+func configureReporter(job *batchv1.Job, opts reporterOptions) {
+	pod := &job.Spec.Template.Spec
+	for i := range pod.Containers {
+		c := &pod.Containers[i]
+		if c.Name != "reporter" {
+			continue
+		}
+		c.Args = []string{reporterScript(opts)}
+
+		if opts.redactScript != "" {
+			c.Env = append(c.Env, corev1.EnvVar{Name: redactScriptEnv, Value: opts.redactScript})
+		}
+
+		if opts.uploadURL != "" {
+			c.Env = append(c.Env,
+				corev1.EnvVar{Name: resultsURLEnv, Value: opts.uploadURL},
+				corev1.EnvVar{
+					Name: resultsTokenEnv,
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: opts.tokenSecret},
+							Key:                  results.TokenKey,
+						},
+					},
+				},
+			)
+		}
+
+		if opts.reportClaim != "" {
+			c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: "zap-reports", MountPath: reportsMount})
+		}
+	}
+
+	if opts.reportClaim != "" {
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: "zap-reports",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: opts.reportClaim},
+			},
+		})
+	}
+}
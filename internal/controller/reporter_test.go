@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReporterScript(t *testing.T) {
+	plain := reporterScript(reporterOptions{})
+	if strings.Contains(plain, "wget") || strings.Contains(plain, reportsMount) {
+		t.Errorf("expected no upload or copy by default, got %q", plain)
+	}
+	if !strings.Contains(plain, reportBeginMarker) || !strings.Contains(plain, reportEndMarker) {
+		t.Errorf("expected log markers in reporter script, got %q", plain)
+	}
+	if !strings.Contains(plain, scanDoneMarker) {
+		t.Errorf("expected reporter to wait for the scan to finish, got %q", plain)
+	}
+
+	upload := reporterScript(reporterOptions{uploadURL: "http://receiver", tokenSecret: "t"})
+	if !strings.Contains(upload, "--post-file") || !strings.Contains(upload, "$"+resultsURLEnv) {
+		t.Errorf("expected upload in reporter script, got %q", upload)
+	}
+	if !strings.Contains(upload, reportBeginMarker) {
+		t.Errorf("expected log fallback in upload script, got %q", upload)
+	}
+
+	copyScript := reporterScript(reporterOptions{reportClaim: "reports", reportPath: "ns1/s1"})
+	if !strings.Contains(copyScript, "mkdir -p "+reportsMount+"/ns1/s1") {
+		t.Errorf("expected reports to be copied to the claim, got %q", copyScript)
+	}
+	if strings.Contains(copyScript, sessionDir) || strings.Contains(upload, sessionDir) {
+		t.Errorf("expected the session to be left alone by default")
+	}
+
+	redact := reporterScript(reporterOptions{redactScript: "s#x#y#g"})
+	if !strings.Contains(redact, "sed -E -i -f /zap/wrk/.redact.sed") || strings.Index(redact, ".redact.sed") > strings.Index(redact, reportBeginMarker) {
+		t.Errorf("expected reports to be redacted before they are printed, got %q", redact)
+	}
+	if strings.Contains(plain, "sed") {
+		t.Errorf("expected no redaction without a script, got %q", plain)
+	}
+
+	sessionCopy := reporterScript(reporterOptions{reportClaim: "reports", reportPath: "ns1/s1", copySession: true})
+	if !strings.Contains(sessionCopy, "cp -r "+sessionDir+"/. "+reportsMount+"/ns1/s1/session/") {
+		t.Errorf("expected the session to be copied to the claim, got %q", sessionCopy)
+	}
+	sessionUpload := reporterScript(reporterOptions{uploadURL: "http://receiver", tokenSecret: "t", uploadSession: true})
+	if !strings.Contains(sessionUpload, "tar -czf /zap/wrk/"+sessionArchiveFile) || !strings.Contains(sessionUpload, "/"+sessionArchiveFile+"\"") {
+		t.Errorf("expected the session archive to be uploaded, got %q", sessionUpload)
+	}
+}
+
+func TestConfigureReporter(t *testing.T) {
+	job := buildZapFullScanJob("test-job", "test-ns", "my-scan", "https://example.com", nil, nil, nil, nil)
+	configureReporter(job, reporterOptions{
+		uploadURL:   "http://receiver/reports/test-ns/test-job",
+		tokenSecret: "test-job-results",
+		reportClaim: "reports",
+		reportPath:  "ns1/my-scan",
+	})
+
+	pod := job.Spec.Template.Spec
+	var claimFound bool
+	for _, v := range pod.Volumes {
+		if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == "reports" {
+			claimFound = true
+		}
+	}
+	if !claimFound {
+		t.Errorf("expected reports claim to be mounted")
+	}
+
+	for _, c := range pod.Containers {
+		if c.Name == "zap" {
+			if len(c.Env) != 0 {
+				t.Errorf("expected zap container to be left untouched")
+			}
+			continue
+		}
+		if len(c.Env) != 2 {
+			t.Errorf("expected upload env on reporter, got %v", c.Env)
+		}
+		var mounted bool
+		for _, m := range c.VolumeMounts {
+			if m.MountPath == reportsMount {
+				mounted = true
+			}
+		}
+		if !mounted {
+			t.Errorf("expected reports claim to be mounted in reporter")
+		}
+	}
+}
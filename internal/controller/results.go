@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+func (r *ScanReconciler) uploadsEnabled() bool {
+	return r.Results != nil && r.ResultsURL != ""
+}
+
+func (r *ScanReconciler) resultsUploadURL(job types.NamespacedName) string {
+	return strings.TrimSuffix(r.ResultsURL, "/") + "/reports/" + job.Namespace + "/" + job.Name
+}
+
+// ensureResultsToken creates the Secret holding the per-scan upload token and returns its name.
+// An existing Secret is reused so retries keep the token the Job already references.
+func (r *ScanReconciler) ensureResultsToken(ctx context.Context, scan *zapv1alpha1.ZapScan, job types.NamespacedName) (string, error) {
+	name := results.TokenSecretName(job.Name)
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: job.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "zap-operator",
+				"app.kubernetes.io/component": "zap-scan-results",
+				"spaceship.com/scan-name":     scan.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{results.TokenKey: []byte(hex.EncodeToString(buf))},
+	}
+	if err := controllerutil.SetControllerReference(scan, secret, r.Scheme); err != nil {
+		return "", err
+	}
+	if err := r.Create(ctx, secret); err != nil && !errors.IsAlreadyExists(err) {
+		return "", err
+	}
+	return name, nil
+}
+
+// releaseResults drops uploaded reports for a finished job and revokes its upload token.
+func (r *ScanReconciler) releaseResults(ctx context.Context, job types.NamespacedName) {
+	if r.Results == nil {
+		return
+	}
+	r.Results.Delete(job)
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: results.TokenSecretName(job.Name), Namespace: job.Namespace}}
+	if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to delete results token", "secret", client.ObjectKeyFromObject(secret))
+	}
+}
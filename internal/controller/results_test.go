@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+func TestScanReconciler_CreatesResultsTokenAndEnablesUpload(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+	}
+
+	r := &ScanReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan).Build(),
+		Scheme:     s,
+		Results:    results.NewStore(),
+		ResultsURL: "http://zap-operator-results.zap-system.svc:8082/",
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: results.TokenSecretName(jobName), Namespace: "ns1"}, &secret); err != nil {
+		t.Fatalf("expected results token secret: %v", err)
+	}
+	if len(secret.Data[results.TokenKey]) == 0 {
+		t.Errorf("expected token to be set")
+	}
+
+	var job batchv1.Job
+	if err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: "ns1"}, &job); err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+
+	var reporter *corev1.Container
+	for i := range job.Spec.Template.Spec.Containers {
+		if job.Spec.Template.Spec.Containers[i].Name == "reporter" {
+			reporter = &job.Spec.Template.Spec.Containers[i]
+		}
+	}
+	if reporter == nil {
+		t.Fatalf("expected reporter container")
+	}
+
+	env := map[string]corev1.EnvVar{}
+	for _, e := range reporter.Env {
+		env[e.Name] = e
+	}
+	wantURL := "http://zap-operator-results.zap-system.svc:8082/reports/ns1/" + jobName
+	if env[resultsURLEnv].Value != wantURL {
+		t.Errorf("expected upload URL %q, got %q", wantURL, env[resultsURLEnv].Value)
+	}
+	ref := env[resultsTokenEnv].ValueFrom
+	if ref == nil || ref.SecretKeyRef == nil || ref.SecretKeyRef.Name != secret.Name {
+		t.Errorf("expected token to come from secret %q, got %+v", secret.Name, ref)
+	}
+	if !strings.Contains(reporter.Args[0], "--post-file") {
+		t.Errorf("expected reporter to upload reports, got %q", reporter.Args[0])
+	}
+}
+
+func TestScanReconciler_PrefersUploadedReport(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: results.TokenSecretName(jobName), Namespace: "ns1"},
+		Data:       map[string][]byte{results.TokenKey: []byte("t")},
+	}
+
+	store := results.NewStore()
+	jobNN := types.NamespacedName{Name: jobName, Namespace: "ns1"}
+	store.Put(jobNN, "zap.json", []byte(`{"site":[{"alerts":[{"pluginid":"1","riskcode":"3"},{"pluginid":"2","riskcode":"2"}]}]}`))
+
+	r := &ScanReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod, secret).Build(),
+		Scheme:     s,
+		Results:    store,
+		ResultsURL: "http://receiver:8082",
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			t.Fatalf("logs must not be read when a report was uploaded")
+			return nil, nil
+		}),
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if updated.Status.AlertsFound != 2 {
+		t.Errorf("expected alertsFound=2, got %d", updated.Status.AlertsFound)
+	}
+
+	if _, ok := store.Get(jobNN); ok {
+		t.Errorf("expected uploaded reports to be released")
+	}
+	err = r.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "ns1"}, &corev1.Secret{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("expected results token to be deleted, got %v", err)
+	}
+}
+
+func TestCollectAlerts_FallsBackToLogsWithoutUploadedJSON(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns1", Labels: map[string]string{"job-name": "test-job"}},
+	}
+
+	store := results.NewStore()
+	store.Put(types.NamespacedName{Name: "test-job", Namespace: "ns1"}, "zap.html", []byte("<html></html>"))
+
+	called := false
+	r := &ScanReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(s).WithObjects(job, pod).Build(),
+		Scheme:  s,
+		Results: store,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			called = true
+			return []byte("zap-operator: begin zap.json\n{\"site\":[{\"alerts\":[{\"pluginid\":\"1\",\"riskcode\":\"1\"}]}]}\n"), nil
+		}),
+	}
+
+	alerts, err := r.collectAlerts(ctx, job, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Errorf("expected logs to be read when zap.json was not uploaded")
+	}
+	if alerts.Total != 1 {
+		t.Errorf("expected 1 alert, got %d", alerts.Total)
+	}
+}
+
+func TestCollectAlerts_InvalidUploadedJSON(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "test-job", Namespace: "ns1"}}
+
+	store := results.NewStore()
+	store.Put(types.NamespacedName{Name: "test-job", Namespace: "ns1"}, "zap.json", []byte("{not json"))
+
+	r := &ScanReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(s).WithObjects(job).Build(),
+		Scheme:  s,
+		Results: store,
+	}
+
+	if _, err := r.collectAlerts(ctx, job, nil); err == nil {
+		t.Fatalf("expected error for invalid uploaded report")
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -109,12 +110,10 @@ func TestCollectAlertsFromJobLogs_NoPods(t *testing.T) {
 		Scheme: s,
 	}
 
-	alerts, err := r.collectAlertsFromJobLogs(ctx, job)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if alerts.Total != 0 {
-		t.Errorf("expected 0 alerts for no pods, got %d", alerts.Total)
+	// A job without pods left no report, which is not the same as a clean one.
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
+	if !isReportNotFound(err) || alerts != nil {
+		t.Errorf("expected errReportNotFound for no pods, got %v, %+v", err, alerts)
 	}
 }
 
@@ -147,7 +146,7 @@ func TestCollectAlertsFromJobLogs_LogsGetterError(t *testing.T) {
 		}),
 	}
 
-	_, err := r.collectAlertsFromJobLogs(ctx, job)
+	_, err := r.collectAlertsFromJobLogs(ctx, job, nil)
 	if err == nil {
 		t.Error("expected error when logs getter fails")
 	}
@@ -183,13 +182,9 @@ func TestCollectAlertsFromJobLogs_NoMarker(t *testing.T) {
 		}),
 	}
 
-	alerts, err := r.collectAlertsFromJobLogs(ctx, job)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	// Should return empty alerts when marker is not found
-	if alerts.Total != 0 {
-		t.Errorf("expected 0 alerts when marker not found, got %d", alerts.Total)
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
+	if !isReportNotFound(err) || alerts != nil {
+		t.Errorf("expected errReportNotFound when marker not found, got %v, %+v", err, alerts)
 	}
 }
 
@@ -223,13 +218,9 @@ func TestCollectAlertsFromJobLogs_NoJSONAfterMarker(t *testing.T) {
 		}),
 	}
 
-	alerts, err := r.collectAlertsFromJobLogs(ctx, job)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	// Should return empty alerts when no JSON found
-	if alerts.Total != 0 {
-		t.Errorf("expected 0 alerts when no JSON found, got %d", alerts.Total)
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
+	if err == nil || isReportNotFound(err) || alerts != nil {
+		t.Errorf("expected a parse error when no JSON found, got %v, %+v", err, alerts)
 	}
 }
 
@@ -263,13 +254,9 @@ func TestCollectAlertsFromJobLogs_InvalidJSON(t *testing.T) {
 		}),
 	}
 
-	alerts, err := r.collectAlertsFromJobLogs(ctx, job)
-	if err != nil {
-		t.Fatalf("unexpected error (invalid JSON should be non-fatal): %v", err)
-	}
-	// Should return empty alerts when JSON is invalid
-	if alerts.Total != 0 {
-		t.Errorf("expected 0 alerts for invalid JSON, got %d", alerts.Total)
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
+	if err == nil || !strings.Contains(err.Error(), "test-pod") || alerts != nil {
+		t.Errorf("expected a parse error naming the pod for invalid JSON, got %v, %+v", err, alerts)
 	}
 }
 
@@ -314,7 +301,7 @@ func TestCollectAlertsFromJobLogs_MultiplePods(t *testing.T) {
 		}),
 	}
 
-	alerts, err := r.collectAlertsFromJobLogs(ctx, job)
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -361,7 +348,7 @@ func TestCollectAlertsFromJobLogs_AllRiskLevels(t *testing.T) {
 		}),
 	}
 
-	alerts, err := r.collectAlertsFromJobLogs(ctx, job)
+	alerts, err := r.collectAlertsFromJobLogs(ctx, job, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
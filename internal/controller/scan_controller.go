@@ -1,8 +1,8 @@
 package controller
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -12,21 +12,71 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/alertmanager"
+	"github.com/NCCloud/zap-operator/internal/cloudevents"
 	"github.com/NCCloud/zap-operator/internal/metrics"
+	"github.com/NCCloud/zap-operator/internal/results"
 )
 
 type ScanReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 
+	// APIReader reads ZapNotificationPolicies past the cache when checking
+	// their rate limits. Defaults to Client.
+	APIReader client.Reader
+
+	// Results holds report files uploaded by scan pods.
+	// If nil, results are only read from the reporter's pod logs.
+	Results *results.Store
+
+	// ResultsURL is the base URL scan pods use to reach the results receiver.
+	// Uploads are only enabled when both Results and ResultsURL are set.
+	ResultsURL string
+
+	// MaxReportBytes caps the size of a single zap.json the operator will parse.
+	// Defaults to 64MiB.
+	MaxReportBytes int64
+
+	// ObjectStorage is the operator-wide bucket reports are uploaded to when
+	// a scan doesn't configure its own. If nil, only per-scan exports happen.
+	ObjectStorage *zapv1alpha1.ObjectStorage
+
+	// ObjectStorageNamespace is the namespace of ObjectStorage's credentials Secret.
+	ObjectStorageNamespace string
+
+	// MinConfidence excludes alerts with a lower ZAP confidence from status
+	// counts, metrics and gates. They are still kept in ZapScanReports.
+	// If empty, every alert counts.
+	MinConfidence string
+
+	// Recorder emits events about the scan's lifecycle. If nil, no events are emitted.
+	Recorder events.EventRecorder
+
+	// CloudEvents sends CloudEvents about the scan's lifecycle. If nil, none are sent.
+	CloudEvents *cloudevents.Sender
+
+	// Alertmanager receives an alert per plugin for findings of
+	// AlertmanagerMinRisk or higher. If nil, no alerts are posted.
+	Alertmanager *alertmanager.Client
+
+	// AlertmanagerMinRisk defaults to medium.
+	AlertmanagerMinRisk string
+
+	// AlertmanagerAlertTTL is how long an alert fires unless a later scan of
+	// the same target posts or resolves it. Defaults to 48h.
+	AlertmanagerAlertTTL time.Duration
+
 	// logsGetter allows tests to inject pod log contents.
 	// If nil, the reconciler uses its default implementation.
 	logsGetter podLogsGetter
@@ -42,10 +92,11 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	jobNS := jobNamespaceFor(scan.Namespace, scan.Spec.JobNamespace)
 
-	// If scan already completed, don't do anything
+	// If scan already completed, only notifications may still need retries
+	// and stored reports may still need to expire
 	if scan.Status.Phase == "Succeeded" || scan.Status.Phase == "Failed" {
 		log.Info("scan already completed", "phase", scan.Status.Phase)
-		return ctrl.Result{}, nil
+		return r.finishCompletedScan(ctx, &scan)
 	}
 
 	// If we have a job name in status, use it; otherwise create a new one with timestamp
@@ -66,15 +117,44 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 			return ctrl.Result{}, nil
 		}
 
-		newJob := buildZapFullScanJob(jobName, jobNS, scan.Name, scan.Spec.Target, scan.Spec.OpenAPI, scan.Spec.Image, scan.Spec.Args, scan.Spec.ServiceAccountName)
+		args := scan.Spec.Args
+		if scan.Spec.KeepSession {
+			args = withSessionArgs(args)
+		}
+		newJob := buildZapFullScanJob(jobName, jobNS, scan.Name, scan.Spec.Target, scan.Spec.OpenAPI, scan.Spec.Image, args, scan.Spec.ServiceAccountName)
 		if err := controllerutil.SetControllerReference(&scan, newJob, r.Scheme); err != nil {
 			return ctrl.Result{}, err
 		}
+		red, err := r.redactorFor(ctx, scan.Namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		reporter := reporterOptions{redactScript: red.sedScript()}
+		if r.uploadsEnabled() {
+			secretName, err := r.ensureResultsToken(ctx, &scan, jobNN)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			reporter.uploadURL = r.resultsUploadURL(jobNN)
+			reporter.tokenSecret = secretName
+		}
+		if st := scan.Spec.ReportStorage; st != nil && st.Type == zapv1alpha1.ReportStoragePVC {
+			reporter.reportClaim = st.ClaimName
+			reporter.reportPath = reportPathFor(&scan)
+		}
+		if keepsSessionOnClaim(&scan) {
+			reporter.copySession = true
+		} else if cfg, _ := r.objectStorageFor(&scan); scan.Spec.KeepSession && cfg != nil {
+			reporter.uploadSession = true
+		}
+		configureReporter(newJob, reporter)
+		created := true
 		if err := r.Create(ctx, newJob); err != nil {
 			if errors.IsAlreadyExists(err) {
 				// Job was created in a previous reconcile but status update failed
 				// Just continue to update status
 				log.Info("job already exists, updating status", "job", jobName)
+				created = false
 			} else {
 				return ctrl.Result{}, err
 			}
@@ -93,6 +173,10 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		// Track scan in progress
 		metrics.IncScansInProgress(scan.Namespace)
 
+		if created {
+			recordEvent(r.Recorder, &scan, corev1.EventTypeNormal, eventJobCreated, "CreateJob", "Created job %s/%s scanning %s", jobNS, jobName, scan.Spec.Target)
+			r.sendCloudEvent(ctx, &scan, zapv1alpha1.CloudEventJobCreated)
+		}
 		log.Info("created scan job", "job", jobNN)
 		return ctrl.Result{RequeueAfter: defaultPollInterval}, nil
 	}
@@ -104,7 +188,18 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	if !complete {
 		if scan.Status.Phase == "" || scan.Status.Phase == "Running" {
 			scan.Status.Phase = "Running"
-			_ = r.Status().Update(ctx, &scan)
+			started := false
+			if job.Status.Ready != nil && *job.Status.Ready > 0 {
+				started = meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+					Type:    zapv1alpha1.ConditionJobStarted,
+					Status:  metav1.ConditionTrue,
+					Reason:  zapv1alpha1.ReasonJobStarted,
+					Message: fmt.Sprintf("job %s is running", job.Name),
+				})
+			}
+			if err := r.Status().Update(ctx, &scan); err == nil && started {
+				r.sendCloudEvent(ctx, &scan, zapv1alpha1.CloudEventJobRunning)
+			}
 		}
 		return ctrl.Result{RequeueAfter: defaultPollInterval}, nil
 	}
@@ -116,12 +211,65 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	// ZAP jobs often exit non-zero when alerts are found.
 	// We still want to parse the report and emit metrics in that case.
-	alerts, parseErr := r.collectAlertsFromJobLogs(ctx, &job)
+	red, err := r.redactorFor(ctx, scan.Namespace)
+	if err != nil {
+		// The reporter already redacted the report in the pod, so a
+		// ConfigMap broken since the job started mustn't keep the scan
+		// Running. A nil redactor applies the built-in rules.
+		log.Error(err, "failed to load the redaction rules, using the built-in ones")
+		recordEvent(r.Recorder, &scan, corev1.EventTypeWarning, eventRedactionFallback, "ParseReport", "Redacted the report of job %s with the built-in rules only: %v", job.Name, err)
+		red = nil
+	}
+	alerts, parseErr := r.collectAlerts(ctx, &job, red)
 	if parseErr != nil {
 		log.Error(parseErr, "failed to parse alerts from scan report")
 		scan.Status.LastError = parseErr.Error()
+		reason := zapv1alpha1.ReasonReportParseFailed
+		if isReportTooLarge(parseErr) {
+			reason = zapv1alpha1.ReasonReportTooLarge
+		} else if isReportNotFound(parseErr) {
+			reason = zapv1alpha1.ReasonReportNotFound
+		}
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionReportParsed,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: parseErr.Error(),
+		})
 	} else {
+		kept, err := r.applySuppressions(ctx, &scan, alerts)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		alerts = r.applyMinConfidence(kept)
 		scan.Status.AlertsFound = int64(alerts.Total)
+		scan.Status.AlertsByRisk = &alerts.ByRisk
+		scan.Status.AlertsByConfidence = &alerts.ByConfidence
+		scan.Status.TopAlerts = alerts.Top
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionReportParsed,
+			Status:  metav1.ConditionTrue,
+			Reason:  zapv1alpha1.ReasonReportParsed,
+			Message: fmt.Sprintf("parsed %d alerts", alerts.Total),
+		})
+		setSuppressionStatus(&scan, alerts)
+	}
+	setGateStatus(&scan, succeeded, alerts)
+
+	generated, err := generateReports(&scan, alerts)
+	if err != nil {
+		log.Error(err, "failed to generate reports")
+	}
+	r.storeReports(ctx, &scan, &job, generated)
+	r.storeSession(ctx, &scan, &job)
+
+	if alerts != nil {
+		if err := r.writeScanReports(ctx, &scan, alerts.reportFindings()); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.diffWithPreviousRun(ctx, &scan, alerts.reportFindings()); err != nil {
+			log.Error(err, "failed to compare findings with the previous run")
+		}
 	}
 
 	// Calculate scan duration
@@ -146,7 +294,12 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	}
 	scan.Status.Phase = finalPhase
 
-	// Update status FIRST, only emit metrics if update succeeds
+	r.exportReports(ctx, &scan, &job, generated)
+	sinks := r.scanSinks(&scan, alerts, generated)
+	markSinksPending(&scan, sinks)
+
+	// Update status FIRST, only emit metrics and call external systems if
+	// update succeeds
 	if err := r.Status().Update(ctx, &scan); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -154,18 +307,66 @@ func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	// Now that status is persisted, emit metrics (won't be double-counted on retry)
 	if alerts != nil {
 		for _, a := range alerts.ByPlugin {
-			metrics.IncAlert(scan.Namespace, scan.Spec.Target, a.Risk, a.PluginID, a.Count)
+			metrics.IncAlert(scan.Namespace, scan.Spec.Target, a.Risk, a.Confidence, a.PluginID, a.Count)
 		}
 	}
+	if diff := scan.Status.Diff; diff != nil {
+		schedule := scan.Labels[scheduledScanLabel]
+		// Drop the counts of a target the schedule no longer scans.
+		metrics.DeleteNewAlerts(scan.Namespace, schedule)
+		metrics.SetNewAlerts(scan.Namespace, schedule, scan.Spec.Target, "high", diff.NewByRisk.High)
+		metrics.SetNewAlerts(scan.Namespace, schedule, scan.Spec.Target, "medium", diff.NewByRisk.Medium)
+		metrics.SetNewAlerts(scan.Namespace, schedule, scan.Spec.Target, "low", diff.NewByRisk.Low)
+		metrics.SetNewAlerts(scan.Namespace, schedule, scan.Spec.Target, "informational", diff.NewByRisk.Informational)
+	}
 	metrics.IncScanRun(scan.Namespace, scan.Spec.Target, finalStatus)
 	metrics.ObserveScanDuration(scan.Namespace, scan.Spec.Target, durationSeconds)
 	metrics.SetLastScanTimestamp(scan.Namespace, scan.Spec.Target, finalStatus, float64(time.Now().Unix()))
 	metrics.DecScansInProgress(scan.Namespace)
 
+	if parseErr != nil {
+		recordEvent(r.Recorder, &scan, corev1.EventTypeWarning, eventReportParseFailed, "ParseReport", "Failed to parse the report of job %s: %v", job.Name, parseErr)
+	}
+	if succeeded {
+		recordEvent(r.Recorder, &scan, corev1.EventTypeNormal, eventScanSucceeded, "CompleteScan", "Job %s completed with %d alerts", job.Name, scan.Status.AlertsFound)
+	} else {
+		recordEvent(r.Recorder, &scan, corev1.EventTypeWarning, eventScanFailed, "CompleteScan", "Job %s failed: %s", job.Name, scan.Status.LastError)
+	}
+	r.sendCloudEvent(ctx, &scan, zapv1alpha1.CloudEventScanFinished)
+	if risk := scan.Status.AlertsByRisk; risk != nil && risk.High > 0 {
+		r.sendCloudEvent(ctx, &scan, zapv1alpha1.CloudEventHighRiskAlerts)
+	}
+	r.postAlerts(ctx, &scan, alerts)
+
+	// The sinks may read the uploaded reports, so they run before these are released.
+	sinkErr := r.runSinks(ctx, &scan, sinks)
+	r.releaseResults(ctx, jobNN)
+	if sinkErr != nil {
+		return ctrl.Result{}, sinkErr
+	}
+
 	// Jobs are kept for historical reference (not deleted)
 	log.Info("scan completed", "phase", finalPhase, "job", job.Name)
 
-	return ctrl.Result{}, nil
+	return r.finishCompletedScan(ctx, &scan)
+}
+
+// finishCompletedScan fails interrupted sinks, sends due notifications and
+// enforces report retention for a scan whose final status has been persisted.
+func (r *ScanReconciler) finishCompletedScan(ctx context.Context, scan *zapv1alpha1.ZapScan) (ctrl.Result, error) {
+	if err := r.interruptSinks(ctx, scan); err != nil {
+		return ctrl.Result{}, err
+	}
+	notified, err := r.sendNotifications(ctx, scan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	res, err := r.enforceReportRetention(ctx, scan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	res.RequeueAfter = earliest(res.RequeueAfter, notified.RequeueAfter)
+	return res, nil
 }
 
 const defaultPollInterval = 10 * time.Second
@@ -180,88 +381,243 @@ func (r *ScanReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 type parsedAlerts struct {
-	Total    int
-	ByPlugin []pluginAlert
+	Total        int
+	ByPlugin     []pluginAlert
+	ByRisk       zapv1alpha1.RiskCounts
+	ByConfidence zapv1alpha1.ConfidenceCounts
+	Top          []zapv1alpha1.AlertSummary
+	Findings     []zapv1alpha1.Finding
+
+	// Suppressed are the findings excluded from the counts by ZapAlertSuppressions.
+	Suppressed []zapv1alpha1.Finding
+
+	// ExpiredSuppressions name expired suppressions that would have matched a finding.
+	ExpiredSuppressions []string
+
+	// LowConfidence are the findings excluded from the counts by MinConfidence.
+	LowConfidence []zapv1alpha1.Finding
 }
 
 type pluginAlert struct {
-	PluginID string
-	Risk     string
-	Count    int
+	PluginID   string
+	Risk       string
+	Confidence string
+	Count      int
 }
 
-func (r *ScanReconciler) collectAlertsFromJobLogs(ctx context.Context, job *batchv1.Job) (*parsedAlerts, error) {
+// collectAlerts prefers the zap.json uploaded by the scan pod and only falls
+// back to parsing the reporter's logs when no upload arrived.
+// Sensitive values are redacted with red before they're kept.
+func (r *ScanReconciler) collectAlerts(ctx context.Context, job *batchv1.Job, red *redactor) (*parsedAlerts, error) {
+	if r.Results != nil {
+		if files, ok := r.Results.Get(client.ObjectKeyFromObject(job)); ok {
+			if data, ok := files["zap.json"]; ok {
+				c := newAlertCollector()
+				c.redactor = red
+				rd := &limitedReader{r: bytes.NewReader(data), limit: r.maxReportBytes()}
+				if err := decodeZapReport(rd, c.add); err != nil {
+					if isReportTooLarge(err) {
+						return nil, err
+					}
+					return nil, fmt.Errorf("parse uploaded zap.json: %w", err)
+				}
+				logRedactions(ctx, c.redacted)
+				return c.result(), nil
+			}
+		}
+	}
+	return r.collectAlertsFromJobLogs(ctx, job, red)
+}
+
+func (r *ScanReconciler) collectAlertsFromJobLogs(ctx context.Context, job *batchv1.Job, red *redactor) (*parsedAlerts, error) {
 	pods, err := r.podsForJob(ctx, job)
 	if err != nil {
 		return nil, err
 	}
 	if len(pods.Items) == 0 {
-		return &parsedAlerts{}, nil
+		return nil, errReportNotFound
 	}
 
 	// We parse zap.json from logs emitted by the "reporter" sidecar.
+	// It redacted the report already, but logs may come from an older
+	// reporter, so alert details are redacted again.
 
-	all := parsedAlerts{Total: 0}
-	acc := map[string]*pluginAlert{}
+	c := newAlertCollector()
+	c.redactor = red
 
 	for _, p := range pods.Items {
-		lg := r.logsGetter
-		if lg == nil {
-			lg = r
-		}
-		logBytes, err := lg.getPodLogs(ctx, p.Namespace, p.Name, "reporter")
-		if err != nil {
+		if err := r.collectAlertsFromPod(ctx, &p, c); err != nil {
 			return nil, err
 		}
-		// Reporter sidecar prints the full JSON report between markers.
-		text := string(logBytes)
-		start := strings.Index(text, "zap-operator: begin zap.json")
-		if start < 0 {
-			continue
-		}
-		text = text[start:]
-		idx := strings.Index(text, "{")
-		if idx < 0 {
-			continue
-		}
-		cand := text[idx:]
+	}
 
-		var report zapJSONReport
-		if err := json.NewDecoder(strings.NewReader(cand)).Decode(&report); err != nil {
-			// not fatal; keep going
-			continue
+	if c.reports == 0 {
+		// Counting nothing would look like a clean scan.
+		if c.invalid != nil {
+			return nil, c.invalid
 		}
+		return nil, errReportNotFound
+	}
+	logRedactions(ctx, c.redacted)
+	return c.result(), nil
+}
 
-		for _, site := range report.Site {
-			for _, a := range site.Alerts {
-				all.Total++
-				pid := a.PluginID
-				risk := normalizeRisk(a.RiskCode)
-				key := pid + ":" + risk
-				pa, ok := acc[key]
-				if !ok {
-					pa = &pluginAlert{PluginID: pid, Risk: risk}
-					acc[key] = pa
-				}
-				pa.Count++
-			}
+func logRedactions(ctx context.Context, n int) {
+	if n > 0 {
+		ctrl.LoggerFrom(ctx).Info("redacted sensitive values from scan report", "count", n)
+	}
+}
+
+// collectAlertsFromPod streams the reporter's logs and feeds the report found
+// between the markers into c. Only the size cap and log errors are fatal; a
+// report that doesn't decode is recorded in c.invalid.
+func (r *ScanReconciler) collectAlertsFromPod(ctx context.Context, pod *corev1.Pod, c *alertCollector) error {
+	lg := r.logsGetter
+	if lg == nil {
+		lg = r
+	}
+	stream, err := lg.getPodLogs(ctx, pod.Namespace, pod.Name, "reporter")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	// Reporter sidecar prints the full JSON report between markers.
+	section, found, err := newMarkerReader(stream)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	// Alerts are only merged once the whole report decoded, so a truncated
+	// report doesn't contribute partial counts.
+	pc := newAlertCollector()
+	pc.redactor = c.redactor
+	if err := decodeZapReport(&limitedReader{r: section, limit: r.maxReportBytes()}, pc.add); err != nil {
+		if isReportTooLarge(err) {
+			return err
 		}
+		// not fatal; another pod of the job may have a complete report
+		c.invalid = fmt.Errorf("parse zap.json from the logs of pod %s: %w", pod.Name, err)
+		return nil
+	}
+	c.merge(pc)
+	c.reports++
+	return nil
+}
+
+func (r *ScanReconciler) maxReportBytes() int64 {
+	if r.MaxReportBytes > 0 {
+		return r.MaxReportBytes
+	}
+	return defaultMaxReportBytes
+}
+
+// alertCollector aggregates alerts from one or more ZAP reports.
+type alertCollector struct {
+	total        int
+	acc          map[string]*pluginAlert
+	order        []string
+	byConfidence zapv1alpha1.ConfidenceCounts
+	findings     []zapv1alpha1.Finding
+
+	// redactor redacts alert details before they are kept; redacted counts the replaced values.
+	redactor *redactor
+	redacted int
+
+	// reports counts the reports merged in; invalid is the last one that didn't decode.
+	reports int
+	invalid error
+}
+
+func newAlertCollector() *alertCollector {
+	return &alertCollector{acc: map[string]*pluginAlert{}}
+}
+
+func (c *alertCollector) add(a *zapJSONAlert) {
+	c.redacted += c.redactor.redactAlert(a)
+	c.addFinding(newFinding(a))
+}
+
+func (c *alertCollector) addFinding(f zapv1alpha1.Finding) {
+	c.addCount(f.PluginID, f.Risk, f.Confidence, 1)
+	countConfidence(&c.byConfidence, f.Confidence, 1)
+	c.findings = append(c.findings, f)
+}
+
+func (c *alertCollector) addCount(pluginID, risk, confidence string, n int) {
+	c.total += n
+	key := pluginID + ":" + risk + ":" + confidence
+	pa, ok := c.acc[key]
+	if !ok {
+		pa = &pluginAlert{PluginID: pluginID, Risk: risk, Confidence: confidence}
+		c.acc[key] = pa
+		c.order = append(c.order, key)
+	}
+	pa.Count += n
+}
+
+func (c *alertCollector) merge(other *alertCollector) {
+	for _, k := range other.order {
+		pa := other.acc[k]
+		c.addCount(pa.PluginID, pa.Risk, pa.Confidence, pa.Count)
 	}
+	c.byConfidence.Confirmed += other.byConfidence.Confirmed
+	c.byConfidence.High += other.byConfidence.High
+	c.byConfidence.Medium += other.byConfidence.Medium
+	c.byConfidence.Low += other.byConfidence.Low
+	c.byConfidence.FalsePositive += other.byConfidence.FalsePositive
+	c.findings = append(c.findings, other.findings...)
+	c.redacted += other.redacted
+}
 
-	for _, v := range acc {
-		all.ByPlugin = append(all.ByPlugin, *v)
+// summarizeFindings recomputes the counts of already parsed findings, e.g.
+// after some were filtered out.
+func summarizeFindings(findings []zapv1alpha1.Finding) *parsedAlerts {
+	c := newAlertCollector()
+	for _, f := range findings {
+		c.addFinding(f)
 	}
+	return c.result()
+}
+
+func (c *alertCollector) result() *parsedAlerts {
+	out := &parsedAlerts{
+		Total:        c.total,
+		ByConfidence: c.byConfidence,
+		Top:          topAlerts(c.findings, topAlertsLimit),
+		Findings:     c.findings,
+	}
+	for _, k := range c.order {
+		pa := *c.acc[k]
+		out.ByPlugin = append(out.ByPlugin, pa)
+		countRisk(&out.ByRisk, pa.Risk, int64(pa.Count))
+	}
+	return out
+}
 
-	return &all, nil
+// zapJSONAlert is a single entry of site[].alerts[] in ZAP's JSON report.
+// Numeric fields are strings in ZAP's output.
+type zapJSONAlert struct {
+	PluginID   string            `json:"pluginid"`
+	AlertRef   string            `json:"alertRef"`
+	Name       string            `json:"alert"`
+	RiskCode   string            `json:"riskcode"`
+	Confidence string            `json:"confidence"`
+	CWEID      string            `json:"cweid"`
+	WASCID     string            `json:"wascid"`
+	Count      string            `json:"count"`
+	Instances  []zapJSONInstance `json:"instances"`
 }
 
-type zapJSONReport struct {
-	Site []struct {
-		Alerts []struct {
-			PluginID string `json:"pluginid"`
-			RiskCode string `json:"riskcode"`
-		} `json:"alerts"`
-	} `json:"site"`
+type zapJSONInstance struct {
+	URI      string `json:"uri"`
+	Method   string `json:"method"`
+	Param    string `json:"param"`
+	Attack   string `json:"attack"`
+	Evidence string `json:"evidence"`
 }
 
 func normalizeRisk(riskCode string) string {
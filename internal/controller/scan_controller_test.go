@@ -8,10 +8,12 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -29,9 +31,11 @@ func TestScanReconciler_CreatesJobAndSetsStatusRunning(t *testing.T) {
 		t.Fatalf("add zap scheme: %v", err)
 	}
 
+	rec := events.NewFakeRecorder(10)
 	r := &ScanReconciler{
-		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).Build(),
-		Scheme: s,
+		Client:   fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).Build(),
+		Scheme:   s,
+		Recorder: rec,
 		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
 			return nil, nil
 		}),
@@ -77,6 +81,7 @@ func TestScanReconciler_CreatesJobAndSetsStatusRunning(t *testing.T) {
 	if err := r.Get(ctx, jobNN, &job); err != nil {
 		t.Fatalf("expected job to be created: %v", err)
 	}
+	expectEvents(t, rec, "Normal JobCreated Created job ns1/"+updated.Status.JobName)
 }
 
 func TestScanReconciler_JobCompletesSucceeded(t *testing.T) {
@@ -111,9 +116,11 @@ func TestScanReconciler_JobCompletesSucceeded(t *testing.T) {
 		},
 	}
 
+	rec := events.NewFakeRecorder(10)
 	r := &ScanReconciler{
-		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job).Build(),
-		Scheme: s,
+		Client:   fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job).Build(),
+		Scheme:   s,
+		Recorder: rec,
 		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
 			return []byte(""), nil
 		}),
@@ -135,6 +142,16 @@ func TestScanReconciler_JobCompletesSucceeded(t *testing.T) {
 	if updated.Status.FinishedAt == nil {
 		t.Fatalf("expected status.finishedAt to be set")
 	}
+	// The job has no pod left, so there is no report to count alerts from.
+	if cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionReportParsed); cond == nil || cond.Reason != zapv1alpha1.ReasonReportNotFound {
+		t.Errorf("expected a NotFound report condition, got %+v", cond)
+	}
+	if updated.Status.AlertsByRisk != nil {
+		t.Errorf("expected no alert counts without a report, got %+v", updated.Status.AlertsByRisk)
+	}
+	expectEvents(t, rec,
+		"Warning ReportParseFailed Failed to parse the report of job "+jobName+": the scan left no zap.json report",
+		"Normal ScanSucceeded Job "+jobName+" completed with 0 alerts")
 }
 
 func TestScanReconciler_JobCompletesFailedSetsReason(t *testing.T) {
@@ -468,9 +485,11 @@ func TestScanReconciler_JobFailedWithParseError(t *testing.T) {
 		},
 	}
 
+	rec := events.NewFakeRecorder(10)
 	r := &ScanReconciler{
-		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod).Build(),
-		Scheme: s,
+		Client:   fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, pod).Build(),
+		Scheme:   s,
+		Recorder: rec,
 		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
 			return nil, errors.NewServiceUnavailable("logs not available")
 		}),
@@ -492,6 +511,7 @@ func TestScanReconciler_JobFailedWithParseError(t *testing.T) {
 	if updated.Status.LastError == "" {
 		t.Error("expected lastError to be set")
 	}
+	expectEvents(t, rec, "Warning ReportParseFailed Failed to parse the report of job "+jobName, "Warning ScanFailed Job "+jobName+" failed: ")
 }
 
 func TestScanReconciler_JobCompletedWithFinishedAtNil(t *testing.T) {
@@ -8,19 +8,26 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/metrics"
 )
 
 type ZapScheduledScanReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Recorder emits events about created, skipped and replaced runs.
+	// If nil, no events are emitted.
+	Recorder events.EventRecorder
 }
 
 func (r *ZapScheduledScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -28,6 +35,9 @@ func (r *ZapScheduledScanReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	var sched zapv1alpha1.ZapScheduledScan
 	if err := r.Get(ctx, req.NamespacedName, &sched); err != nil {
+		if errors.IsNotFound(err) {
+			metrics.DeleteNewAlerts(req.Namespace, req.Name)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -64,6 +74,7 @@ func (r *ZapScheduledScanReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	if policy == "Forbid" && len(active) > 0 {
 		log.Info("skipping schedule due to active scan", "active", len(active))
+		recordEvent(r.Recorder, &sched, corev1.EventTypeNormal, eventScheduleSkipped, "Schedule", "Skipped run: %d scans still active and concurrencyPolicy is Forbid", len(active))
 		// Just update lastScheduleTime so we don't spam.
 		t := metav1.NewTime(now)
 		sched.Status.LastScheduleTime = &t
@@ -73,7 +84,9 @@ func (r *ZapScheduledScanReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	if policy == "Replace" && len(active) > 0 {
 		for _, s := range active {
-			_ = r.Delete(ctx, &s)
+			if err := r.Delete(ctx, &s); err == nil {
+				recordEvent(r.Recorder, &sched, corev1.EventTypeNormal, eventScanReplaced, "Schedule", "Deleted active scan %s to start a new run; concurrencyPolicy is Replace", s.Name)
+			}
 		}
 	}
 
@@ -101,6 +114,7 @@ func (r *ZapScheduledScanReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	sched.Status.LastScheduleTime = &t
 	_ = r.Status().Update(ctx, &sched)
 
+	recordEvent(r.Recorder, &sched, corev1.EventTypeNormal, eventScanCreated, "Schedule", "Created scan %s", child.Name)
 	log.Info("created scan from schedule", "scan", types.NamespacedName{Name: child.Name, Namespace: child.Namespace})
 	return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 }
@@ -9,6 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -172,9 +173,11 @@ func TestZapScheduledScanReconciler_ForbidPolicySkipsIfActive(t *testing.T) {
 		Status: zapv1alpha1.ZapScanStatus{Phase: "Running"},
 	}
 
+	rec := events.NewFakeRecorder(10)
 	r := &ZapScheduledScanReconciler{
-		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScheduledScan{}).WithObjects(sched, activeScan).Build(),
-		Scheme: s,
+		Client:   fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScheduledScan{}).WithObjects(sched, activeScan).Build(),
+		Scheme:   s,
+		Recorder: rec,
 	}
 
 	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: sched.Name, Namespace: sched.Namespace}})
@@ -190,6 +193,7 @@ func TestZapScheduledScanReconciler_ForbidPolicySkipsIfActive(t *testing.T) {
 	if len(scans.Items) != 1 {
 		t.Fatalf("expected 1 scan (forbid policy should skip), got %d", len(scans.Items))
 	}
+	expectEvents(t, rec, "Normal ScheduleSkipped Skipped run: 1 scans still active")
 }
 
 func TestZapScheduledScanReconciler_ReplacePolicyDeletesActive(t *testing.T) {
@@ -235,9 +239,11 @@ func TestZapScheduledScanReconciler_ReplacePolicyDeletesActive(t *testing.T) {
 		Status: zapv1alpha1.ZapScanStatus{Phase: "Running"},
 	}
 
+	rec := events.NewFakeRecorder(10)
 	r := &ZapScheduledScanReconciler{
-		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScheduledScan{}).WithObjects(sched, activeScan).Build(),
-		Scheme: s,
+		Client:   fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScheduledScan{}).WithObjects(sched, activeScan).Build(),
+		Scheme:   s,
+		Recorder: rec,
 	}
 
 	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: sched.Name, Namespace: sched.Namespace}})
@@ -259,6 +265,7 @@ func TestZapScheduledScanReconciler_ReplacePolicyDeletesActive(t *testing.T) {
 	if scans.Items[0].Name == "active-scan" {
 		t.Error("expected old scan to be replaced")
 	}
+	expectEvents(t, rec, "Normal ScanReplaced Deleted active scan active-scan", "Normal ScanCreated Created scan "+scans.Items[0].Name)
 }
 
 func TestZapScheduledScanReconciler_NotFoundIgnored(t *testing.T) {
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+const (
+	// sessionDir is where ZAP writes its session when spec.keepSession is set.
+	sessionDir = "/zap/wrk/session"
+
+	// sessionArchiveFile is the gzipped tar of sessionDir the reporter uploads
+	// and the name it is exported under.
+	sessionArchiveFile = "zap-session.tar.gz"
+)
+
+// withSessionArgs makes ZAP save its session to sessionDir. ZAP's own options
+// are passed through the -z flag of the scan script, so an existing -z value
+// is extended instead of replaced.
+func withSessionArgs(args []string) []string {
+	opt := "-newsession " + sessionDir + "/zap"
+	out := append([]string{}, args...)
+	for i := 0; i < len(out)-1; i++ {
+		if out[i] == "-z" {
+			out[i+1] += " " + opt
+			return out
+		}
+	}
+	return append(out, "-z", opt)
+}
+
+// sessionPathFor returns the directory, relative to the claim root, a scan's session is copied to.
+func sessionPathFor(scan *zapv1alpha1.ZapScan) string {
+	return reportPathFor(scan) + "/session"
+}
+
+// keepsSessionOnClaim reports whether a scan's session is copied to the
+// claim of its report storage rather than uploaded to object storage.
+func keepsSessionOnClaim(scan *zapv1alpha1.ZapScan) bool {
+	st := scan.Spec.ReportStorage
+	return scan.Spec.KeepSession && st != nil && st.Type == zapv1alpha1.ReportStoragePVC
+}
+
+// storeSession saves the ZAP session of a finished scan if spec.keepSession is
+// set and records where in status. Failures don't fail the scan.
+func (r *ScanReconciler) storeSession(ctx context.Context, scan *zapv1alpha1.ZapScan, job *batchv1.Job) {
+	if !scan.Spec.KeepSession {
+		return
+	}
+
+	ref, err := r.saveSession(ctx, scan, job)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to save ZAP session")
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionSessionStored,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonSessionStoreFailed,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	scan.Status.SessionRef = ref
+	meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+		Type:    zapv1alpha1.ConditionSessionStored,
+		Status:  metav1.ConditionTrue,
+		Reason:  zapv1alpha1.ReasonSessionStored,
+		Message: fmt.Sprintf("saved ZAP session in %s", ref.Type),
+	})
+}
+
+func (r *ScanReconciler) saveSession(ctx context.Context, scan *zapv1alpha1.ZapScan, job *batchv1.Job) (*zapv1alpha1.SessionRef, error) {
+	now := metav1.Now()
+	if keepsSessionOnClaim(scan) {
+		st := scan.Spec.ReportStorage
+		if st.ClaimName == "" {
+			return nil, fmt.Errorf("reportStorage.claimName is required for %s storage", st.Type)
+		}
+		// The reporter sidecar copied the session directory; the operator only records where.
+		return &zapv1alpha1.SessionRef{Type: st.Type, ClaimName: st.ClaimName, Path: sessionPathFor(scan), StoredAt: &now}, nil
+	}
+
+	cfg, secretNS := r.objectStorageFor(scan)
+	if cfg == nil {
+		return nil, fmt.Errorf("keepSession needs reportStorage of type %s or object storage", zapv1alpha1.ReportStoragePVC)
+	}
+	if !r.uploadsEnabled() {
+		return nil, fmt.Errorf("keepSession with object storage needs the results receiver to be enabled")
+	}
+	files, _ := r.Results.Get(client.ObjectKeyFromObject(job))
+	archive, ok := files[sessionArchiveFile]
+	if !ok {
+		return nil, fmt.Errorf("job %s didn't upload a ZAP session; the results receiver rejects archives over %d bytes (--max-report-bytes)", job.Name, r.maxReportBytes())
+	}
+
+	store, err := r.newObjectStore(ctx, cfg, secretNS)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := objectKeyPrefix(cfg.Prefix, scan)
+	if err != nil {
+		return nil, err
+	}
+	key := prefix + sessionArchiveFile
+	if err := store.Put(ctx, key, contentTypeFor(sessionArchiveFile), archive); err != nil {
+		return nil, err
+	}
+	return &zapv1alpha1.SessionRef{Type: zapv1alpha1.SessionStorageObjectStorage, URL: store.ObjectURL(key), Key: key, StoredAt: &now}, nil
+}
+
+// deleteSession removes a saved session once the reports' TTL has passed.
+func (r *ScanReconciler) deleteSession(ctx context.Context, scan *zapv1alpha1.ZapScan, session *zapv1alpha1.SessionRef) error {
+	if session.Type == zapv1alpha1.ReportStoragePVC {
+		// The session directory lives below the reports, which are removed together.
+		if ref := scan.Status.ReportRef; ref != nil && ref.Type == zapv1alpha1.ReportStoragePVC && ref.ClaimName == session.ClaimName {
+			return nil
+		}
+		return r.cleanupClaim(ctx, scan, session.ClaimName, session.Path)
+	}
+
+	cfg, secretNS := r.objectStorageFor(scan)
+	if cfg == nil {
+		ctrl.LoggerFrom(ctx).Info("object storage is no longer configured, leaving the ZAP session in place", "url", session.URL)
+		return nil
+	}
+	store, err := r.newObjectStore(ctx, cfg, secretNS)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, session.Key)
+}
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/results"
+)
+
+func TestWithSessionArgs(t *testing.T) {
+	if got := withSessionArgs([]string{"-m", "5"}); !reflect.DeepEqual(got, []string{"-m", "5", "-z", "-newsession " + sessionDir + "/zap"}) {
+		t.Errorf("unexpected args %v", got)
+	}
+
+	args := []string{"-z", "-config spider.maxDepth=3", "-m", "5"}
+	got := withSessionArgs(args)
+	if !reflect.DeepEqual(got, []string{"-z", "-config spider.maxDepth=3 -newsession " + sessionDir + "/zap", "-m", "5"}) {
+		t.Errorf("expected the existing ZAP options to be extended, got %v", got)
+	}
+	if args[1] != "-config spider.maxDepth=3" {
+		t.Errorf("expected the spec's args to be left untouched, got %v", args)
+	}
+}
+
+func TestScanReconciler_KeepsSessionInObjectStorage(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, UID: "uid-1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:        "https://example.com",
+			KeepSession:   true,
+			ReportStorage: &zapv1alpha1.ReportStorage{Type: zapv1alpha1.ReportStorageConfigMap, TTL: &metav1.Duration{Duration: time.Hour}},
+			ObjectStorage: &zapv1alpha1.ObjectStorage{Endpoint: srv.URL, Bucket: "reports", CredentialsSecret: "minio", PathStyle: true},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "minio", Namespace: "ns1"},
+		Data:       map[string][]byte{"accessKeyID": []byte("minio"), "secretAccessKey": []byte("minio123")},
+	}
+
+	store := results.NewStore()
+	jobNN := types.NamespacedName{Name: jobName, Namespace: "ns1"}
+	store.Put(jobNN, "zap.json", []byte(sampleZapReport))
+	store.Put(jobNN, sessionArchiveFile, []byte("session"))
+
+	r := &ScanReconciler{
+		Client:     fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan, job, secret).Build(),
+		Scheme:     s,
+		Results:    store,
+		ResultsURL: "http://receiver",
+	}
+	lctx := ctrl.LoggerInto(ctx, ctrl.Log.WithName("test"))
+	if _, err := r.Reconcile(lctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scan)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	if string(objects["/reports/ns1/s1/"+sessionArchiveFile]) != "session" {
+		t.Fatalf("expected the session archive to be uploaded, got %v", objects)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	ref := updated.Status.SessionRef
+	if ref == nil || ref.Type != zapv1alpha1.SessionStorageObjectStorage || ref.Key != "ns1/s1/"+sessionArchiveFile || ref.URL != srv.URL+"/reports/ns1/s1/"+sessionArchiveFile {
+		t.Errorf("unexpected sessionRef %+v", ref)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, zapv1alpha1.ConditionSessionStored) {
+		t.Errorf("expected SessionStored condition to be true")
+	}
+	for _, u := range updated.Status.ReportURLs {
+		if u == ref.URL {
+			t.Errorf("expected the session not to be exported as a report")
+		}
+	}
+	if updated.Status.ReportRef == nil || len(updated.Status.ReportRef.Files) == 0 {
+		t.Fatalf("expected reports to be stored")
+	}
+	for _, f := range updated.Status.ReportRef.Files {
+		if f == sessionArchiveFile {
+			t.Errorf("expected the session not to be stored as a report, got %v", updated.Status.ReportRef.Files)
+		}
+	}
+
+	// The session expires together with the reports.
+	past := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	updated.Status.ReportRef.StoredAt = &past
+	updated.Status.SessionRef.StoredAt = &past
+	if err := r.Status().Update(ctx, &updated); err != nil {
+		t.Fatalf("update scan: %v", err)
+	}
+	if _, err := r.Reconcile(lctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scan)}); err != nil {
+		t.Fatalf("reconcile expired: %v", err)
+	}
+	if _, ok := objects["/reports/ns1/s1/"+sessionArchiveFile]; ok {
+		t.Errorf("expected the expired session to be deleted")
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if updated.Status.SessionRef != nil || updated.Status.ReportRef != nil {
+		t.Errorf("expected sessionRef and reportRef to be cleared")
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionSessionStored)
+	if cond == nil || cond.Reason != zapv1alpha1.ReasonSessionExpired {
+		t.Errorf("expected Expired condition, got %+v", cond)
+	}
+}
+
+func TestScanReconciler_KeepSessionWithoutStorage(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com", KeepSession: true},
+	}
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan).Build(),
+		Scheme: s,
+	}
+
+	r.storeSession(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), scan, &batchv1.Job{})
+	cond := meta.FindStatusCondition(scan.Status.Conditions, zapv1alpha1.ConditionSessionStored)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != zapv1alpha1.ReasonSessionStoreFailed {
+		t.Errorf("expected a StoreFailed condition, got %+v", cond)
+	}
+	if scan.Status.SessionRef != nil {
+		t.Errorf("expected no sessionRef, got %+v", scan.Status.SessionRef)
+	}
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+// sinkConditions are the conditions of the systems a finished scan's report
+// is sent to.
+var sinkConditions = []string{
+	zapv1alpha1.ConditionDefectDojoImported,
+	zapv1alpha1.ConditionIssuesSynced,
+	zapv1alpha1.ConditionPullRequestCommented,
+}
+
+// scanSink sends a finished scan's report to an external system and records
+// the outcome in its condition.
+type scanSink struct {
+	condition string
+	send      func(ctx context.Context, scan *zapv1alpha1.ZapScan)
+}
+
+// scanSinks returns the sinks configured for scan.
+func (r *ScanReconciler) scanSinks(scan *zapv1alpha1.ZapScan, alerts *parsedAlerts, generated map[string][]byte) []scanSink {
+	var sinks []scanSink
+	if scan.Spec.DefectDojo != nil {
+		sinks = append(sinks, scanSink{zapv1alpha1.ConditionDefectDojoImported, func(ctx context.Context, scan *zapv1alpha1.ZapScan) {
+			r.importToDefectDojo(ctx, scan, alerts, generated)
+		}})
+	}
+	if scan.Spec.IssueTracker != nil {
+		sinks = append(sinks, scanSink{zapv1alpha1.ConditionIssuesSynced, func(ctx context.Context, scan *zapv1alpha1.ZapScan) {
+			r.syncIssues(ctx, scan, alerts)
+		}})
+	}
+	if hasPullRequest(scan) {
+		sinks = append(sinks, scanSink{zapv1alpha1.ConditionPullRequestCommented, r.commentOnPullRequest})
+	}
+	return sinks
+}
+
+// markSinksPending records that the sinks are yet to run, so the final status
+// can be saved before any of them is called.
+func markSinksPending(scan *zapv1alpha1.ZapScan, sinks []scanSink) {
+	for _, s := range sinks {
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    s.condition,
+			Status:  metav1.ConditionUnknown,
+			Reason:  zapv1alpha1.ReasonSinkPending,
+			Message: "waiting for the scan to finish",
+		})
+	}
+}
+
+// runSinks sends the report of a scan whose final status has been saved and
+// saves the outcome. The sinks run concurrently, so a slow endpoint doesn't
+// delay the others.
+func (r *ScanReconciler) runSinks(ctx context.Context, scan *zapv1alpha1.ZapScan, sinks []scanSink) error {
+	if len(sinks) == 0 {
+		return nil
+	}
+	results := make([]*zapv1alpha1.ZapScan, len(sinks))
+	var wg sync.WaitGroup
+	for i, s := range sinks {
+		results[i] = scan.DeepCopy()
+		wg.Go(func() { s.send(ctx, results[i]) })
+	}
+	wg.Wait()
+	for i, s := range sinks {
+		if c := meta.FindStatusCondition(results[i].Status.Conditions, s.condition); c != nil {
+			meta.SetStatusCondition(&scan.Status.Conditions, *c)
+		}
+	}
+	return r.Status().Update(ctx, scan)
+}
+
+// interruptSinks fails the sinks still pending on a completed scan, whose
+// outcome was lost when the operator stopped or the status update failed.
+// They aren't run again, since a report may have been sent already.
+func (r *ScanReconciler) interruptSinks(ctx context.Context, scan *zapv1alpha1.ZapScan) error {
+	changed := false
+	for _, typ := range sinkConditions {
+		c := meta.FindStatusCondition(scan.Status.Conditions, typ)
+		if c == nil || c.Reason != zapv1alpha1.ReasonSinkPending {
+			continue
+		}
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    typ,
+			Status:  metav1.ConditionFalse,
+			Reason:  zapv1alpha1.ReasonSinkInterrupted,
+			Message: "the outcome wasn't saved; not retried to avoid sending the report twice",
+		})
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return r.Status().Update(ctx, scan)
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestScanReconciler_RunsSinksAfterSavingStatus(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	key := types.NamespacedName{Name: "s1", Namespace: "ns1"}
+
+	var c client.Client
+	var seen zapv1alpha1.ZapScan
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The scan as saved when the pull request is commented on.
+		if r.Method == http.MethodGet {
+			if err := c.Get(r.Context(), key, &seen); err != nil {
+				t.Errorf("get scan: %v", err)
+			}
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c = fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(
+		&zapv1alpha1.ZapScan{
+			ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, Annotations: map[string]string{
+				zapv1alpha1.AnnotationPullRequestAPIURL:      srv.URL,
+				zapv1alpha1.AnnotationPullRequestRepository:  "acme/shop",
+				zapv1alpha1.AnnotationPullRequestNumber:      "42",
+				zapv1alpha1.AnnotationPullRequestTokenSecret: "github",
+			}},
+			Spec:   zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+			Status: zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+			},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "ns1", Annotations: map[string]string{zapv1alpha1.AnnotationAllowedURLs: srv.URL}},
+			Data:       map[string][]byte{pullRequestTokenKey: []byte("t0ken")},
+		},
+	).Build()
+	r := &ScanReconciler{
+		Client: c,
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	pending := meta.FindStatusCondition(seen.Status.Conditions, zapv1alpha1.ConditionPullRequestCommented)
+	if seen.Status.Phase != "Succeeded" || pending == nil || pending.Reason != zapv1alpha1.ReasonSinkPending {
+		t.Errorf("expected the final status to be saved with the comment pending, got phase %q and %+v", seen.Status.Phase, pending)
+	}
+	var updated zapv1alpha1.ZapScan
+	if err := c.Get(ctx, key, &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionPullRequestCommented); cond == nil || cond.Reason != zapv1alpha1.ReasonPullRequestCommented {
+		t.Errorf("expected the outcome to be saved, got %+v", cond)
+	}
+}
+
+func TestScanReconciler_InterruptsPendingSinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	s := runtime.NewScheme()
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec: zapv1alpha1.ZapScanSpec{
+			Target:     "https://example.com",
+			DefectDojo: &zapv1alpha1.DefectDojo{URL: srv.URL, APIKeySecret: "dojo", ProductName: "shop", EngagementName: "zap"},
+		},
+		Status: zapv1alpha1.ZapScanStatus{Phase: "Succeeded", Conditions: []metav1.Condition{
+			{Type: zapv1alpha1.ConditionDefectDojoImported, Status: metav1.ConditionUnknown, Reason: zapv1alpha1.ReasonSinkPending},
+			{Type: zapv1alpha1.ConditionIssuesSynced, Status: metav1.ConditionTrue, Reason: zapv1alpha1.ReasonIssuesSynced},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).WithObjects(scan).Build()
+	r := &ScanReconciler{Client: c, Scheme: s}
+	ctx := ctrl.LoggerInto(context.Background(), ctrl.Log.WithName("test"))
+
+	// The operator stopped after saving the final status, so the import
+	// may or may not have gone through.
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scan)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var updated zapv1alpha1.ZapScan
+	if err := c.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionDefectDojoImported); cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != zapv1alpha1.ReasonSinkInterrupted {
+		t.Errorf("expected the import to be marked interrupted, got %+v", cond)
+	}
+	if cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionIssuesSynced); cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected the finished sync to be left alone, got %+v", cond)
+	}
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+// maxSuppressedAlerts caps status.suppressedAlerts; alertsSuppressed still holds the total.
+const maxSuppressedAlerts = 50
+
+// suppression is a ZapAlertSuppression with its URL pattern compiled.
+type suppression struct {
+	name    string
+	spec    zapv1alpha1.ZapAlertSuppressionSpec
+	url     *regexp.Regexp
+	expired bool
+}
+
+// matches reports whether an instance of a finding raised by pluginID is suppressed.
+func (s *suppression) matches(pluginID string, in zapv1alpha1.FindingInstance) bool {
+	if s.spec.PluginID != pluginID {
+		return false
+	}
+	if s.spec.Param != "" && s.spec.Param != in.Param {
+		return false
+	}
+	return s.url == nil || s.url.MatchString(in.URL)
+}
+
+// coversPlugin reports whether the suppression named name matches every
+// instance of its plugin.
+func coversPlugin(sups []suppression, name string) bool {
+	for _, s := range sups {
+		if s.name == name {
+			return s.spec.Param == "" && s.url == nil
+		}
+	}
+	return false
+}
+
+// suppressionsFor returns the suppressions in the scan's namespace that apply
+// to its target, including expired ones. Suppressions with an invalid URL
+// pattern are skipped; the suppression reconciler reports them.
+func (r *ScanReconciler) suppressionsFor(ctx context.Context, scan *zapv1alpha1.ZapScan, now time.Time) ([]suppression, error) {
+	var list zapv1alpha1.ZapAlertSuppressionList
+	if err := r.List(ctx, &list, client.InNamespace(scan.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var out []suppression
+	for _, item := range list.Items {
+		if item.Spec.Target != "" && item.Spec.Target != scan.Spec.Target {
+			continue
+		}
+		s := suppression{name: item.Name, spec: item.Spec, expired: !now.Before(item.Spec.Expires.Time)}
+		if item.Spec.URLPattern != "" {
+			re, err := regexp.Compile(item.Spec.URLPattern)
+			if err != nil {
+				ctrl.LoggerFrom(ctx).Info("ignoring suppression with invalid URL pattern", "suppression", item.Name, "error", err.Error())
+				continue
+			}
+			s.url = re
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out, nil
+}
+
+// suppressFindings splits findings into the instances that still count and
+// those matched by an active suppression, grouped per suppression. It also
+// returns the expired suppressions that would have matched an instance.
+func suppressFindings(findings []zapv1alpha1.Finding, sups []suppression) (kept, suppressed []zapv1alpha1.Finding, expired []string) {
+	expiredSet := map[string]bool{}
+	match := func(pluginID string, in zapv1alpha1.FindingInstance) *suppression {
+		var found *suppression
+		for i := range sups {
+			s := &sups[i]
+			if !s.matches(pluginID, in) {
+				continue
+			}
+			if s.expired {
+				expiredSet[s.name] = true
+				continue
+			}
+			if found == nil {
+				found = s
+			}
+		}
+		return found
+	}
+
+	for _, f := range findings {
+		if len(f.Instances) == 0 {
+			if s := match(f.PluginID, zapv1alpha1.FindingInstance{}); s != nil {
+				f.SuppressedBy = s.name
+				suppressed = append(suppressed, f)
+			} else {
+				kept = append(kept, f)
+			}
+			continue
+		}
+
+		var remaining []zapv1alpha1.FindingInstance
+		bySuppression := map[string][]zapv1alpha1.FindingInstance{}
+		var order []string
+		for _, in := range f.Instances {
+			s := match(f.PluginID, in)
+			if s == nil {
+				remaining = append(remaining, in)
+				continue
+			}
+			if _, ok := bySuppression[s.name]; !ok {
+				order = append(order, s.name)
+			}
+			bySuppression[s.name] = append(bySuppression[s.name], in)
+		}
+
+		count := f.Count
+		start := len(suppressed)
+		for _, name := range order {
+			sf := f
+			sf.Instances = bySuppression[name]
+			sf.Count = int32(len(sf.Instances))
+			sf.SuppressedBy = name
+			suppressed = append(suppressed, sf)
+			count -= sf.Count
+		}
+		if count > int32(len(remaining)) {
+			// The instances beyond the listed ones are unknown, so only a
+			// suppression of every instance of the plugin covers them.
+			if i := slices.IndexFunc(order, func(name string) bool { return coversPlugin(sups, name) }); i >= 0 {
+				suppressed[start+i].Count += count - int32(len(remaining))
+				count = int32(len(remaining))
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		f.Instances = remaining
+		f.Count = max(count, int32(len(remaining)))
+		kept = append(kept, f)
+	}
+
+	for name := range expiredSet {
+		expired = append(expired, name)
+	}
+	sort.Strings(expired)
+	return kept, suppressed, expired
+}
+
+// applySuppressions removes suppressed instances from alerts and recomputes
+// the counts from the findings that remain.
+func (r *ScanReconciler) applySuppressions(ctx context.Context, scan *zapv1alpha1.ZapScan, alerts *parsedAlerts) (*parsedAlerts, error) {
+	sups, err := r.suppressionsFor(ctx, scan, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("list suppressions: %w", err)
+	}
+	if len(sups) == 0 {
+		return alerts, nil
+	}
+
+	kept, suppressed, expired := suppressFindings(alerts.Findings, sups)
+	out := summarizeFindings(kept)
+	out.Suppressed = suppressed
+	out.ExpiredSuppressions = expired
+	return out, nil
+}
+
+// setSuppressionStatus records the suppressed alerts of a scan and flags
+// expired suppressions that matched.
+func setSuppressionStatus(scan *zapv1alpha1.ZapScan, alerts *parsedAlerts) {
+	scan.Status.AlertsSuppressed = 0
+	scan.Status.SuppressedAlerts = nil
+
+	byKey := map[string]*zapv1alpha1.SuppressedAlert{}
+	var all []*zapv1alpha1.SuppressedAlert
+	for _, f := range alerts.Suppressed {
+		scan.Status.AlertsSuppressed += int64(f.Count)
+		key := f.SuppressedBy + "\x00" + f.PluginID + "\x00" + f.Name + "\x00" + f.Risk
+		s, ok := byKey[key]
+		if !ok {
+			s = &zapv1alpha1.SuppressedAlert{Suppression: f.SuppressedBy, Name: f.Name, PluginID: f.PluginID, Risk: f.Risk}
+			byKey[key] = s
+			all = append(all, s)
+		}
+		s.Count += f.Count
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if ri, rj := risk.Rank(all[i].Risk), risk.Rank(all[j].Risk); ri != rj {
+			return ri > rj
+		}
+		return all[i].Count > all[j].Count
+	})
+	for i := 0; i < len(all) && i < maxSuppressedAlerts; i++ {
+		scan.Status.SuppressedAlerts = append(scan.Status.SuppressedAlerts, *all[i])
+	}
+
+	if len(alerts.ExpiredSuppressions) > 0 {
+		meta.SetStatusCondition(&scan.Status.Conditions, metav1.Condition{
+			Type:    zapv1alpha1.ConditionSuppressionExpired,
+			Status:  metav1.ConditionTrue,
+			Reason:  zapv1alpha1.ReasonSuppressionsExpired,
+			Message: "alerts matched expired suppressions: " + strings.Join(alerts.ExpiredSuppressions, ", "),
+		})
+	}
+}
+
+// reportFindings returns the findings written to ZapScanReports: the counted
+// ones, then those below the minimum confidence, then the suppressed ones.
+func (a *parsedAlerts) reportFindings() []zapv1alpha1.Finding {
+	out := make([]zapv1alpha1.Finding, 0, len(a.Findings)+len(a.LowConfidence)+len(a.Suppressed))
+	out = append(out, a.Findings...)
+	out = append(out, a.LowConfidence...)
+	return append(out, a.Suppressed...)
+}
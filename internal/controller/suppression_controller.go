@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+// ZapAlertSuppressionReconciler keeps the Active condition of suppressions
+// up to date, so expired ones are visible without running a scan.
+type ZapAlertSuppressionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *ZapAlertSuppressionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var sup zapv1alpha1.ZapAlertSuppression
+	if err := r.Get(ctx, req.NamespacedName, &sup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	now := time.Now()
+	cond := metav1.Condition{
+		Type:               zapv1alpha1.ConditionSuppressionActive,
+		Status:             metav1.ConditionTrue,
+		Reason:             zapv1alpha1.ReasonSuppressionActive,
+		Message:            fmt.Sprintf("suppressing plugin %s until %s", sup.Spec.PluginID, sup.Spec.Expires.UTC().Format(time.RFC3339)),
+		ObservedGeneration: sup.Generation,
+	}
+	var requeue time.Duration
+	if _, err := regexp.Compile(sup.Spec.URLPattern); err != nil {
+		cond.Status, cond.Reason, cond.Message = metav1.ConditionFalse, zapv1alpha1.ReasonSuppressionInvalid, err.Error()
+	} else if !now.Before(sup.Spec.Expires.Time) {
+		cond.Status, cond.Reason = metav1.ConditionFalse, zapv1alpha1.ReasonSuppressionExpired
+		cond.Message = fmt.Sprintf("expired at %s, owned by %s", sup.Spec.Expires.UTC().Format(time.RFC3339), sup.Spec.Owner)
+	} else {
+		requeue = sup.Spec.Expires.Sub(now)
+	}
+
+	if meta.SetStatusCondition(&sup.Status.Conditions, cond) {
+		if err := r.Status().Update(ctx, &sup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+func (r *ZapAlertSuppressionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&zapv1alpha1.ZapAlertSuppression{}).
+		Complete(r)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestZapAlertSuppressionReconciler(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		pattern    string
+		expires    time.Time
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		requeue    bool
+	}{
+		{"active", `^https://example\.com/static/`, time.Now().Add(time.Hour), metav1.ConditionTrue, zapv1alpha1.ReasonSuppressionActive, true},
+		{"expired", "", time.Now().Add(-time.Hour), metav1.ConditionFalse, zapv1alpha1.ReasonSuppressionExpired, false},
+		{"invalid", "(", time.Now().Add(time.Hour), metav1.ConditionFalse, zapv1alpha1.ReasonSuppressionInvalid, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sup := &zapv1alpha1.ZapAlertSuppression{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.name, Namespace: "ns1"},
+				Spec: zapv1alpha1.ZapAlertSuppressionSpec{
+					PluginID: "10038", URLPattern: tc.pattern, Reason: "CDN assets", Owner: "team-web", Expires: metav1.NewTime(tc.expires),
+				},
+			}
+			r := &ZapAlertSuppressionReconciler{
+				Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapAlertSuppression{}).WithObjects(sup).Build(),
+				Scheme: s,
+			}
+
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: tc.name, Namespace: "ns1"}})
+			if err != nil {
+				t.Fatalf("reconcile: %v", err)
+			}
+			if (res.RequeueAfter > 0) != tc.requeue {
+				t.Errorf("unexpected requeue %v", res.RequeueAfter)
+			}
+
+			var updated zapv1alpha1.ZapAlertSuppression
+			if err := r.Get(ctx, types.NamespacedName{Name: tc.name, Namespace: "ns1"}, &updated); err != nil {
+				t.Fatalf("get suppression: %v", err)
+			}
+			cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionSuppressionActive)
+			if cond == nil || cond.Status != tc.wantStatus || cond.Reason != tc.wantReason {
+				t.Errorf("unexpected condition %+v", cond)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestSuppressFindings(t *testing.T) {
+	findings := []zapv1alpha1.Finding{
+		{PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", Count: 3, Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/", Method: "GET"},
+			{URL: "https://example.com/static/a.css", Method: "GET"},
+			{URL: "https://example.com/static/b.css", Method: "GET"},
+		}},
+		{PluginID: "10096", Name: "Timestamp Disclosure", Risk: "informational", Count: 1, Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/app.js", Method: "GET"},
+		}},
+		{PluginID: "40012", Name: "Cross Site Scripting", Risk: "high", Count: 1, Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/search", Method: "GET", Param: "q"},
+		}},
+	}
+	sups := []suppression{
+		{name: "static-csp", spec: zapv1alpha1.ZapAlertSuppressionSpec{PluginID: "10038"}, url: regexp.MustCompile(`/static/`)},
+		{name: "timestamps", spec: zapv1alpha1.ZapAlertSuppressionSpec{PluginID: "10096"}},
+		{name: "old-xss", spec: zapv1alpha1.ZapAlertSuppressionSpec{PluginID: "40012", Param: "q"}, expired: true},
+		{name: "other-param", spec: zapv1alpha1.ZapAlertSuppressionSpec{PluginID: "40012", Param: "id"}},
+	}
+
+	kept, suppressed, expired := suppressFindings(findings, sups)
+
+	if len(kept) != 2 || kept[0].PluginID != "10038" || kept[1].PluginID != "40012" {
+		t.Fatalf("unexpected kept findings %+v", kept)
+	}
+	if kept[0].Count != 1 || len(kept[0].Instances) != 1 || kept[0].Instances[0].URL != "https://example.com/" {
+		t.Errorf("expected only the unmatched CSP instance to count, got %+v", kept[0])
+	}
+	if len(suppressed) != 2 {
+		t.Fatalf("expected two suppressed findings, got %+v", suppressed)
+	}
+	if suppressed[0].SuppressedBy != "static-csp" || suppressed[0].Count != 2 {
+		t.Errorf("unexpected suppressed CSP finding %+v", suppressed[0])
+	}
+	if suppressed[1].SuppressedBy != "timestamps" || suppressed[1].Count != 1 {
+		t.Errorf("unexpected suppressed timestamp finding %+v", suppressed[1])
+	}
+	if len(expired) != 1 || expired[0] != "old-xss" {
+		t.Errorf("expected the expired XSS suppression to be reported, got %v", expired)
+	}
+}
+
+func TestSuppressFindings_UnlistedInstances(t *testing.T) {
+	// ZAP reported 150 instances, of which only the first maxFindingInstances
+	// are listed.
+	instances := make([]zapv1alpha1.FindingInstance, maxFindingInstances)
+	for i := range instances {
+		instances[i] = zapv1alpha1.FindingInstance{URL: fmt.Sprintf("https://example.com/static/%d.css", i)}
+	}
+	findings := []zapv1alpha1.Finding{{PluginID: "10038", Risk: "medium", Count: 150, Instances: instances}}
+
+	// A suppression of every instance of the plugin covers the unlisted ones.
+	sups := []suppression{{name: "csp", spec: zapv1alpha1.ZapAlertSuppressionSpec{PluginID: "10038"}}}
+	kept, suppressed, _ := suppressFindings(findings, sups)
+	if len(kept) != 0 || len(suppressed) != 1 || suppressed[0].Count != 150 {
+		t.Errorf("expected the whole finding to be suppressed, got kept=%+v suppressed=%d findings", kept, len(suppressed))
+	}
+
+	// A URL pattern only covers the listed instances it matched; the others
+	// may be anywhere, so they still count.
+	sups = []suppression{{name: "static-csp", spec: zapv1alpha1.ZapAlertSuppressionSpec{PluginID: "10038"}, url: regexp.MustCompile(`/static/`)}}
+	kept, suppressed, _ = suppressFindings(findings, sups)
+	if len(suppressed) != 1 || suppressed[0].Count != maxFindingInstances {
+		t.Errorf("expected the %d listed instances to be suppressed, got %d findings", maxFindingInstances, len(suppressed))
+	}
+	if len(kept) != 1 || kept[0].Count != 50 || len(kept[0].Instances) != 0 {
+		t.Errorf("expected the 50 unlisted instances to be kept, got %d findings", len(kept))
+	}
+}
+
+func TestScanReconciler_AppliesSuppressions(t *testing.T) {
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	creationTime := metav1.NewTime(time.Unix(1700000000, 0))
+	jobName := scanJobNameWithTimestamp("s1", creationTime.Time)
+	scan := &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", CreationTimestamp: creationTime, UID: "uid-1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status:     zapv1alpha1.ZapScanStatus{Phase: "Running", JobName: jobName},
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: jobName, Namespace: "ns1"},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p1", Namespace: "ns1", Labels: map[string]string{"job-name": jobName}},
+	}
+	suppress := func(name, pluginID, target string, expires time.Time) *zapv1alpha1.ZapAlertSuppression {
+		return &zapv1alpha1.ZapAlertSuppression{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec: zapv1alpha1.ZapAlertSuppressionSpec{
+				PluginID: pluginID, Target: target, Reason: "accepted", Owner: "team-web", Expires: metav1.NewTime(expires),
+			},
+		}
+	}
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+
+	r := &ScanReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithStatusSubresource(&zapv1alpha1.ZapScan{}).
+			WithObjects(scan, job, pod,
+				suppress("timestamps", "10096", "https://example.com", future),
+				suppress("old-csp", "10038", "", past),
+				suppress("other-site", "10038", "https://other.example.com", future)).
+			Build(),
+		Scheme: s,
+		logsGetter: podLogsGetterFunc(func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+			return []byte(reportBeginMarker + "\n" + sampleZapReport + "\n" + reportEndMarker + "\n"), nil
+		}),
+	}
+
+	_, err := r.Reconcile(ctrl.LoggerInto(ctx, ctrl.Log.WithName("test")), ctrl.Request{NamespacedName: types.NamespacedName{Name: scan.Name, Namespace: scan.Namespace}})
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var updated zapv1alpha1.ZapScan
+	if err := r.Get(ctx, client.ObjectKeyFromObject(scan), &updated); err != nil {
+		t.Fatalf("get scan: %v", err)
+	}
+	if updated.Status.AlertsFound != 1 {
+		t.Errorf("expected the suppressed alert not to be counted, got %d", updated.Status.AlertsFound)
+	}
+	if updated.Status.AlertsByRisk == nil || *updated.Status.AlertsByRisk != (zapv1alpha1.RiskCounts{Medium: 1}) {
+		t.Errorf("unexpected alertsByRisk %+v", updated.Status.AlertsByRisk)
+	}
+	if updated.Status.AlertsSuppressed != 1 || len(updated.Status.SuppressedAlerts) != 1 || updated.Status.SuppressedAlerts[0].Suppression != "timestamps" {
+		t.Errorf("expected the timestamp alert to be listed as suppressed, got %d %+v", updated.Status.AlertsSuppressed, updated.Status.SuppressedAlerts)
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, zapv1alpha1.ConditionSuppressionExpired)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != "alerts matched expired suppressions: old-csp" {
+		t.Errorf("expected an expired suppression condition, got %+v", cond)
+	}
+
+	var report zapv1alpha1.ZapScanReport
+	if err := r.Get(ctx, types.NamespacedName{Name: scanReportName("s1", 1), Namespace: "ns1"}, &report); err != nil {
+		t.Fatalf("get report: %v", err)
+	}
+	if len(report.Spec.Findings) != 2 || report.Spec.Findings[1].SuppressedBy != "timestamps" {
+		t.Errorf("expected suppressed findings to be kept in the report, got %+v", report.Spec.Findings)
+	}
+}
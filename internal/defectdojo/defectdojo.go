@@ -0,0 +1,142 @@
+// Package defectdojo imports scan reports into DefectDojo.
+//
+// Only the import-scan and reimport-scan endpoints of the API v2 are
+// implemented, authenticated with an API key.
+package defectdojo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scan types of the reports the operator imports.
+const (
+	ScanTypeZAP   = "ZAP Scan"
+	ScanTypeSARIF = "SARIF"
+)
+
+// DefaultTimeout bounds a single import. DefectDojo parses the report before
+// it responds, so this is longer than for notifications.
+const DefaultTimeout = 2 * time.Minute
+
+// Client talks to one DefectDojo instance.
+type Client struct {
+	// URL is the base URL of DefectDojo, without /api/v2.
+	URL    string
+	APIKey string
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+// Import describes a report to import and the test it belongs to.
+type Import struct {
+	// Reimport updates the test with TestTitle in the engagement instead
+	// of creating a new one.
+	Reimport bool
+
+	ScanType string
+	FileName string
+	Report   []byte
+
+	ProductName       string
+	ProductTypeName   string
+	EngagementName    string
+	TestTitle         string
+	AutoCreateContext bool
+	CloseOldFindings  bool
+	MinimumSeverity   string
+	ScanDate          time.Time
+	Tags              []string
+}
+
+// Result is DefectDojo's response to an import.
+type Result struct {
+	Test       int `json:"test"`
+	Engagement int `json:"engagement"`
+	Product    int `json:"product"`
+}
+
+// Import uploads the report of im and returns the test it was imported into.
+func (c *Client) Import(ctx context.Context, im *Import) (*Result, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fields := [][2]string{
+		{"scan_type", im.ScanType},
+		{"product_name", im.ProductName},
+		{"engagement_name", im.EngagementName},
+		{"test_title", im.TestTitle},
+		{"auto_create_context", strconv.FormatBool(im.AutoCreateContext)},
+		{"close_old_findings", strconv.FormatBool(im.CloseOldFindings)},
+		{"active", "true"},
+		{"verified", "false"},
+	}
+	if im.ProductTypeName != "" {
+		fields = append(fields, [2]string{"product_type_name", im.ProductTypeName})
+	}
+	if im.MinimumSeverity != "" {
+		fields = append(fields, [2]string{"minimum_severity", im.MinimumSeverity})
+	}
+	if !im.ScanDate.IsZero() {
+		fields = append(fields, [2]string{"scan_date", im.ScanDate.UTC().Format(time.DateOnly)})
+	}
+	for _, tag := range im.Tags {
+		fields = append(fields, [2]string{"tags", tag})
+	}
+	for _, f := range fields {
+		if err := w.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	fw, err := w.CreateFormFile("file", im.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(im.Report); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	endpoint := "/api/v2/import-scan/"
+	if im.Reimport {
+		endpoint = "/api/v2/reimport-scan/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Token "+c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "zap-operator")
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	var res Result
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&res); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &res, nil
+}
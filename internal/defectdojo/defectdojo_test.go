@@ -0,0 +1,114 @@
+package defectdojo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Import(t *testing.T) {
+	var path, auth string
+	var form map[string][]string
+	var file, fileName string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse form: %v", err)
+			return
+		}
+		form = r.MultipartForm.Value
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("form file: %v", err)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		file, fileName = string(b), h.Filename
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"test": 42, "engagement": 7, "product": 3, "scan_type": "ZAP Scan"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL + "/", APIKey: "k3y"}
+	res, err := c.Import(context.Background(), &Import{
+		Reimport:          true,
+		ScanType:          ScanTypeZAP,
+		FileName:          "zap.xml",
+		Report:            []byte("<OWASPZAPReport/>"),
+		ProductName:       "shop",
+		EngagementName:    "zap",
+		TestTitle:         "nightly",
+		AutoCreateContext: true,
+		CloseOldFindings:  true,
+		MinimumSeverity:   "Low",
+		ScanDate:          time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC),
+		Tags:              []string{"zap-operator", "ns1"},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.Test != 42 || res.Engagement != 7 || res.Product != 3 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if path != "/api/v2/reimport-scan/" || auth != "Token k3y" {
+		t.Errorf("unexpected request path=%q authorization=%q", path, auth)
+	}
+	if file != "<OWASPZAPReport/>" || fileName != "zap.xml" {
+		t.Errorf("unexpected file %q named %q", file, fileName)
+	}
+	want := map[string]string{
+		"scan_type":           "ZAP Scan",
+		"product_name":        "shop",
+		"engagement_name":     "zap",
+		"test_title":          "nightly",
+		"auto_create_context": "true",
+		"close_old_findings":  "true",
+		"minimum_severity":    "Low",
+		"scan_date":           "2026-03-04",
+	}
+	for k, v := range want {
+		if got := form[k]; len(got) != 1 || got[0] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got)
+		}
+	}
+	if tags := form["tags"]; len(tags) != 2 || tags[1] != "ns1" {
+		t.Errorf("unexpected tags %q", tags)
+	}
+	if _, ok := form["product_type_name"]; ok {
+		t.Errorf("expected no product type, got %q", form["product_type_name"])
+	}
+}
+
+func TestClient_ImportNewTest(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = w.Write([]byte(`{"test": 1}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, APIKey: "k3y"}
+	if _, err := c.Import(context.Background(), &Import{ScanType: ScanTypeSARIF, FileName: "zap.sarif"}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if path != "/api/v2/import-scan/" {
+		t.Errorf("expected import-scan, got %q", path)
+	}
+}
+
+func TestClient_ImportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"detail": "Invalid token."}`, http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, APIKey: "wrong"}
+	_, err := c.Import(context.Background(), &Import{ScanType: ScanTypeZAP, FileName: "zap.xml"})
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "Invalid token") {
+		t.Errorf("expected the 403 response in the error, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package jira manages issues through the Jira REST API v2.
+//
+// Only creating issues, commenting on them and transitioning them is
+// implemented, which is all the operator needs to track findings. Jira Cloud
+// is authenticated with an account email and API token, Jira Data Center
+// with a personal access token.
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single request.
+const DefaultTimeout = 10 * time.Second
+
+// Client talks to one Jira instance.
+type Client struct {
+	// URL is the base URL of Jira, without /rest/api/2.
+	URL string
+
+	// Username is sent with Token as basic auth if set. Otherwise Token is
+	// sent as a bearer token.
+	Username string
+	Token    string
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+// Issue holds the fields of an issue to create.
+type Issue struct {
+	Project     string
+	IssueType   string
+	Summary     string
+	Description string
+	Labels      []string
+}
+
+// CreateIssue creates an issue and returns its key.
+func (c *Client) CreateIssue(ctx context.Context, issue *Issue) (string, error) {
+	fields := map[string]any{
+		"project":     map[string]string{"key": issue.Project},
+		"issuetype":   map[string]string{"name": issue.IssueType},
+		"summary":     issue.Summary,
+		"description": issue.Description,
+	}
+	if len(issue.Labels) > 0 {
+		fields["labels"] = issue.Labels
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/issue", map[string]any{"fields": fields}, &created); err != nil {
+		return "", fmt.Errorf("create issue: %w", err)
+	}
+	if created.Key == "" {
+		return "", fmt.Errorf("create issue: no key in response")
+	}
+	return created.Key, nil
+}
+
+// AddComment adds a comment to the issue with key.
+func (c *Client) AddComment(ctx context.Context, key, body string) error {
+	if err := c.do(ctx, http.MethodPost, "/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("comment on %s: %w", key, err)
+	}
+	return nil
+}
+
+// Transition moves the issue with key along the transition named name, or
+// the one leading to the status named name. Names are compared case-insensitively.
+func (c *Client) Transition(ctx context.Context, key, name string) error {
+	var list struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	path := "/issue/" + url.PathEscape(key) + "/transitions"
+	if err := c.do(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return fmt.Errorf("list transitions of %s: %w", key, err)
+	}
+	var id string
+	for _, t := range list.Transitions {
+		if strings.EqualFold(t.Name, name) || strings.EqualFold(t.To.Name, name) {
+			id = t.ID
+			break
+		}
+	}
+	if id == "" {
+		return fmt.Errorf("issue %s has no transition %q", key, name)
+	}
+	if err := c.do(ctx, http.MethodPost, path, map[string]any{"transition": map[string]string{"id": id}}, nil); err != nil {
+		return fmt.Errorf("transition %s: %w", key, err)
+	}
+	return nil
+}
+
+// do sends in as JSON to path below /rest/api/2 and decodes the response
+// into out, if set. It returns an error for any non-2xx response.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+"/rest/api/2"+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "zap-operator")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
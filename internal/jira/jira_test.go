@@ -0,0 +1,132 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_CreateIssue(t *testing.T) {
+	var got struct {
+		Fields struct {
+			Project     map[string]string `json:"project"`
+			IssueType   map[string]string `json:"issuetype"`
+			Summary     string            `json:"summary"`
+			Description string            `json:"description"`
+			Labels      []string          `json:"labels"`
+		} `json:"fields"`
+	}
+	var path string
+	var user, pass string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "10001", "key": "SEC-1", "self": "x"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL + "/", Username: "bot@example.com", Token: "t0ken"}
+	key, err := c.CreateIssue(context.Background(), &Issue{
+		Project:     "SEC",
+		IssueType:   "Bug",
+		Summary:     "XSS",
+		Description: "found",
+		Labels:      []string{"zap-operator"},
+	})
+	if err != nil {
+		t.Fatalf("create issue: %v", err)
+	}
+	if key != "SEC-1" {
+		t.Errorf("expected key SEC-1, got %q", key)
+	}
+	if path != "/rest/api/2/issue" || user != "bot@example.com" || pass != "t0ken" {
+		t.Errorf("unexpected request path=%q user=%q pass=%q", path, user, pass)
+	}
+	f := got.Fields
+	if f.Project["key"] != "SEC" || f.IssueType["name"] != "Bug" || f.Summary != "XSS" || f.Description != "found" || len(f.Labels) != 1 {
+		t.Errorf("unexpected fields %+v", f)
+	}
+}
+
+func TestClient_AddComment(t *testing.T) {
+	var path, auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		var c struct {
+			Body string `json:"body"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&c)
+		body = c.Body
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Token: "pat"}
+	if err := c.AddComment(context.Background(), "SEC-1", "seen again"); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+	if path != "/rest/api/2/issue/SEC-1/comment" || auth != "Bearer pat" || body != "seen again" {
+		t.Errorf("unexpected request path=%q authorization=%q body=%q", path, auth, body)
+	}
+}
+
+func TestClient_Transition(t *testing.T) {
+	var posted string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/issue/SEC-1/transitions" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"transitions": [
+				{"id": "11", "name": "Start Progress", "to": {"name": "In Progress"}},
+				{"id": "31", "name": "Close Issue", "to": {"name": "Done"}}
+			]}`))
+			return
+		}
+		var in struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		posted = in.Transition.ID
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Token: "pat"}
+	if err := c.Transition(context.Background(), "SEC-1", "done"); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+	if posted != "31" {
+		t.Errorf("expected transition 31 by its target status, got %q", posted)
+	}
+	if err := c.Transition(context.Background(), "SEC-1", "Start progress"); err != nil || posted != "11" {
+		t.Errorf("expected transition 11 by its name, got %q: %v", posted, err)
+	}
+	if err := c.Transition(context.Background(), "SEC-1", "Reopen"); err == nil || !strings.Contains(err.Error(), "no transition") {
+		t.Errorf("expected an error for a missing transition, got %v", err)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"errorMessages": ["project SEC does not exist"]}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Token: "pat"}
+	_, err := c.CreateIssue(context.Background(), &Issue{Project: "SEC", IssueType: "Bug", Summary: "x"})
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected the 400 response in the error, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package junit converts normalized ZAP findings into JUnit XML reports.
+//
+// A scan becomes a test suite named after its target with one test case per
+// ZAP plugin. A test case fails when the plugin raised an alert at or above
+// the configured risk. A scan without findings has a single passing test case,
+// so CI still shows that it ran.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+const (
+	// DefaultFailOnRisk is the lowest risk that fails a test case when none is configured.
+	DefaultFailOnRisk = "medium"
+
+	// CleanScanCase names the test case of a scan without findings.
+	CleanScanCase = "ZAP scan found no alerts"
+)
+
+// TestSuites is the root element of a JUnit report.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite holds the test cases of one target.
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Time      string     `xml:"time,attr,omitempty"`
+	Timestamp string     `xml:"timestamp,attr,omitempty"`
+	Cases     []TestCase `xml:"testcase"`
+}
+
+// TestCase is one ZAP plugin.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+// Failure describes the alerts that failed a test case.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Suite describes the scan a report is generated for.
+type Suite struct {
+	// Target is the scanned URL, used as the suite name.
+	Target string
+
+	// Timestamp is when the scan started, in RFC 3339 format.
+	Timestamp string
+
+	// Seconds is the scan duration.
+	Seconds float64
+}
+
+// Convert builds a JUnit report for a scan. Findings of the same plugin are
+// merged into a single test case, which fails if any of them is at or above failOnRisk.
+func Convert(suite Suite, findings []zapv1alpha1.Finding, failOnRisk string) *TestSuites {
+	if failOnRisk == "" {
+		failOnRisk = DefaultFailOnRisk
+	}
+	threshold := risk.Rank(failOnRisk)
+
+	type plugin struct {
+		name     string
+		risk     string
+		count    int32
+		urls     []string
+		failures []string
+	}
+	byID := map[string]*plugin{}
+	var order []string
+	for _, f := range findings {
+		p, ok := byID[f.PluginID]
+		if !ok {
+			p = &plugin{name: f.Name, risk: f.Risk}
+			byID[f.PluginID] = p
+			order = append(order, f.PluginID)
+		}
+		if risk.Rank(f.Risk) > risk.Rank(p.risk) {
+			p.risk = f.Risk
+		}
+		p.count += f.Count
+		for _, in := range f.Instances {
+			line := strings.TrimSpace(in.Method + " " + in.URL)
+			if in.Param != "" {
+				line += fmt.Sprintf(" (parameter %q)", in.Param)
+			}
+			p.urls = append(p.urls, line)
+			if risk.Rank(f.Risk) >= threshold {
+				p.failures = append(p.failures, fmt.Sprintf("[%s] %s", f.Risk, line))
+			}
+		}
+		if len(f.Instances) == 0 && risk.Rank(f.Risk) >= threshold {
+			p.failures = append(p.failures, fmt.Sprintf("[%s] %s", f.Risk, f.Name))
+		}
+	}
+
+	ts := TestSuite{Name: suite.Target, Timestamp: suite.Timestamp}
+	if suite.Seconds > 0 {
+		ts.Time = fmt.Sprintf("%.3f", suite.Seconds)
+	}
+	for _, id := range order {
+		p := byID[id]
+		tc := TestCase{Name: fmt.Sprintf("%s: %s", id, p.name), ClassName: suite.Target}
+		if risk.Rank(p.risk) >= threshold {
+			tc.Failure = &Failure{
+				Message: fmt.Sprintf("%d instances, highest risk %s", p.count, p.risk),
+				Type:    p.risk,
+				Text:    strings.Join(p.failures, "\n"),
+			}
+			ts.Failures++
+		} else if len(p.urls) > 0 {
+			tc.SystemOut = strings.Join(p.urls, "\n")
+		}
+		ts.Cases = append(ts.Cases, tc)
+	}
+	if len(ts.Cases) == 0 {
+		ts.Cases = []TestCase{{Name: CleanScanCase, ClassName: suite.Target}}
+	}
+	ts.Tests = len(ts.Cases)
+
+	return &TestSuites{
+		Name:     "ZAP",
+		Tests:    ts.Tests,
+		Failures: ts.Failures,
+		Suites:   []TestSuite{ts},
+	}
+}
+
+// Marshal converts findings and encodes the report as indented XML with a header.
+func Marshal(suite Suite, findings []zapv1alpha1.Finding, failOnRisk string) ([]byte, error) {
+	data, err := xml.MarshalIndent(Convert(suite, findings, failOnRisk), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,89 @@
+package junit
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+var findings = []zapv1alpha1.Finding{
+	{
+		PluginID: "10038", Name: "CSP Header Not Set", Risk: "medium", Count: 2,
+		Instances: []zapv1alpha1.FindingInstance{
+			{URL: "https://example.com/", Method: "GET"},
+			{URL: "https://example.com/login", Method: "POST", Param: "user"},
+		},
+	},
+	{PluginID: "10096", Name: "Timestamp Disclosure", Risk: "informational", Count: 1,
+		Instances: []zapv1alpha1.FindingInstance{{URL: "https://example.com/app.js", Method: "GET"}}},
+	{PluginID: "10038", Name: "CSP Header Not Set", Risk: "low", Count: 1},
+}
+
+func TestConvert(t *testing.T) {
+	report := Convert(Suite{Target: "https://example.com", Timestamp: "2026-01-02T03:04:05Z", Seconds: 61.5}, findings, "")
+	if report.Tests != 2 || report.Failures != 1 || len(report.Suites) != 1 {
+		t.Fatalf("unexpected totals %+v", report)
+	}
+
+	suite := report.Suites[0]
+	if suite.Name != "https://example.com" || suite.Time != "61.500" || suite.Timestamp != "2026-01-02T03:04:05Z" {
+		t.Errorf("unexpected suite %+v", suite)
+	}
+
+	csp := suite.Cases[0]
+	if csp.Name != "10038: CSP Header Not Set" || csp.ClassName != "https://example.com" {
+		t.Errorf("unexpected test case %+v", csp)
+	}
+	if csp.Failure == nil || csp.Failure.Type != "medium" || csp.Failure.Message != "3 instances, highest risk medium" {
+		t.Fatalf("expected medium failure by default, got %+v", csp.Failure)
+	}
+	if !strings.Contains(csp.Failure.Text, `[medium] POST https://example.com/login (parameter "user")`) {
+		t.Errorf("expected failing instances in failure text, got %q", csp.Failure.Text)
+	}
+
+	ts := suite.Cases[1]
+	if ts.Failure != nil {
+		t.Errorf("expected informational alert to pass, got %+v", ts.Failure)
+	}
+	if ts.SystemOut != "GET https://example.com/app.js" {
+		t.Errorf("expected passing instances in system-out, got %q", ts.SystemOut)
+	}
+}
+
+func TestConvert_Threshold(t *testing.T) {
+	if r := Convert(Suite{Target: "t"}, findings, "high"); r.Failures != 0 {
+		t.Errorf("expected no failures at high threshold, got %d", r.Failures)
+	}
+	if r := Convert(Suite{Target: "t"}, findings, "informational"); r.Failures != 2 {
+		t.Errorf("expected every plugin to fail at informational threshold, got %d", r.Failures)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(Suite{Target: "https://example.com"}, findings, "medium")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("expected XML header, got %q", data[:40])
+	}
+
+	var decoded TestSuites
+	if err := xml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Suites[0].Tests != 2 || decoded.Suites[0].Cases[0].Failure == nil {
+		t.Errorf("unexpected decoded report %+v", decoded)
+	}
+
+	empty, err := Marshal(Suite{Target: "https://example.com"}, nil, "")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(empty), `<testsuite name="https://example.com" tests="1" failures="0">`) ||
+		!strings.Contains(string(empty), `<testcase name="`+CleanScanCase+`" classname="https://example.com"></testcase>`) {
+		t.Errorf("expected a single passing test case, got %s", empty)
+	}
+}
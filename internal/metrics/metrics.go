@@ -15,6 +15,16 @@ var (
 		[]string{"scan_target", "scan_namespace", "risk", "plugin_id"},
 	)
 
+	// alertsByConfidence splits alertsFound by confidence. It's a metric of
+	// its own so queries and dashboards built on alertsFound keep working.
+	alertsByConfidence = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "zap_operator_alerts_by_confidence_total",
+			Help: "Total number of ZAP alerts found by zap-full-scan jobs, by confidence.",
+		},
+		[]string{"scan_target", "scan_namespace", "risk", "confidence", "plugin_id"},
+	)
+
 	scanRunsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "zap_operator_scan_runs_total",
@@ -56,6 +66,14 @@ var (
 		[]string{"scan_target", "scan_namespace"},
 	)
 
+	newAlerts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "zap_operator_new_alerts",
+			Help: "Number of alert instances in the last run of a scheduled scan that were not in the previous run.",
+		},
+		[]string{"scan_target", "scan_namespace", "schedule", "risk"},
+	)
+
 	registerOnce sync.Once
 )
 
@@ -63,20 +81,23 @@ func Register(registry prometheus.Registerer) {
 	registerOnce.Do(func() {
 		registry.MustRegister(
 			alertsFound,
+			alertsByConfidence,
 			scanRunsTotal,
 			scanDurationSeconds,
 			scansInProgress,
 			lastScanTimestamp,
 			lastScanDuration,
+			newAlerts,
 		)
 	})
 }
 
-func IncAlert(scanNamespace, scanTarget, risk, pluginID string, count int) {
+func IncAlert(scanNamespace, scanTarget, risk, confidence, pluginID string, count int) {
 	if count <= 0 {
 		return
 	}
 	alertsFound.WithLabelValues(scanTarget, scanNamespace, risk, pluginID).Add(float64(count))
+	alertsByConfidence.WithLabelValues(scanTarget, scanNamespace, risk, confidence, pluginID).Add(float64(count))
 }
 
 // IncScanRun increments the scan runs counter.
@@ -112,3 +133,15 @@ func DecScansInProgress(scanNamespace string) {
 func SetLastScanTimestamp(scanNamespace, scanTarget, status string, timestamp float64) {
 	lastScanTimestamp.WithLabelValues(scanTarget, scanNamespace, status).Set(timestamp)
 }
+
+// SetNewAlerts records how many alerts of a risk level the last run of a
+// scheduled scan found that the previous run didn't.
+func SetNewAlerts(scanNamespace, schedule, scanTarget, risk string, count int64) {
+	newAlerts.WithLabelValues(scanTarget, scanNamespace, schedule, risk).Set(float64(count))
+}
+
+// DeleteNewAlerts removes the new alert counts of a scheduled scan, for every
+// target it scanned.
+func DeleteNewAlerts(scanNamespace, schedule string) {
+	newAlerts.DeletePartialMatch(prometheus.Labels{"scan_namespace": scanNamespace, "schedule": schedule})
+}
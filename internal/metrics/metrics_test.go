@@ -17,11 +17,12 @@ func TestRegister(t *testing.T) {
 }
 
 func TestIncAlert(t *testing.T) {
-	// Reset the counter for testing
+	// Reset the counters for testing
 	alertsFound.Reset()
+	alertsByConfidence.Reset()
 
 	// Test with valid count
-	IncAlert("ns1", "https://example.com", "high", "10038", 5)
+	IncAlert("ns1", "https://example.com", "high", "medium", "10038", 5)
 
 	expected := `
 		# HELP zap_operator_alerts_found_total Total number of ZAP alerts found by zap-full-scan jobs.
@@ -31,9 +32,17 @@ func TestIncAlert(t *testing.T) {
 	if err := testutil.CollectAndCompare(alertsFound, strings.NewReader(expected)); err != nil {
 		t.Errorf("unexpected metric result: %v", err)
 	}
+	byConfidence := `
+		# HELP zap_operator_alerts_by_confidence_total Total number of ZAP alerts found by zap-full-scan jobs, by confidence.
+		# TYPE zap_operator_alerts_by_confidence_total counter
+		zap_operator_alerts_by_confidence_total{confidence="medium",plugin_id="10038",risk="high",scan_namespace="ns1",scan_target="https://example.com"} 5
+	`
+	if err := testutil.CollectAndCompare(alertsByConfidence, strings.NewReader(byConfidence)); err != nil {
+		t.Errorf("unexpected metric result by confidence: %v", err)
+	}
 
 	// Test with zero count (should not increment)
-	IncAlert("ns1", "https://example.com", "high", "10038", 0)
+	IncAlert("ns1", "https://example.com", "high", "medium", "10038", 0)
 
 	// Should still be 5
 	if err := testutil.CollectAndCompare(alertsFound, strings.NewReader(expected)); err != nil {
@@ -41,7 +50,7 @@ func TestIncAlert(t *testing.T) {
 	}
 
 	// Test with negative count (should not increment)
-	IncAlert("ns1", "https://example.com", "high", "10038", -1)
+	IncAlert("ns1", "https://example.com", "high", "medium", "10038", -1)
 
 	// Should still be 5
 	if err := testutil.CollectAndCompare(alertsFound, strings.NewReader(expected)); err != nil {
@@ -144,3 +153,31 @@ func TestSetLastScanTimestamp(t *testing.T) {
 		t.Errorf("expected %v, got %v", ts2, val)
 	}
 }
+
+func TestSetNewAlerts(t *testing.T) {
+	newAlerts.Reset()
+
+	SetNewAlerts("ns1", "nightly", "https://example.com", "high", 2)
+	SetNewAlerts("ns1", "nightly", "https://example.com", "high", 1)
+
+	val := testutil.ToFloat64(newAlerts.WithLabelValues("https://example.com", "ns1", "nightly", "high"))
+	if val != 1 {
+		t.Errorf("expected the last run's count 1, got %v", val)
+	}
+}
+
+func TestDeleteNewAlerts(t *testing.T) {
+	newAlerts.Reset()
+
+	SetNewAlerts("ns1", "nightly", "https://old.example.com", "high", 2)
+	SetNewAlerts("ns1", "nightly", "https://example.com", "high", 1)
+	SetNewAlerts("ns1", "weekly", "https://example.com", "high", 3)
+	DeleteNewAlerts("ns1", "nightly")
+
+	if n := testutil.CollectAndCount(newAlerts); n != 1 {
+		t.Errorf("expected only the other schedule's series to remain, got %d", n)
+	}
+	if val := testutil.ToFloat64(newAlerts.WithLabelValues("https://example.com", "ns1", "weekly", "high")); val != 3 {
+		t.Errorf("expected the other schedule's count 3, got %v", val)
+	}
+}
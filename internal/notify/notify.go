@@ -0,0 +1,117 @@
+// Package notify tells external systems about finished scans.
+//
+// Every backend implements Notifier. Delivery is attempted once per call; the
+// scan controller records the outcome on the ZapScan and retries failed
+// deliveries with Backoff until MaxAttempts is reached.
+package notify
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+const (
+	// MaxAttempts is how often a notification is tried before it is given up.
+	MaxAttempts = 5
+
+	// DefaultTimeout bounds a single delivery attempt.
+	DefaultTimeout = 10 * time.Second
+
+	baseBackoff = 10 * time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+// Notifier delivers the event of a finished scan to one destination.
+type Notifier interface {
+	Notify(ctx context.Context, ev *Event) error
+}
+
+// Event describes a finished scan. It is the data webhook body templates are
+// rendered with and the default webhook body. ReportURL links the HTML report,
+// if there is one.
+type Event struct {
+	Scan             string                     `json:"scan"`
+	Namespace        string                     `json:"namespace"`
+	Labels           map[string]string          `json:"labels,omitempty"`
+	Target           string                     `json:"target"`
+	Phase            string                     `json:"phase"`
+	Outcome          string                     `json:"outcome,omitempty"`
+	StartedAt        *metav1.Time               `json:"startedAt,omitempty"`
+	FinishedAt       *metav1.Time               `json:"finishedAt,omitempty"`
+	AlertsFound      int64                      `json:"alertsFound"`
+	AlertsByRisk     zapv1alpha1.RiskCounts     `json:"alertsByRisk"`
+	AlertsSuppressed int64                      `json:"alertsSuppressed,omitempty"`
+	TopAlerts        []zapv1alpha1.AlertSummary `json:"topAlerts,omitempty"`
+	Diff             *zapv1alpha1.FindingDiff   `json:"diff,omitempty"`
+	ReportURLs       []string                   `json:"reportURLs,omitempty"`
+	ReportURL        string                     `json:"reportURL,omitempty"`
+	LastError        string                     `json:"lastError,omitempty"`
+}
+
+// NewEvent returns the event of a finished scan.
+func NewEvent(scan *zapv1alpha1.ZapScan) *Event {
+	ev := &Event{
+		Scan:             scan.Name,
+		Namespace:        scan.Namespace,
+		Labels:           scan.Labels,
+		Target:           scan.Spec.Target,
+		Phase:            scan.Status.Phase,
+		Outcome:          scan.Status.Outcome,
+		StartedAt:        scan.Status.StartedAt,
+		FinishedAt:       scan.Status.FinishedAt,
+		AlertsFound:      scan.Status.AlertsFound,
+		AlertsSuppressed: scan.Status.AlertsSuppressed,
+		TopAlerts:        scan.Status.TopAlerts,
+		Diff:             scan.Status.Diff,
+		ReportURLs:       scan.Status.ReportURLs,
+		LastError:        scan.Status.LastError,
+	}
+	if scan.Status.AlertsByRisk != nil {
+		ev.AlertsByRisk = *scan.Status.AlertsByRisk
+	}
+	for _, u := range scan.Status.ReportURLs {
+		if strings.HasSuffix(u, "/zap.html") {
+			ev.ReportURL = u
+		}
+	}
+	return ev
+}
+
+// MeetsRisk reports whether the scan found an alert of risk minRisk or
+// higher. An empty minRisk is always met.
+func (ev *Event) MeetsRisk(minRisk string) bool {
+	return CountsMeetRisk(ev.AlertsByRisk, minRisk)
+}
+
+// CountsMeetRisk reports whether c counts an alert of risk minRisk or higher.
+// An empty minRisk is always met.
+func CountsMeetRisk(c zapv1alpha1.RiskCounts, minRisk string) bool {
+	switch minRisk {
+	case "":
+		return true
+	case "informational":
+		return c.High+c.Medium+c.Low+c.Informational > 0
+	case "low":
+		return c.High+c.Medium+c.Low > 0
+	case "medium":
+		return c.High+c.Medium > 0
+	case "high":
+		return c.High > 0
+	}
+	return false
+}
+
+// Backoff returns how long to wait before the next attempt after attempts
+// failed ones: 10s, doubling up to 5m.
+func Backoff(attempts int32) time.Duration {
+	d := baseBackoff
+	for i := int32(1); i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
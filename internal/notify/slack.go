@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Slack posts a summary of the event to a Slack-compatible incoming webhook.
+type Slack struct {
+	URL string
+
+	// Channel and Username override the webhook's defaults if set.
+	Channel  string
+	Username string
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+type slackMessage struct {
+	Channel  string       `json:"channel,omitempty"`
+	Username string       `json:"username,omitempty"`
+	Text     string       `json:"text"`
+	Blocks   []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Notify implements Notifier.
+func (s *Slack) Notify(ctx context.Context, ev *Event) error {
+	msg := slackMessageFor(ev)
+	msg.Channel, msg.Username = s.Channel, s.Username
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "zap-operator")
+	return withoutURL(send(s.HTTPClient, req))
+}
+
+// withoutURL drops the request URL from err. A Slack webhook URL is a
+// credential, and errors end up in the scan's status.
+func withoutURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("%s webhook: %w", uerr.Op, uerr.Err)
+	}
+	return err
+}
+
+// slackMessageFor renders the target, phase, alerts per risk, new and fixed
+// findings, and the report link of ev.
+func slackMessageFor(ev *Event) slackMessage {
+	title := fmt.Sprintf("ZAP scan %s/%s %s", ev.Namespace, ev.Scan, strings.ToLower(ev.Phase))
+	if ev.Outcome != "" {
+		title += " (" + ev.Outcome + ")"
+	}
+
+	summary := fmt.Sprintf("*%s*\n*Target:* %s", slackEscape(title), slackEscape(ev.Target))
+	if ev.LastError != "" {
+		summary += "\n*Error:* " + slackEscape(ev.LastError)
+	}
+	blocks := []slackBlock{
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: summary}},
+		{Type: "section", Fields: []slackText{
+			{Type: "mrkdwn", Text: fmt.Sprintf("*High:* %d", ev.AlertsByRisk.High)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Medium:* %d", ev.AlertsByRisk.Medium)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Low:* %d", ev.AlertsByRisk.Low)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Informational:* %d", ev.AlertsByRisk.Informational)},
+		}},
+	}
+	if d := ev.Diff; d != nil {
+		text := fmt.Sprintf("*New:* %d (%d high, %d medium)   *Fixed:* %d   *Persisting:* %d\nCompared with %s",
+			d.New, d.NewByRisk.High, d.NewByRisk.Medium, d.Fixed, d.Persisting, slackEscape(d.BaselineScan))
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}})
+	}
+	if ev.ReportURL != "" {
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "<" + ev.ReportURL + "|View report>"}})
+	}
+
+	text := fmt.Sprintf("%s: %d alerts on %s", title, ev.AlertsFound, ev.Target)
+	return slackMessage{Text: text, Blocks: blocks}
+}
+
+// slackEscape escapes the characters Slack's mrkdwn treats as control characters.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
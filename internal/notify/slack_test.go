@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestSlack_Notify(t *testing.T) {
+	var got slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	ev := testEvent()
+	ev.Target = "https://example.com/?a=<b>"
+	ev.Diff = &zapv1alpha1.FindingDiff{BaselineScan: "s0", New: 2, Fixed: 1, NewByRisk: zapv1alpha1.RiskCounts{High: 1, Medium: 1}}
+	ev.ReportURL = "https://reports.example.com/ns1/s1/zap.html"
+
+	s := &Slack{URL: srv.URL, Channel: "#security"}
+	if err := s.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if got.Channel != "#security" || !strings.Contains(got.Text, "ns1/s1 succeeded (Blocked): 3 alerts") {
+		t.Errorf("unexpected message %+v", got)
+	}
+	var text []string
+	for _, b := range got.Blocks {
+		if b.Text != nil {
+			text = append(text, b.Text.Text)
+		}
+		for _, f := range b.Fields {
+			text = append(text, f.Text)
+		}
+	}
+	all := strings.Join(text, "\n")
+	for _, want := range []string{
+		"*Target:* https://example.com/?a=&lt;b&gt;",
+		"*High:* 1", "*Medium:* 2", "*Low:* 0",
+		"*New:* 2 (1 high, 1 medium)", "*Fixed:* 1",
+		"<https://reports.example.com/ns1/s1/zap.html|View report>",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("expected %q in message:\n%s", want, all)
+		}
+	}
+}
+
+func TestSlack_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	err := (&Slack{URL: srv.URL}).Notify(context.Background(), testEvent())
+	if err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("expected the response to be reported, got %v", err)
+	}
+}
+
+func TestSlack_ErrorHidesURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	for _, u := range []string{
+		srv.URL + "/services/T000/B000/s3cret",
+		"http://hooks.example.com/services/%zz/s3cret",
+	} {
+		err := (&Slack{URL: u}).Notify(context.Background(), testEvent())
+		if err == nil || strings.Contains(err.Error(), "s3cret") {
+			t.Errorf("expected an error without the webhook URL, got %v", err)
+		}
+	}
+}
+
+func TestEvent_MeetsRisk(t *testing.T) {
+	ev := testEvent()
+	for risk, want := range map[string]bool{"": true, "informational": true, "low": true, "medium": true, "high": true} {
+		if got := ev.MeetsRisk(risk); got != want {
+			t.Errorf("MeetsRisk(%q) = %v, want %v", risk, got, want)
+		}
+	}
+	ev.AlertsByRisk = zapv1alpha1.RiskCounts{Low: 4}
+	for risk, want := range map[string]bool{"informational": true, "low": true, "medium": false, "high": false} {
+		if got := ev.MeetsRisk(risk); got != want {
+			t.Errorf("MeetsRisk(%q) with only low alerts = %v, want %v", risk, got, want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attachment is a file attached to a mail.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// SMTP mails events through an SMTP server.
+type SMTP struct {
+	Host string
+	Port int
+
+	// StartTLS upgrades the connection before authenticating and fails if
+	// the server doesn't support it.
+	StartTLS bool
+
+	// Username and Password authenticate with PLAIN if set. net/smtp refuses
+	// to send them over an unencrypted connection except to localhost.
+	Username string
+	Password string
+
+	From string
+	To   []string
+
+	// Attachments are attached to every mail, e.g. the scan's HTML report.
+	Attachments []Attachment
+
+	// TLSConfig is used for STARTTLS. Defaults to verifying Host.
+	TLSConfig *tls.Config
+
+	now func() time.Time
+}
+
+// Notify implements Notifier by mailing a summary of the scan.
+func (s *SMTP) Notify(ctx context.Context, ev *Event) error {
+	subject := fmt.Sprintf("ZAP scan %s/%s %s: %d alerts", ev.Namespace, ev.Scan, strings.ToLower(ev.Phase), ev.AlertsFound)
+	if ev.AlertsByRisk.High > 0 {
+		subject += fmt.Sprintf(" (%d high)", ev.AlertsByRisk.High)
+	}
+	var body bytes.Buffer
+	if err := scanMailTemplate.Execute(&body, ev); err != nil {
+		return err
+	}
+	return s.send(ctx, subject, body.Bytes())
+}
+
+// SendDigest mails one summary of every event in evs, which must not be empty.
+func (s *SMTP) SendDigest(ctx context.Context, namespace string, evs []*Event) error {
+	var high int64
+	for _, ev := range evs {
+		high += ev.AlertsByRisk.High
+	}
+	subject := fmt.Sprintf("ZAP scan digest for %s: %d scans, %d high alerts", namespace, len(evs), high)
+	var body bytes.Buffer
+	if err := digestMailTemplate.Execute(&body, struct {
+		Namespace string
+		Events    []*Event
+	}{namespace, evs}); err != nil {
+		return err
+	}
+	return s.send(ctx, subject, body.Bytes())
+}
+
+func (s *SMTP) send(ctx context.Context, subject string, html []byte) error {
+	msg, err := s.message(subject, html)
+	if err != nil {
+		return err
+	}
+
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if s.StartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server doesn't support STARTTLS")
+		}
+		cfg := s.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{ServerName: s.Host}
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message returns a multipart/mixed message with the HTML body and the attachments.
+func (s *SMTP) message(subject string, html []byte) ([]byte, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "zap-operator"
+	if at := strings.LastIndex(s.From, "@"); at >= 0 {
+		domain = s.From[at+1:]
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", s.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeBase64(part, html); err != nil {
+		return nil, err
+	}
+	for _, a := range s.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64-encoded in lines of 76 characters, as MIME requires.
+func writeBase64(w io.Writer, data []byte) error {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 0 {
+		n := min(76, len(enc))
+		if _, err := w.Write([]byte(enc[:n] + "\r\n")); err != nil {
+			return err
+		}
+		enc = enc[n:]
+	}
+	return nil
+}
+
+var scanMailTemplate = template.Must(template.New("scan").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<h2>ZAP scan {{ .Namespace }}/{{ .Scan }}</h2>
+<table>
+<tr><th align="left">Target</th><td>{{ .Target }}</td></tr>
+<tr><th align="left">Phase</th><td>{{ .Phase }}{{ with .Outcome }} ({{ . }}){{ end }}</td></tr>
+{{- with .FinishedAt }}
+<tr><th align="left">Finished</th><td>{{ .UTC.Format "2006-01-02 15:04:05 MST" }}</td></tr>
+{{- end }}
+{{- with .LastError }}
+<tr><th align="left">Error</th><td>{{ . }}</td></tr>
+{{- end }}
+</table>
+<h3>Alerts</h3>
+<table>
+<tr><th>High</th><th>Medium</th><th>Low</th><th>Informational</th></tr>
+<tr><td>{{ .AlertsByRisk.High }}</td><td>{{ .AlertsByRisk.Medium }}</td><td>{{ .AlertsByRisk.Low }}</td><td>{{ .AlertsByRisk.Informational }}</td></tr>
+</table>
+{{- with .Diff }}
+<p>Compared with {{ .BaselineScan }}: {{ .New }} new, {{ .Fixed }} fixed, {{ .Persisting }} persisting findings.</p>
+{{- end }}
+{{- with .TopAlerts }}
+<h3>Top alerts</h3>
+<ul>
+{{- range . }}
+<li>{{ .Risk }}: {{ .Name }} ({{ .Count }})</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- with .ReportURL }}
+<p><a href="{{ . }}">View report</a></p>
+{{- end }}
+</body>
+</html>
+`))
+
+var digestMailTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body>
+<h2>ZAP scan digest for {{ .Namespace }}</h2>
+<table>
+<tr><th>Scan</th><th>Target</th><th>Phase</th><th>Finished</th><th>High</th><th>Medium</th><th>Low</th><th>New</th><th>Fixed</th><th>Report</th></tr>
+{{- range .Events }}
+<tr>
+<td>{{ .Scan }}</td><td>{{ .Target }}</td><td>{{ .Phase }}{{ with .Outcome }} ({{ . }}){{ end }}</td>
+<td>{{ with .FinishedAt }}{{ .UTC.Format "2006-01-02 15:04 MST" }}{{ end }}</td>
+<td>{{ .AlertsByRisk.High }}</td><td>{{ .AlertsByRisk.Medium }}</td><td>{{ .AlertsByRisk.Low }}</td>
+<td>{{ with .Diff }}{{ .New }}{{ end }}</td><td>{{ with .Diff }}{{ .Fixed }}{{ end }}</td>
+<td>{{ with .ReportURL }}<a href="{{ . }}">View</a>{{ end }}</td>
+</tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
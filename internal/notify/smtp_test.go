@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStandIn is a minimal SMTP server recording the messages it receives.
+type smtpStandIn struct {
+	ln       net.Listener
+	tls      *tls.Config
+	messages chan smtpMessage
+}
+
+type smtpMessage struct {
+	from, auth string
+	to         []string
+	tls        bool
+	data       string
+}
+
+func newSMTPStandIn(t *testing.T, tlsConfig *tls.Config) *smtpStandIn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &smtpStandIn{ln: ln, tls: tlsConfig, messages: make(chan smtpMessage, 4)}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) port() int { return s.ln.Addr().(*net.TCPAddr).Port }
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	var msg smtpMessage
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	reply := func(line string) {
+		_, _ = w.WriteString(line + "\r\n")
+		_ = w.Flush()
+	}
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case cmd == "EHLO":
+			if s.tls != nil && !msg.tls {
+				reply("250-localhost")
+				reply("250 STARTTLS")
+			} else {
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			}
+		case cmd == "STARTTLS":
+			reply("220 ready")
+			tc := tls.Server(conn, s.tls)
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			conn, msg.tls = tc, true
+			r, w = bufio.NewReader(tc), bufio.NewWriter(tc)
+		case cmd == "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			msg.auth = strings.ReplaceAll(string(creds), "\x00", ":")
+			reply("235 ok")
+		case cmd == "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			reply("250 ok")
+		case cmd == "RCPT":
+			to := strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			if strings.HasPrefix(to, "unknown@") {
+				reply("550 no such user")
+				continue
+			}
+			msg.to = append(msg.to, to)
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// parseMail returns the subject, the decoded HTML body and the attachments of a message.
+func parseMail(t *testing.T, data string) (subject, body string, attachments map[string]string) {
+	t.Helper()
+	m, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	subject, err = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parse content type: %v", err)
+	}
+	attachments = map[string]string{}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		b, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if name := p.FileName(); name != "" {
+			attachments[name] = string(b)
+		} else {
+			body = string(b)
+		}
+	}
+	return subject, body, attachments
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	// Borrow httptest's certificate for 127.0.0.1.
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	standIn := newSMTPStandIn(t, srv.TLS)
+	s := &SMTP{
+		Host: "127.0.0.1", Port: standIn.port(), StartTLS: true,
+		Username: "zap", Password: "secret",
+		From: "zap@example.com", To: []string{"sec@example.com", "web@example.com"},
+		Attachments: []Attachment{{Name: "zap.html", ContentType: "text/html", Data: []byte("<html>report</html>")}},
+		TLSConfig:   &tls.Config{ServerName: "127.0.0.1", RootCAs: roots},
+	}
+	ev := testEvent()
+	ev.ReportURL = "https://reports.example.com/ns1/s1/zap.html"
+	if err := s.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	msg := <-standIn.messages
+	if !msg.tls || msg.auth != ":zap:secret" || msg.from != "zap@example.com" || strings.Join(msg.to, ",") != "sec@example.com,web@example.com" {
+		t.Errorf("unexpected envelope %+v", msg)
+	}
+	subject, body, attachments := parseMail(t, msg.data)
+	if subject != "ZAP scan ns1/s1 succeeded: 3 alerts (1 high)" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	for _, want := range []string{"https://example.com", "Succeeded (Blocked)", "<td>1</td><td>2</td>", `<a href="https://reports.example.com/ns1/s1/zap.html">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in body:\n%s", want, body)
+		}
+	}
+	if attachments["zap.html"] != "<html>report</html>" {
+		t.Errorf("expected the report to be attached, got %v", attachments)
+	}
+}
+
+func TestSMTP_SendDigest(t *testing.T) {
+	standIn := newSMTPStandIn(t, nil)
+	s := &SMTP{Host: "127.0.0.1", Port: standIn.port(), From: "zap@example.com", To: []string{"sec@example.com"}}
+
+	ev1, ev2 := testEvent(), testEvent()
+	ev2.Scan, ev2.Phase, ev2.Outcome = "s2", "Failed", ""
+	if err := s.SendDigest(context.Background(), "ns1", []*Event{ev1, ev2}); err != nil {
+		t.Fatalf("send digest: %v", err)
+	}
+
+	msg := <-standIn.messages
+	if msg.tls || msg.auth != "" {
+		t.Errorf("expected a plain unauthenticated session, got %+v", msg)
+	}
+	subject, body, _ := parseMail(t, msg.data)
+	if subject != "ZAP scan digest for ns1: 2 scans, 2 high alerts" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if strings.Count(body, "<td>s") != 2 || !strings.Contains(body, "<td>s2</td>") {
+		t.Errorf("expected both scans in body:\n%s", body)
+	}
+}
+
+func TestSMTP_Errors(t *testing.T) {
+	standIn := newSMTPStandIn(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := &SMTP{Host: "127.0.0.1", Port: standIn.port(), StartTLS: true, From: "zap@example.com", To: []string{"sec@example.com"}}
+	if err := s.Notify(ctx, testEvent()); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected missing STARTTLS support to fail, got %v", err)
+	}
+
+	s.StartTLS, s.To = false, []string{"unknown@example.com"}
+	if err := s.Notify(ctx, testEvent()); err == nil || !strings.Contains(err.Error(), "unknown@example.com") {
+		t.Errorf("expected the rejected recipient to be reported, got %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+	s.Port = port
+	if err := s.Notify(ctx, testEvent()); err == nil {
+		t.Errorf("expected an unreachable server to fail")
+	}
+}
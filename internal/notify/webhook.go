@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// SignatureHeader holds "sha256=" followed by the hex HMAC-SHA256 of
+	// "<timestamp>.<body>", keyed with the webhook's signing key.
+	SignatureHeader = "X-Zap-Operator-Signature"
+
+	// TimestampHeader holds the Unix time the request was signed at, so
+	// receivers can reject replays.
+	TimestampHeader = "X-Zap-Operator-Timestamp"
+)
+
+// Webhook posts the event to an HTTP endpoint.
+type Webhook struct {
+	URL string
+
+	// Body renders the request body. If nil, the event is sent as JSON.
+	Body *template.Template
+
+	// ContentType of the body. Defaults to application/json.
+	ContentType string
+
+	// Headers are added to every request.
+	Headers map[string]string
+
+	// SigningKey signs every request if set.
+	SigningKey []byte
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+// ParseBodyTemplate parses a webhook body template. Besides the usual
+// template functions, json renders its argument as JSON.
+func ParseBodyTemplate(text string) (*template.Template, error) {
+	t, err := template.New("body").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return t, nil
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, ev *Event) error {
+	body, err := w.render(ev)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	contentType := w.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "zap-operator")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(w.SigningKey) > 0 {
+		now := time.Now
+		if w.now != nil {
+			now = w.now
+		}
+		ts := strconv.FormatInt(now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.SigningKey, ts, body))
+	}
+
+	return send(w.HTTPClient, req)
+}
+
+func (w *Webhook) render(ev *Event) ([]byte, error) {
+	if w.Body == nil {
+		return json.Marshal(ev)
+	}
+	var buf bytes.Buffer
+	if err := w.Body.Execute(&buf, ev); err != nil {
+		return nil, fmt.Errorf("render body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with key.
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// send performs req and returns an error for any non-2xx response.
+func send(hc *http.Client, req *http.Request) error {
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func testEvent() *Event {
+	return NewEvent(&zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://example.com"},
+		Status: zapv1alpha1.ZapScanStatus{
+			Phase:        "Succeeded",
+			Outcome:      zapv1alpha1.OutcomeBlocked,
+			AlertsFound:  3,
+			AlertsByRisk: &zapv1alpha1.RiskCounts{High: 1, Medium: 2},
+		},
+	})
+}
+
+func TestWebhook_DefaultBody(t *testing.T) {
+	var got Event
+	var contentType, agent, custom string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, agent, custom = r.Header.Get("Content-Type"), r.Header.Get("User-Agent"), r.Header.Get("X-Team")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	w := &Webhook{URL: srv.URL, Headers: map[string]string{"X-Team": "web"}}
+	if err := w.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if got.Scan != "s1" || got.Namespace != "ns1" || got.Outcome != zapv1alpha1.OutcomeBlocked || got.AlertsByRisk.High != 1 {
+		t.Errorf("unexpected event %+v", got)
+	}
+	if contentType != "application/json" || agent != "zap-operator" || custom != "web" {
+		t.Errorf("unexpected headers content-type=%q user-agent=%q x-team=%q", contentType, agent, custom)
+	}
+}
+
+func TestWebhook_TemplateAndSignature(t *testing.T) {
+	var body, ts, sig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, ts, sig = string(b), r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader)
+	}))
+	defer srv.Close()
+
+	tmpl, err := ParseBodyTemplate(`{"text": {{json (printf "%s found %d alerts" .Scan .AlertsFound)}}, "high": {{.AlertsByRisk.High}}}`)
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+	w := &Webhook{URL: srv.URL, Body: tmpl, SigningKey: []byte("s3cret"), now: func() time.Time { return time.Unix(1700000000, 0) }}
+	if err := w.Notify(context.Background(), testEvent()); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if body != `{"text": "s1 found 3 alerts", "high": 1}` {
+		t.Errorf("unexpected body %q", body)
+	}
+	if ts != "1700000000" {
+		t.Errorf("unexpected timestamp %q", ts)
+	}
+	if want := "sha256=" + Sign([]byte("s3cret"), ts, []byte(body)); sig != want {
+		t.Errorf("unexpected signature %q, want %q", sig, want)
+	}
+	// printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac s3cret
+	if got := Sign([]byte("s3cret"), "1700000000", []byte(`{"a":1}`)); got != "1698a50bc74d1ff1db85c4e0a5297c2ad9fdba245d5737cdb789e4cc6e098940" {
+		t.Errorf("unexpected signature %q", got)
+	}
+}
+
+func TestWebhook_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	w := &Webhook{URL: srv.URL}
+	if err := w.Notify(context.Background(), testEvent()); err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "maintenance") {
+		t.Errorf("expected the response status in the error, got %v", err)
+	}
+
+	if _, err := ParseBodyTemplate("{{.Scan"); err == nil {
+		t.Errorf("expected an invalid template to be rejected")
+	}
+	tmpl, err := ParseBodyTemplate("{{.Missing}}")
+	if err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+	w.Body = tmpl
+	if err := w.Notify(context.Background(), testEvent()); err == nil || !strings.Contains(err.Error(), "render body") {
+		t.Errorf("expected a render error, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := Backoff(int32(i + 1)); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
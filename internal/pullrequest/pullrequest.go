@@ -0,0 +1,206 @@
+// Package pullrequest keeps a comment on a GitHub pull request or GitLab
+// merge request up to date.
+//
+// The comment is found again by a marker the caller embeds in its body, so
+// repeated calls update one comment instead of adding new ones. GitHub
+// Enterprise and self-managed GitLab are supported by setting the API URL.
+package pullrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a single request.
+const DefaultTimeout = 10 * time.Second
+
+// Providers.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Default API URLs of the providers.
+const (
+	DefaultGitHubURL = "https://api.github.com"
+	DefaultGitLabURL = "https://gitlab.com/api/v4"
+)
+
+// perPage is the page size used when listing comments, and maxPages bounds
+// how many pages are searched for the marker.
+const (
+	perPage  = 100
+	maxPages = 20
+)
+
+// Client comments on one pull request.
+type Client struct {
+	// Provider is ProviderGitHub or ProviderGitLab. Defaults to ProviderGitHub.
+	Provider string
+
+	// URL is the provider's API base URL. Defaults to the public API of the provider.
+	URL string
+
+	// Repository is owner/name on GitHub, and the project path or ID on GitLab.
+	Repository string
+
+	// Number is the pull request number, or the merge request IID on GitLab.
+	Number int
+
+	// Token is sent as a bearer token to GitHub and as a private token to GitLab.
+	Token string
+
+	// HTTPClient is used for requests. Defaults to a client with DefaultTimeout.
+	HTTPClient *http.Client
+}
+
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// UpsertComment updates the first comment containing marker to body, or adds
+// body as a new comment if there is none. body should contain marker. It
+// reports whether a comment was created.
+func (c *Client) UpsertComment(ctx context.Context, marker, body string) (bool, error) {
+	collection, item, err := c.commentPaths()
+	if err != nil {
+		return false, err
+	}
+
+	for page := 1; page <= maxPages; page++ {
+		var comments []comment
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", collection, perPage, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &comments); err != nil {
+			return false, fmt.Errorf("list comments: %w", err)
+		}
+		for _, cm := range comments {
+			if !strings.Contains(cm.Body, marker) {
+				continue
+			}
+			if cm.Body == body {
+				return false, nil
+			}
+			// GitHub edits comments with PATCH, GitLab with PUT.
+			method := http.MethodPatch
+			if c.provider() == ProviderGitLab {
+				method = http.MethodPut
+			}
+			if err := c.do(ctx, method, item(cm.ID), map[string]string{"body": body}, nil); err != nil {
+				return false, fmt.Errorf("update comment %d: %w", cm.ID, err)
+			}
+			return false, nil
+		}
+		if len(comments) < perPage {
+			break
+		}
+	}
+
+	if err := c.do(ctx, http.MethodPost, collection, map[string]string{"body": body}, nil); err != nil {
+		return false, fmt.Errorf("create comment: %w", err)
+	}
+	return true, nil
+}
+
+// APIURL returns the API base URL requests are sent to.
+func (c *Client) APIURL() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	if c.provider() == ProviderGitLab {
+		return DefaultGitLabURL
+	}
+	return DefaultGitHubURL
+}
+
+func (c *Client) provider() string {
+	if c.Provider == "" {
+		return ProviderGitHub
+	}
+	return c.Provider
+}
+
+// commentPaths returns the path of the pull request's comments and a
+// function returning the path of a single comment.
+func (c *Client) commentPaths() (string, func(id int64) string, error) {
+	if c.Repository == "" || c.Number <= 0 {
+		return "", nil, fmt.Errorf("a repository and a positive pull request number are required")
+	}
+	number := strconv.Itoa(c.Number)
+	switch c.provider() {
+	case ProviderGitHub:
+		owner, name, ok := strings.Cut(c.Repository, "/")
+		if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return "", nil, fmt.Errorf("GitHub repository %q is not owner/name", c.Repository)
+		}
+		repo := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+		return repo + "/issues/" + number + "/comments", func(id int64) string {
+			return repo + "/issues/comments/" + strconv.FormatInt(id, 10)
+		}, nil
+	case ProviderGitLab:
+		notes := "/projects/" + url.PathEscape(c.Repository) + "/merge_requests/" + number + "/notes"
+		return notes, func(id int64) string {
+			return notes + "/" + strconv.FormatInt(id, 10)
+		}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown provider %q", c.Provider)
+	}
+}
+
+// do sends in as JSON to path below the API URL and decodes the response
+// into out, if set. It returns an error for any non-2xx response.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.APIURL(), "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "zap-operator")
+	if c.provider() == ProviderGitLab {
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("PRIVATE-TOKEN", c.Token)
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package pullrequest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeComments serves one pull request's comments below prefix, and records
+// the requests it got.
+type fakeComments struct {
+	prefix   string
+	comments []comment
+	requests []string
+	auth     []string
+}
+
+func (f *fakeComments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.EscapedPath())
+	f.auth = append(f.auth, r.Header.Get("Authorization")+r.Header.Get("PRIVATE-TOKEN"))
+	path := strings.TrimPrefix(r.URL.EscapedPath(), f.prefix)
+	var in comment
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&in)
+	}
+	switch {
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(f.comments)
+	case r.Method == http.MethodPost:
+		f.comments = append(f.comments, comment{ID: int64(len(f.comments) + 1), Body: in.Body})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		for i := range f.comments {
+			if strings.HasSuffix(path, fmt.Sprintf("/%d", f.comments[i].ID)) {
+				f.comments[i].Body = in.Body
+			}
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}
+}
+
+func TestClient_UpsertComment_GitHub(t *testing.T) {
+	f := &fakeComments{prefix: "/api/v3/repos/acme/shop", comments: []comment{{ID: 7, Body: "LGTM"}}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL + "/api/v3", Repository: "acme/shop", Number: 42, Token: "t0ken"}
+	created, err := c.UpsertComment(context.Background(), "<!-- zap -->", "<!-- zap -->\nfirst")
+	if err != nil || !created {
+		t.Fatalf("expected a new comment, got created=%v err=%v", created, err)
+	}
+	created, err = c.UpsertComment(context.Background(), "<!-- zap -->", "<!-- zap -->\nsecond")
+	if err != nil || created {
+		t.Fatalf("expected the comment to be updated, got created=%v err=%v", created, err)
+	}
+	if len(f.comments) != 2 || f.comments[0].Body != "LGTM" || f.comments[1].Body != "<!-- zap -->\nsecond" {
+		t.Errorf("unexpected comments %+v", f.comments)
+	}
+	want := []string{
+		"GET /api/v3/repos/acme/shop/issues/42/comments",
+		"POST /api/v3/repos/acme/shop/issues/42/comments",
+		"GET /api/v3/repos/acme/shop/issues/42/comments",
+		"PATCH /api/v3/repos/acme/shop/issues/comments/2",
+	}
+	if strings.Join(f.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected requests\n%s", strings.Join(f.requests, "\n"))
+	}
+	if f.auth[0] != "Bearer t0ken" {
+		t.Errorf("expected a bearer token, got %q", f.auth[0])
+	}
+
+	// An unchanged comment isn't edited.
+	f.requests = nil
+	if _, err := c.UpsertComment(context.Background(), "<!-- zap -->", "<!-- zap -->\nsecond"); err != nil || len(f.requests) != 1 {
+		t.Errorf("expected only a listing, got %v err=%v", f.requests, err)
+	}
+}
+
+func TestClient_UpsertComment_GitLab(t *testing.T) {
+	f := &fakeComments{prefix: "/api/v4/projects/group%2Fshop", comments: []comment{{ID: 3, Body: "<!-- zap -->\nold"}}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	c := &Client{Provider: ProviderGitLab, URL: srv.URL + "/api/v4/", Repository: "group/shop", Number: 5, Token: "glpat"}
+	created, err := c.UpsertComment(context.Background(), "<!-- zap -->", "<!-- zap -->\nnew")
+	if err != nil || created {
+		t.Fatalf("expected the note to be updated, got created=%v err=%v", created, err)
+	}
+	if f.comments[0].Body != "<!-- zap -->\nnew" {
+		t.Errorf("unexpected notes %+v", f.comments)
+	}
+	if len(f.requests) != 2 || f.requests[1] != "PUT /api/v4/projects/group%2Fshop/merge_requests/5/notes/3" {
+		t.Errorf("unexpected requests %v", f.requests)
+	}
+	if f.auth[0] != "glpat" {
+		t.Errorf("expected a private token, got %q", f.auth[0])
+	}
+}
+
+func TestClient_UpsertComment_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	for _, c := range []*Client{
+		{URL: srv.URL, Repository: "shop", Number: 1},
+		{URL: srv.URL, Repository: "acme/shop"},
+		{URL: srv.URL, Provider: "bitbucket", Repository: "acme/shop", Number: 1},
+	} {
+		if _, err := c.UpsertComment(context.Background(), "m", "m"); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+	c := &Client{URL: srv.URL, Repository: "acme/shop", Number: 1}
+	if _, err := c.UpsertComment(context.Background(), "m", "m"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected a 401 error, got %v", err)
+	}
+}
+
+func TestClient_APIURL(t *testing.T) {
+	for c, want := range map[*Client]string{
+		{}:                         DefaultGitHubURL,
+		{Provider: ProviderGitLab}: DefaultGitLabURL,
+		{Provider: ProviderGitLab, URL: "https://gitlab.example.com/api/v4"}: "https://gitlab.example.com/api/v4",
+	} {
+		if got := c.APIURL(); got != want {
+			t.Errorf("%+v: expected %s, got %s", c, want, got)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package reportstore keeps full ZAP reports in ConfigMaps or Secrets.
+//
+// All report files of a scan are packed into a single gzipped tar archive,
+// which is split into chunks small enough to fit into Kubernetes objects.
+package reportstore
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+const (
+	// ChunkKey is the data key holding a chunk of the archive.
+	ChunkKey = "reports.tar.gz"
+
+	// MaxChunkBytes keeps each object comfortably below the 1MiB object size limit.
+	MaxChunkBytes = 900 << 10
+)
+
+// ChunkName returns the name of the i-th object holding a scan's reports.
+func ChunkName(scanName string, i int) string {
+	return fmt.Sprintf("%s-report-%d", scanName, i)
+}
+
+// Save packs files and writes them to ConfigMaps or Secrets owned by the scan.
+// Objects left over from a previous attempt are overwritten, but an object of
+// the same name the scan doesn't own is an error rather than replaced.
+func Save(ctx context.Context, c client.Client, scheme *runtime.Scheme, scan *zapv1alpha1.ZapScan, storageType string, files map[string][]byte) (*zapv1alpha1.ReportRef, error) {
+	if storageType != zapv1alpha1.ReportStorageConfigMap && storageType != zapv1alpha1.ReportStorageSecret {
+		return nil, fmt.Errorf("unsupported report storage type %q", storageType)
+	}
+
+	archive, err := Pack(files)
+	if err != nil {
+		return nil, err
+	}
+	chunks := split(archive, MaxChunkBytes)
+
+	now := metav1.Now()
+	ref := &zapv1alpha1.ReportRef{Type: storageType, Files: sortedNames(files), StoredAt: &now}
+	for i, chunk := range chunks {
+		meta := metav1.ObjectMeta{
+			Name:      ChunkName(scan.Name, i),
+			Namespace: scan.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "zap-operator",
+				"app.kubernetes.io/component": "zap-scan-report",
+				"spaceship.com/scan-name":     scan.Name,
+			},
+			Annotations: map[string]string{
+				"spaceship.com/report-chunk": fmt.Sprintf("%d/%d", i+1, len(chunks)),
+			},
+		}
+
+		var obj client.Object
+		if storageType == zapv1alpha1.ReportStorageSecret {
+			obj = &corev1.Secret{ObjectMeta: meta, Type: corev1.SecretTypeOpaque, Data: map[string][]byte{ChunkKey: chunk}}
+		} else {
+			obj = &corev1.ConfigMap{ObjectMeta: meta, BinaryData: map[string][]byte{ChunkKey: chunk}}
+		}
+		if err := controllerutil.SetControllerReference(scan, obj, scheme); err != nil {
+			return nil, err
+		}
+		if err := createOrReplace(ctx, c, scan, obj); err != nil {
+			return nil, err
+		}
+		ref.Names = append(ref.Names, meta.Name)
+	}
+
+	// A previous attempt with a larger archive may have left more chunks.
+	// Chunks are numbered without gaps, so the first missing one, or one the
+	// scan doesn't own, ends them.
+	for i := len(chunks); ; i++ {
+		obj := chunkObject(storageType, ChunkName(scan.Name, i), scan.Namespace)
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); errors.IsNotFound(err) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if !metav1.IsControlledBy(obj, scan) {
+			break
+		}
+		uid, rv := obj.GetUID(), obj.GetResourceVersion()
+		err := c.Delete(ctx, obj, client.Preconditions{UID: &uid, ResourceVersion: &rv})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return ref, nil
+}
+
+// createOrReplace creates obj, or overwrites the object of the same name if
+// scan controls it. Objects of the same name created by anyone else are left
+// alone, so a scan can't replace a user's ConfigMap or Secret.
+func createOrReplace(ctx context.Context, c client.Client, scan *zapv1alpha1.ZapScan, obj client.Object) error {
+	err := c.Create(ctx, obj)
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(existing, scan) {
+		return fmt.Errorf("%s/%s already exists and isn't owned by ZapScan %s", obj.GetNamespace(), obj.GetName(), scan.Name)
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return c.Update(ctx, obj)
+}
+
+// chunkObject returns an empty ConfigMap or Secret named name, to be deleted.
+func chunkObject(storageType, name, namespace string) client.Object {
+	meta := metav1.ObjectMeta{Name: name, Namespace: namespace}
+	if storageType == zapv1alpha1.ReportStorageSecret {
+		return &corev1.Secret{ObjectMeta: meta}
+	}
+	return &corev1.ConfigMap{ObjectMeta: meta}
+}
+
+// Load reads and unpacks the reports referenced by ref.
+// maxBytes caps the total unpacked size.
+func Load(ctx context.Context, c client.Reader, namespace string, ref *zapv1alpha1.ReportRef, maxBytes int64) (map[string][]byte, error) {
+	var archive bytes.Buffer
+	for _, name := range ref.Names {
+		nn := types.NamespacedName{Namespace: namespace, Name: name}
+		switch ref.Type {
+		case zapv1alpha1.ReportStorageSecret:
+			var secret corev1.Secret
+			if err := c.Get(ctx, nn, &secret); err != nil {
+				return nil, err
+			}
+			archive.Write(secret.Data[ChunkKey])
+		case zapv1alpha1.ReportStorageConfigMap:
+			var cm corev1.ConfigMap
+			if err := c.Get(ctx, nn, &cm); err != nil {
+				return nil, err
+			}
+			archive.Write(cm.BinaryData[ChunkKey])
+		default:
+			return nil, fmt.Errorf("reports stored in %s can't be loaded by the operator", ref.Type)
+		}
+	}
+	return Unpack(archive.Bytes(), maxBytes)
+}
+
+// Delete removes the ConfigMaps or Secrets referenced by ref.
+func Delete(ctx context.Context, c client.Client, namespace string, ref *zapv1alpha1.ReportRef) error {
+	for _, name := range ref.Names {
+		if err := c.Delete(ctx, chunkObject(ref.Type, name, namespace)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pack writes files into a gzipped tar archive, sorted by name.
+func Pack(files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range sortedNames(files) {
+		data := files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unpack reads a gzipped tar archive written by Pack.
+// It fails once the unpacked files exceed maxBytes in total.
+func Unpack(archive []byte, maxBytes int64) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gz.Close() }()
+
+	files := map[string][]byte{}
+	remaining := maxBytes
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Size > remaining {
+			return nil, fmt.Errorf("stored reports exceed the %d byte limit", maxBytes)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return nil, err
+		}
+		remaining -= int64(len(data))
+		files[hdr.Name] = data
+	}
+}
+
+func split(data []byte, size int) [][]byte {
+	var out [][]byte
+	for len(data) > size {
+		out = append(out, data[:size])
+		data = data[size:]
+	}
+	return append(out, data)
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
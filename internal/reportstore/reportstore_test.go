@@ -0,0 +1,212 @@
+package reportstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func newTestClient(t *testing.T, objs ...client.Object) (client.Client, *runtime.Scheme) {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build(), s
+}
+
+func TestPackUnpack(t *testing.T) {
+	files := map[string][]byte{
+		"zap.json": []byte(`{"site":[]}`),
+		"zap.html": []byte("<html></html>"),
+	}
+
+	archive, err := Pack(files)
+	if err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+
+	got, err := Unpack(archive, 1<<20)
+	if err != nil {
+		t.Fatalf("unpack: %v", err)
+	}
+	if len(got) != 2 || string(got["zap.json"]) != `{"site":[]}` || string(got["zap.html"]) != "<html></html>" {
+		t.Errorf("unexpected files after round trip: %v", got)
+	}
+
+	if _, err := Unpack(archive, 5); err == nil {
+		t.Errorf("expected size limit to be enforced")
+	}
+	if _, err := Unpack([]byte("not gzip"), 1<<20); err == nil {
+		t.Errorf("expected error for invalid archive")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	chunks := split([]byte("abcdefg"), 3)
+	if len(chunks) != 3 || string(chunks[0]) != "abc" || string(chunks[2]) != "g" {
+		t.Errorf("unexpected chunks %q", chunks)
+	}
+	if chunks := split(nil, 3); len(chunks) != 1 {
+		t.Errorf("expected a single empty chunk, got %d", len(chunks))
+	}
+}
+
+func TestSaveLoadDelete(t *testing.T) {
+	ctx := context.Background()
+
+	for _, storageType := range []string{zapv1alpha1.ReportStorageConfigMap, zapv1alpha1.ReportStorageSecret} {
+		scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", UID: "uid-1"}}
+		c, s := newTestClient(t, scan)
+
+		// Random data doesn't compress, so it must be split across objects.
+		big := make([]byte, MaxChunkBytes+1024)
+		_, _ = rand.Read(big)
+		files := map[string][]byte{"zap.json": []byte("{}"), "zap.html": big}
+
+		ref, err := Save(ctx, c, s, scan, storageType, files)
+		if err != nil {
+			t.Fatalf("%s: save: %v", storageType, err)
+		}
+		if len(ref.Names) != 2 {
+			t.Fatalf("%s: expected 2 chunks, got %v", storageType, ref.Names)
+		}
+		if ref.Names[0] != ChunkName("s1", 0) || ref.Type != storageType {
+			t.Errorf("%s: unexpected ref %+v", storageType, ref)
+		}
+		if len(ref.Files) != 2 || ref.Files[0] != "zap.html" {
+			t.Errorf("%s: expected sorted file names, got %v", storageType, ref.Files)
+		}
+
+		// Saving again must overwrite the existing chunks.
+		if _, err := Save(ctx, c, s, scan, storageType, files); err != nil {
+			t.Fatalf("%s: second save: %v", storageType, err)
+		}
+
+		// Saving a smaller archive removes the chunks it doesn't need.
+		small, err := Save(ctx, c, s, scan, storageType, map[string][]byte{"zap.json": []byte("{}")})
+		if err != nil || len(small.Names) != 1 {
+			t.Fatalf("%s: small save: %+v %v", storageType, small, err)
+		}
+		var leftover client.Object = &corev1.ConfigMap{}
+		if storageType == zapv1alpha1.ReportStorageSecret {
+			leftover = &corev1.Secret{}
+		}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "ns1", Name: ChunkName("s1", 1)}, leftover); err == nil {
+			t.Errorf("%s: expected the leftover chunk to be deleted", storageType)
+		}
+		if ref, err = Save(ctx, c, s, scan, storageType, files); err != nil {
+			t.Fatalf("%s: third save: %v", storageType, err)
+		}
+
+		got, err := Load(ctx, c, "ns1", ref, 10<<20)
+		if err != nil {
+			t.Fatalf("%s: load: %v", storageType, err)
+		}
+		if !bytes.Equal(got["zap.html"], big) {
+			t.Errorf("%s: html report did not round trip", storageType)
+		}
+
+		if err := Delete(ctx, c, "ns1", ref); err != nil {
+			t.Fatalf("%s: delete: %v", storageType, err)
+		}
+		var obj client.Object = &corev1.ConfigMap{}
+		if storageType == zapv1alpha1.ReportStorageSecret {
+			obj = &corev1.Secret{}
+		}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: "ns1", Name: ref.Names[0]}, obj); err == nil {
+			t.Errorf("%s: expected chunks to be deleted", storageType)
+		}
+		// Deleting twice is fine.
+		if err := Delete(ctx, c, "ns1", ref); err != nil {
+			t.Errorf("%s: second delete: %v", storageType, err)
+		}
+	}
+}
+
+func TestSaveOwnsChunks(t *testing.T) {
+	ctx := context.Background()
+	scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", UID: "uid-1"}}
+	c, s := newTestClient(t, scan)
+
+	ref, err := Save(ctx, c, s, scan, zapv1alpha1.ReportStorageConfigMap, map[string][]byte{"zap.json": []byte("{}")})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "ns1", Name: ref.Names[0]}, &cm); err != nil {
+		t.Fatalf("get chunk: %v", err)
+	}
+	if !metav1.IsControlledBy(&cm, scan) {
+		t.Errorf("expected chunk to be owned by the scan")
+	}
+	if cm.Labels["spaceship.com/scan-name"] != "s1" {
+		t.Errorf("expected scan label, got %v", cm.Labels)
+	}
+}
+
+func TestSaveLeavesForeignObjects(t *testing.T) {
+	ctx := context.Background()
+	scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1", UID: "uid-1"}}
+	user := func(i int) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: ChunkName("s1", i), Namespace: "ns1"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		}
+	}
+
+	// A user's Secret named like a chunk isn't overwritten.
+	c, s := newTestClient(t, scan, user(0))
+	if _, err := Save(ctx, c, s, scan, zapv1alpha1.ReportStorageSecret, map[string][]byte{"zap.json": []byte("{}")}); err == nil {
+		t.Errorf("expected saving over a foreign Secret to fail")
+	}
+	var got corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "ns1", Name: ChunkName("s1", 0)}, &got); err != nil || string(got.Data["password"]) != "hunter2" {
+		t.Errorf("expected the user's Secret to be kept, got %v %v", got.Data, err)
+	}
+
+	// Nor is one that looks like a leftover chunk deleted.
+	c, s = newTestClient(t, scan, user(1))
+	if _, err := Save(ctx, c, s, scan, zapv1alpha1.ReportStorageSecret, map[string][]byte{"zap.json": []byte("{}")}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "ns1", Name: ChunkName("s1", 1)}, &got); err != nil {
+		t.Errorf("expected the user's Secret to be kept: %v", err)
+	}
+}
+
+func TestSaveUnsupportedType(t *testing.T) {
+	scan := &zapv1alpha1.ZapScan{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "ns1"}}
+	c, s := newTestClient(t, scan)
+
+	if _, err := Save(context.Background(), c, s, scan, zapv1alpha1.ReportStoragePVC, nil); err == nil {
+		t.Errorf("expected error for PVC storage")
+	}
+	if _, err := Load(context.Background(), c, "ns1", &zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStoragePVC, Names: []string{"x"}}, 1); err == nil {
+		t.Errorf("expected error loading PVC storage")
+	}
+}
+
+func TestLoadMissingChunk(t *testing.T) {
+	c, _ := newTestClient(t)
+	ref := &zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStorageSecret, Names: []string{"missing"}}
+	if _, err := Load(context.Background(), c, "ns1", ref, 1<<20); err == nil {
+		t.Errorf("expected error for missing chunk")
+	}
+}
@@ -0,0 +1,163 @@
+package results
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TokenKey is the key holding the upload token in a scan's results Secret.
+	TokenKey = "token"
+
+	// DefaultMaxBytes caps a single uploaded report file when Server.MaxBytes is unset.
+	DefaultMaxBytes int64 = 64 << 20
+)
+
+// TokenSecretName returns the name of the Secret holding the upload token for a scan Job.
+func TokenSecretName(jobName string) string {
+	return jobName + "-results"
+}
+
+var fileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Server receives report files from scan pods.
+//
+// Scan pods POST each file to /reports/{namespace}/{job}/{file} with an
+// "Authorization: Bearer <token>" header, where the token must match the one
+// stored in the job's results Secret.
+type Server struct {
+	// Addr is the address the server listens on.
+	Addr string
+
+	// Reader is used to look up results Secrets. It should not be a cached client,
+	// so the operator doesn't have to watch every Secret in the cluster.
+	Reader client.Reader
+
+	// Store receives accepted uploads.
+	Store *Store
+
+	// MaxBytes caps a single uploaded file. Defaults to DefaultMaxBytes.
+	MaxBytes int64
+}
+
+// Start implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("results")
+
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("starting results receiver", "addr", ln.Addr().String())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Uploads are kept in memory, so only the leader that reconciles scans may accept them.
+func (s *Server) NeedLeaderElection() bool {
+	return true
+}
+
+// Handler returns the HTTP handler serving report uploads.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /reports/{namespace}/{job}/{file}", s.handleUpload)
+	return mux
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, req *http.Request) {
+	job := types.NamespacedName{Namespace: req.PathValue("namespace"), Name: req.PathValue("job")}
+	file := req.PathValue("file")
+	if !fileNameRe.MatchString(file) {
+		http.Error(w, "invalid file name", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := s.authorized(req.Context(), job, req.Header.Get("Authorization"))
+	if err != nil {
+		ctrl.Log.WithName("results").Error(err, "failed to verify upload token", "job", job)
+		http.Error(w, "unable to verify token", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit := s.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxBytes
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, req.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "report too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Store.Put(job, file, data); err != nil {
+		status := http.StatusInsufficientStorage
+		if errors.Is(err, ErrTooManyFiles) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) authorized(ctx context.Context, job types.NamespacedName, header string) (bool, error) {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return false, nil
+	}
+
+	var secret corev1.Secret
+	nn := types.NamespacedName{Namespace: job.Namespace, Name: TokenSecretName(job.Name)}
+	if err := s.Reader.Get(ctx, nn, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	want := secret.Data[TokenKey]
+	if len(want) == 0 {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(token), want) == 1, nil
+}
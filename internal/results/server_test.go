@@ -0,0 +1,197 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newTestServer(t *testing.T, objs ...client.Object) *Server {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+
+	return &Server{
+		Reader: fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build(),
+		Store:  NewStore(),
+	}
+}
+
+func tokenSecret(ns, job, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: TokenSecretName(job), Namespace: ns},
+		Data:       map[string][]byte{TokenKey: []byte(token)},
+	}
+}
+
+func upload(h http.Handler, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTokenSecretName(t *testing.T) {
+	if got := TokenSecretName("zap-scan-abc"); got != "zap-scan-abc-results" {
+		t.Errorf("expected zap-scan-abc-results, got %q", got)
+	}
+}
+
+func TestServer_AcceptsUploadWithValidToken(t *testing.T) {
+	srv := newTestServer(t, tokenSecret("ns1", "job1", "s3cret"))
+
+	rec := upload(srv.Handler(), "/reports/ns1/job1/zap.json", "s3cret", `{"site":[]}`)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	files, ok := srv.Store.Get(types.NamespacedName{Namespace: "ns1", Name: "job1"})
+	if !ok {
+		t.Fatalf("expected upload to be stored")
+	}
+	if string(files["zap.json"]) != `{"site":[]}` {
+		t.Errorf("unexpected stored content %q", files["zap.json"])
+	}
+}
+
+func TestServer_RejectsBadTokens(t *testing.T) {
+	srv := newTestServer(t, tokenSecret("ns1", "job1", "s3cret"), tokenSecret("ns1", "empty", ""))
+
+	cases := []struct {
+		name  string
+		path  string
+		token string
+	}{
+		{"missing token", "/reports/ns1/job1/zap.json", ""},
+		{"wrong token", "/reports/ns1/job1/zap.json", "nope"},
+		{"unknown job", "/reports/ns1/other/zap.json", "s3cret"},
+		{"token of another namespace", "/reports/ns2/job1/zap.json", "s3cret"},
+		{"empty stored token", "/reports/ns1/empty/zap.json", ""},
+	}
+
+	for _, tc := range cases {
+		rec := upload(srv.Handler(), tc.path, tc.token, "{}")
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", tc.name, rec.Code)
+		}
+	}
+
+	if _, ok := srv.Store.Get(types.NamespacedName{Namespace: "ns1", Name: "job1"}); ok {
+		t.Errorf("expected nothing to be stored")
+	}
+}
+
+func TestServer_RejectsInvalidFileName(t *testing.T) {
+	srv := newTestServer(t, tokenSecret("ns1", "job1", "s3cret"))
+
+	for _, name := range []string{".done", "-x"} {
+		rec := upload(srv.Handler(), "/reports/ns1/job1/"+name, "s3cret", "{}")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestServer_RejectsTooLargeUpload(t *testing.T) {
+	srv := newTestServer(t, tokenSecret("ns1", "job1", "s3cret"))
+	srv.MaxBytes = 4
+
+	rec := upload(srv.Handler(), "/reports/ns1/job1/zap.json", "s3cret", "0123456789")
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rec.Code)
+	}
+}
+
+func TestServer_RejectsUploadsOverStoreLimits(t *testing.T) {
+	srv := newTestServer(t, tokenSecret("ns1", "job1", "s3cret"), tokenSecret("ns1", "job2", "s3cret"))
+	srv.Store.MaxFilesPerJob = 2
+	srv.Store.MaxBytes = 10
+
+	for _, name := range []string{"zap.json", "zap.html"} {
+		if rec := upload(srv.Handler(), "/reports/ns1/job1/"+name, "s3cret", "0123"); rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: expected 204, got %d", name, rec.Code)
+		}
+	}
+	if rec := upload(srv.Handler(), "/reports/ns1/job1/zap.xml", "s3cret", "0"); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a third file, got %d", rec.Code)
+	}
+	if rec := upload(srv.Handler(), "/reports/ns1/job2/zap.json", "s3cret", "01234"); rec.Code != http.StatusInsufficientStorage {
+		t.Errorf("expected 507 once the store is full, got %d", rec.Code)
+	}
+}
+
+func TestServer_OnlyAcceptsPost(t *testing.T) {
+	srv := newTestServer(t, tokenSecret("ns1", "job1", "s3cret"))
+
+	req := httptest.NewRequest(http.MethodGet, "/reports/ns1/job1/zap.json", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestServer_SecretLookupError(t *testing.T) {
+	s := runtime.NewScheme()
+	_ = scheme.AddToScheme(s)
+
+	srv := &Server{
+		Reader: fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return errors.New("simulated get error")
+			},
+		}).Build(),
+		Store: NewStore(),
+	}
+
+	rec := upload(srv.Handler(), "/reports/ns1/job1/zap.json", "s3cret", "{}")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestServer_StartAndShutdown(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Addr = "127.0.0.1:0"
+
+	if !srv.NeedLeaderElection() {
+		t.Errorf("expected results receiver to require leader election")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(ctx) }()
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestServer_StartInvalidAddr(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Addr = "not-an-address"
+
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatalf("expected error for invalid address")
+	}
+}
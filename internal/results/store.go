@@ -0,0 +1,104 @@
+package results
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DefaultMaxFilesPerJob caps the files kept per job when Store.MaxFilesPerJob is unset.
+	DefaultMaxFilesPerJob = 16
+
+	// DefaultMaxStoreBytes caps the bytes kept for all jobs when Store.MaxBytes is unset.
+	DefaultMaxStoreBytes int64 = 1 << 30
+)
+
+var (
+	// ErrTooManyFiles is returned by Put when a job already has the most files allowed.
+	ErrTooManyFiles = errors.New("too many report files for the job")
+
+	// ErrStoreFull is returned by Put when the file would take the store over its byte limit.
+	ErrStoreFull = errors.New("report store is full")
+)
+
+// Store keeps report files uploaded by scan pods until the scan controller consumes them.
+// Reports are keyed by the namespaced name of the scan Job and then by file name.
+type Store struct {
+	// MaxFilesPerJob caps the files kept per job. Defaults to DefaultMaxFilesPerJob.
+	MaxFilesPerJob int
+
+	// MaxBytes caps the bytes kept for all jobs together, so uploads can't
+	// exhaust the operator's memory. Defaults to DefaultMaxStoreBytes.
+	MaxBytes int64
+
+	mu      sync.Mutex
+	reports map[types.NamespacedName]map[string][]byte
+	size    int64
+}
+
+func NewStore() *Store {
+	return &Store{reports: map[types.NamespacedName]map[string][]byte{}}
+}
+
+// Put records a report file for a job, replacing any previous upload with the
+// same name. A file over the store's limits isn't kept, and ErrTooManyFiles or
+// ErrStoreFull is returned.
+func (s *Store) Put(job types.NamespacedName, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := s.reports[job]
+	prev, replaced := files[name]
+	maxFiles := s.MaxFilesPerJob
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxFilesPerJob
+	}
+	if !replaced && len(files) >= maxFiles {
+		return ErrTooManyFiles
+	}
+	maxBytes := s.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxStoreBytes
+	}
+	size := s.size - int64(len(prev)) + int64(len(data))
+	if size > maxBytes {
+		return ErrStoreFull
+	}
+
+	if files == nil {
+		files = map[string][]byte{}
+		s.reports[job] = files
+	}
+	files[name] = data
+	s.size = size
+	return nil
+}
+
+// Get returns the files uploaded for a job. The returned map is a copy and safe to modify.
+func (s *Store) Get(job types.NamespacedName) (map[string][]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, ok := s.reports[job]
+	if !ok {
+		return nil, false
+	}
+	out := make(map[string][]byte, len(files))
+	for k, v := range files {
+		out[k] = v
+	}
+	return out, true
+}
+
+// Delete drops every file uploaded for a job.
+func (s *Store) Delete(job types.NamespacedName) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, data := range s.reports[job] {
+		s.size -= int64(len(data))
+	}
+	delete(s.reports, job)
+}
@@ -0,0 +1,80 @@
+package results
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStore_PutGetDelete(t *testing.T) {
+	s := NewStore()
+	job := types.NamespacedName{Namespace: "ns1", Name: "job1"}
+
+	if _, ok := s.Get(job); ok {
+		t.Fatalf("expected empty store")
+	}
+
+	for _, put := range []struct{ name, data string }{{"zap.json", "a"}, {"zap.html", "b"}, {"zap.json", "c"}} {
+		if err := s.Put(job, put.name, []byte(put.data)); err != nil {
+			t.Fatalf("put %s: %v", put.name, err)
+		}
+	}
+
+	files, ok := s.Get(job)
+	if !ok {
+		t.Fatalf("expected files for job")
+	}
+	if len(files) != 2 {
+		t.Errorf("expected 2 files, got %d", len(files))
+	}
+	if string(files["zap.json"]) != "c" {
+		t.Errorf("expected latest upload to win, got %q", files["zap.json"])
+	}
+
+	// The returned map must not alias the store.
+	delete(files, "zap.html")
+	files, _ = s.Get(job)
+	if _, ok := files["zap.html"]; !ok {
+		t.Errorf("expected store to be unaffected by changes to returned map")
+	}
+
+	s.Delete(job)
+	if _, ok := s.Get(job); ok {
+		t.Errorf("expected files to be deleted")
+	}
+}
+
+func TestStore_Limits(t *testing.T) {
+	s := NewStore()
+	s.MaxFilesPerJob = 2
+	s.MaxBytes = 10
+	job1 := types.NamespacedName{Namespace: "ns1", Name: "job1"}
+	job2 := types.NamespacedName{Namespace: "ns1", Name: "job2"}
+
+	if err := s.Put(job1, "zap.json", []byte("0123")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := s.Put(job1, "zap.html", []byte("0123")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := s.Put(job1, "zap.xml", []byte("0")); !errors.Is(err, ErrTooManyFiles) {
+		t.Errorf("expected ErrTooManyFiles, got %v", err)
+	}
+	// Replacing a file neither adds a file nor counts the old bytes twice.
+	if err := s.Put(job1, "zap.json", []byte("012345")); err != nil {
+		t.Errorf("expected a replacement within the limits to be kept, got %v", err)
+	}
+	if err := s.Put(job2, "zap.json", []byte("0")); !errors.Is(err, ErrStoreFull) {
+		t.Errorf("expected ErrStoreFull, got %v", err)
+	}
+	if _, ok := s.Get(job2); ok {
+		t.Errorf("expected the rejected upload not to be kept")
+	}
+
+	// Consumed reports free their bytes.
+	s.Delete(job1)
+	if err := s.Put(job2, "zap.json", []byte("0123456789")); err != nil {
+		t.Errorf("expected the store to have room again, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+// Package risk orders ZAP's normalized risk levels.
+package risk
+
+// Rank orders ZAP risk levels from informational (0) to high (3). Unknown
+// risks rank below informational.
+func Rank(risk string) int {
+	switch risk {
+	case "informational":
+		return 0
+	case "low":
+		return 1
+	case "medium":
+		return 2
+	case "high":
+		return 3
+	default:
+		return -1
+	}
+}
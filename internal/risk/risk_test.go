@@ -0,0 +1,15 @@
+package risk
+
+import "testing"
+
+func TestRank(t *testing.T) {
+	order := []string{"", "informational", "low", "medium", "high"}
+	for i := 1; i < len(order); i++ {
+		if Rank(order[i-1]) >= Rank(order[i]) {
+			t.Errorf("expected %q to rank below %q", order[i-1], order[i])
+		}
+	}
+	if Rank("critical") != Rank("") {
+		t.Errorf("expected unknown risks to rank alike")
+	}
+}
@@ -0,0 +1,237 @@
+// Package sarif converts normalized ZAP findings into SARIF 2.1.0 logs.
+//
+// Every ZAP rule becomes a SARIF rule identified by its plugin ID and tagged
+// with its CWE, and every instance of an alert becomes a result located at
+// the affected URL.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/risk"
+)
+
+const (
+	// Version is the SARIF version written by Convert.
+	Version = "2.1.0"
+
+	// Schema is the JSON schema of SARIF 2.1.0 logs.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Log is the root object of a SARIF file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a single invocation of ZAP.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes ZAP and the rules it ran.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the tool component that produced the results.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes a ZAP plugin.
+type Rule struct {
+	ID                   string         `json:"id"`
+	Name                 string         `json:"name,omitempty"`
+	ShortDescription     Message        `json:"shortDescription"`
+	HelpURI              string         `json:"helpUri,omitempty"`
+	DefaultConfiguration Configuration  `json:"defaultConfiguration"`
+	Properties           RuleProperties `json:"properties"`
+}
+
+// Configuration holds a rule's default severity.
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+// RuleProperties carries tags and the severity score code-scanning tools sort by.
+type RuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+// Result is one instance of an alert.
+type Result struct {
+	RuleID     string           `json:"ruleId"`
+	RuleIndex  int              `json:"ruleIndex"`
+	Level      string           `json:"level"`
+	Message    Message          `json:"message"`
+	Locations  []Location       `json:"locations"`
+	Properties ResultProperties `json:"properties"`
+}
+
+// ResultProperties keeps the ZAP classification of a result.
+type ResultProperties struct {
+	Risk       string `json:"risk"`
+	Confidence string `json:"confidence"`
+	Method     string `json:"method,omitempty"`
+	Param      string `json:"param,omitempty"`
+	Evidence   string `json:"evidence,omitempty"`
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location points at the URL an alert was raised for.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation wraps the artifact a result refers to.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+}
+
+// ArtifactLocation is the URL of the affected resource.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Level maps a normalized ZAP risk to a SARIF level.
+func Level(risk string) string {
+	switch risk {
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	case "low":
+		return "note"
+	default:
+		return "none"
+	}
+}
+
+func securitySeverity(risk string) string {
+	switch risk {
+	case "high":
+		return "8.0"
+	case "medium":
+		return "5.0"
+	case "low":
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
+
+// Convert builds a SARIF log from the findings of a scan of target.
+// Findings without instances are reported once, located at target.
+func Convert(target string, findings []zapv1alpha1.Finding) *Log {
+	driver := Driver{Name: "ZAP", InformationURI: "https://www.zaproxy.org/", Rules: []Rule{}}
+	results := []Result{}
+	ruleIndex := map[string]int{}
+
+	for _, f := range findings {
+		idx, ok := ruleIndex[f.PluginID]
+		if !ok {
+			idx = len(driver.Rules)
+			ruleIndex[f.PluginID] = idx
+			driver.Rules = append(driver.Rules, newRule(f))
+		} else if risk.Rank(f.Risk) > risk.Rank(ruleRisk(driver.Rules[idx])) {
+			// A rule's default level follows its most severe alert.
+			driver.Rules[idx].DefaultConfiguration.Level = Level(f.Risk)
+			driver.Rules[idx].Properties.SecuritySeverity = securitySeverity(f.Risk)
+		}
+
+		instances := f.Instances
+		if len(instances) == 0 {
+			instances = []zapv1alpha1.FindingInstance{{URL: target}}
+		}
+		for _, in := range instances {
+			results = append(results, Result{
+				RuleID:    f.PluginID,
+				RuleIndex: idx,
+				Level:     Level(f.Risk),
+				Message:   Message{Text: message(f, in)},
+				Locations: []Location{{PhysicalLocation: PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: in.URL}}}},
+				Properties: ResultProperties{
+					Risk:       f.Risk,
+					Confidence: f.Confidence,
+					Method:     in.Method,
+					Param:      in.Param,
+					Evidence:   in.Evidence,
+				},
+			})
+		}
+	}
+
+	return &Log{
+		Schema:  Schema,
+		Version: Version,
+		Runs:    []Run{{Tool: Tool{Driver: driver}, Results: results}},
+	}
+}
+
+// Marshal converts findings and encodes the SARIF log as indented JSON.
+func Marshal(target string, findings []zapv1alpha1.Finding) ([]byte, error) {
+	return json.MarshalIndent(Convert(target, findings), "", "  ")
+}
+
+func newRule(f zapv1alpha1.Finding) Rule {
+	tags := []string{"security"}
+	if f.CWEID > 0 {
+		tags = append(tags, fmt.Sprintf("external/cwe/cwe-%d", f.CWEID))
+	}
+	if f.WASCID > 0 {
+		tags = append(tags, fmt.Sprintf("external/wasc/wasc-%d", f.WASCID))
+	}
+	return Rule{
+		ID:                   f.PluginID,
+		Name:                 ruleName(f.Name),
+		ShortDescription:     Message{Text: f.Name},
+		HelpURI:              fmt.Sprintf("https://www.zaproxy.org/docs/alerts/%s/", f.PluginID),
+		DefaultConfiguration: Configuration{Level: Level(f.Risk)},
+		Properties:           RuleProperties{Tags: tags, SecuritySeverity: securitySeverity(f.Risk)},
+	}
+}
+
+func message(f zapv1alpha1.Finding, in zapv1alpha1.FindingInstance) string {
+	if in.Param != "" {
+		return fmt.Sprintf("%s (parameter %q)", f.Name, in.Param)
+	}
+	return f.Name
+}
+
+// ruleName turns an alert title into the PascalCase identifier SARIF recommends.
+func ruleName(title string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(title, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+func ruleRisk(r Rule) string {
+	switch r.DefaultConfiguration.Level {
+	case "error":
+		return "high"
+	case "warning":
+		return "medium"
+	case "note":
+		return "low"
+	default:
+		return "informational"
+	}
+}
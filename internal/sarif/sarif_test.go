@@ -0,0 +1,94 @@
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+)
+
+func TestConvert(t *testing.T) {
+	findings := []zapv1alpha1.Finding{
+		{
+			PluginID: "10038", Name: "Content Security Policy (CSP) Header Not Set", Risk: "medium", Confidence: "high",
+			CWEID: 693, WASCID: 15, Count: 2,
+			Instances: []zapv1alpha1.FindingInstance{
+				{URL: "https://example.com/", Method: "GET"},
+				{URL: "https://example.com/login", Method: "POST", Param: "user", Evidence: "<form>"},
+			},
+		},
+		{PluginID: "10096", Name: "Timestamp Disclosure", Risk: "informational", Confidence: "low"},
+		// Same plugin on another site with a higher risk raises the rule's default level.
+		{PluginID: "10038", Name: "Content Security Policy (CSP) Header Not Set", Risk: "high", Confidence: "medium",
+			Instances: []zapv1alpha1.FindingInstance{{URL: "https://api.example.com/"}}},
+	}
+
+	log := Convert("https://example.com", findings)
+	if log.Version != Version || log.Schema != Schema || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log header %+v", log)
+	}
+	run := log.Runs[0]
+
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("expected one rule per plugin, got %d", len(run.Tool.Driver.Rules))
+	}
+	csp := run.Tool.Driver.Rules[0]
+	if csp.ID != "10038" || csp.Name != "ContentSecurityPolicyCSPHeaderNotSet" || csp.HelpURI != "https://www.zaproxy.org/docs/alerts/10038/" {
+		t.Errorf("unexpected rule %+v", csp)
+	}
+	if len(csp.Properties.Tags) != 3 || csp.Properties.Tags[1] != "external/cwe/cwe-693" {
+		t.Errorf("expected CWE tag, got %v", csp.Properties.Tags)
+	}
+	if csp.DefaultConfiguration.Level != "error" || csp.Properties.SecuritySeverity != "8.0" {
+		t.Errorf("expected rule level to follow the most severe alert, got %+v", csp)
+	}
+
+	if len(run.Results) != 4 {
+		t.Fatalf("expected one result per instance, got %d", len(run.Results))
+	}
+	login := run.Results[1]
+	if login.RuleID != "10038" || login.RuleIndex != 0 || login.Level != "warning" {
+		t.Errorf("unexpected result %+v", login)
+	}
+	if login.Locations[0].PhysicalLocation.ArtifactLocation.URI != "https://example.com/login" {
+		t.Errorf("unexpected location %+v", login.Locations)
+	}
+	if login.Properties.Param != "user" || login.Message.Text != `Content Security Policy (CSP) Header Not Set (parameter "user")` {
+		t.Errorf("unexpected result details %+v", login)
+	}
+
+	ts := run.Results[2]
+	if ts.Level != "none" || ts.RuleIndex != 1 || ts.Locations[0].PhysicalLocation.ArtifactLocation.URI != "https://example.com" {
+		t.Errorf("expected finding without instances to be located at the target, got %+v", ts)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cases := map[string]string{"high": "error", "medium": "warning", "low": "note", "informational": "none", "": "none"}
+	for risk, want := range cases {
+		if got := Level(risk); got != want {
+			t.Errorf("%q: expected %q, got %q", risk, want, got)
+		}
+	}
+}
+
+func TestMarshal_EmptyFindings(t *testing.T) {
+	data, err := Marshal("https://example.com", nil)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	runs := raw["runs"].([]any)
+	run := runs[0].(map[string]any)
+	// Empty arrays, not null, keep strict SARIF consumers happy.
+	if results, ok := run["results"].([]any); !ok || len(results) != 0 {
+		t.Errorf("expected empty results array, got %v", run["results"])
+	}
+	if raw["$schema"] != Schema {
+		t.Errorf("expected $schema to be set, got %v", raw["$schema"])
+	}
+}
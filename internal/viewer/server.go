@@ -0,0 +1,362 @@
+// Package viewer serves stored scan reports over HTTP.
+//
+// Callers authenticate with a Kubernetes bearer token, which is checked with a
+// TokenReview. A caller only sees the scans of namespaces where a
+// SubjectAccessReview allows them to get ZapScans.
+package viewer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"mime"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+)
+
+// DefaultMaxBytes caps the unpacked reports of a scan when Server.MaxBytes is unset.
+const DefaultMaxBytes int64 = 64 << 20
+
+// Server is a read-only HTTP server listing ZapScans and serving their stored reports.
+//
+//	GET /                                   HTML index of visible scans
+//	GET /api/scans                          the same as JSON
+//	GET /scans/{namespace}/{name}/{file}    a stored report file, e.g. zap.html
+type Server struct {
+	// Addr is the address the server listens on.
+	Addr string
+
+	// Client lists ZapScans, reads stored reports and creates the
+	// TokenReviews and SubjectAccessReviews used to authorize callers.
+	Client client.Client
+
+	// MaxBytes caps the unpacked reports of a single scan. Defaults to DefaultMaxBytes.
+	MaxBytes int64
+}
+
+// scanSummary is the JSON representation of a scan in /api/scans.
+type scanSummary struct {
+	Namespace    string                  `json:"namespace"`
+	Name         string                  `json:"name"`
+	Target       string                  `json:"target"`
+	Phase        string                  `json:"phase,omitempty"`
+	FinishedAt   string                  `json:"finishedAt,omitempty"`
+	AlertsFound  int64                   `json:"alertsFound"`
+	AlertsByRisk *zapv1alpha1.RiskCounts `json:"alertsByRisk,omitempty"`
+	Files        []string                `json:"files,omitempty"`
+	ReportURLs   []string                `json:"reportURLs,omitempty"`
+}
+
+// Start implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	log := ctrl.Log.WithName("viewer")
+
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("starting report viewer", "addr", ln.Addr().String())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// The viewer only reads, so every replica can serve it.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the HTTP handler serving the viewer.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /api/scans", s.handleList)
+	mux.HandleFunc("GET /scans/{namespace}/{name}/{file}", s.handleFile)
+	return mux
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, req *http.Request) {
+	scans, ok := s.visibleScans(w, req)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, scans); err != nil {
+		ctrl.Log.WithName("viewer").Error(err, "failed to render index")
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, req *http.Request) {
+	scans, ok := s.visibleScans(w, req)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(scans)
+}
+
+// visibleScans returns the scans the caller may see. It writes an error
+// response and returns false if the caller can't be authorized.
+func (s *Server) visibleScans(w http.ResponseWriter, req *http.Request) ([]scanSummary, bool) {
+	ctx := req.Context()
+	user, ok := s.authenticate(w, req)
+	if !ok {
+		return nil, false
+	}
+
+	var list zapv1alpha1.ZapScanList
+	if err := s.Client.List(ctx, &list); err != nil {
+		s.serverError(w, "failed to list scans", err)
+		return nil, false
+	}
+
+	allowed := map[string]bool{}
+	scans := []scanSummary{}
+	for i := range list.Items {
+		scan := &list.Items[i]
+		ns := scan.Namespace
+		if _, checked := allowed[ns]; !checked {
+			ok, err := s.canGetScans(ctx, user, ns)
+			if err != nil {
+				s.serverError(w, "failed to authorize request", err)
+				return nil, false
+			}
+			allowed[ns] = ok
+		}
+		if allowed[ns] {
+			scans = append(scans, summarize(scan))
+		}
+	}
+	sort.Slice(scans, func(i, j int) bool {
+		if scans[i].Namespace != scans[j].Namespace {
+			return scans[i].Namespace < scans[j].Namespace
+		}
+		return scans[i].Name < scans[j].Name
+	})
+	return scans, true
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	nn := types.NamespacedName{Namespace: req.PathValue("namespace"), Name: req.PathValue("name")}
+	file := req.PathValue("file")
+
+	user, ok := s.authenticate(w, req)
+	if !ok {
+		return
+	}
+	allowed, err := s.canGetScans(ctx, user, nn.Namespace)
+	if err != nil {
+		s.serverError(w, "failed to authorize request", err)
+		return
+	}
+	if !allowed {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var scan zapv1alpha1.ZapScan
+	if err := s.Client.Get(ctx, nn, &scan); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, "scan not found", http.StatusNotFound)
+			return
+		}
+		s.serverError(w, "failed to get scan", err)
+		return
+	}
+
+	ref := scan.Status.ReportRef
+	if ref == nil || !storedInObjects(ref) {
+		http.Error(w, "no reports stored for this scan", http.StatusNotFound)
+		return
+	}
+
+	// Reports in Secrets may hold what the Secrets were chosen to protect, so
+	// reading them takes the same access as reading the Secrets.
+	if ref.Type == zapv1alpha1.ReportStorageSecret {
+		allowed, err := s.canGetSecrets(ctx, user, nn.Namespace, ref.Names)
+		if err != nil {
+			s.serverError(w, "failed to authorize request", err)
+			return
+		}
+		if !allowed {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	maxBytes := s.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	files, err := reportstore.Load(ctx, s.Client, nn.Namespace, ref, maxBytes)
+	if err != nil {
+		s.serverError(w, "failed to load reports", err)
+		return
+	}
+	data, ok := files[file]
+	if !ok {
+		http.Error(w, "report not found", http.StatusNotFound)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Reports embed scripts and content from the scanned site; never run them with the viewer's origin.
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	_, _ = w.Write(data)
+}
+
+// authenticate reviews the caller's bearer token. It writes an error response
+// and returns false if the token is missing or invalid.
+func (s *Server) authenticate(w http.ResponseWriter, req *http.Request) (authenticationv1.UserInfo, bool) {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return authenticationv1.UserInfo{}, false
+	}
+
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := s.Client.Create(req.Context(), review); err != nil {
+		s.serverError(w, "failed to review token", err)
+		return authenticationv1.UserInfo{}, false
+	}
+	if !review.Status.Authenticated {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return authenticationv1.UserInfo{}, false
+	}
+	return review.Status.User, true
+}
+
+// canGetScans reports whether user may get ZapScans in namespace.
+func (s *Server) canGetScans(ctx context.Context, user authenticationv1.UserInfo, namespace string) (bool, error) {
+	return s.can(ctx, user, &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "get",
+		Group:     zapv1alpha1.GroupVersion.Group,
+		Resource:  "zapscans",
+	})
+}
+
+// canGetSecrets reports whether user may get every Secret in names.
+func (s *Server) canGetSecrets(ctx context.Context, user authenticationv1.UserInfo, namespace string, names []string) (bool, error) {
+	for _, name := range names {
+		ok, err := s.can(ctx, user, &authorizationv1.ResourceAttributes{Namespace: namespace, Verb: "get", Resource: "secrets", Name: name})
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// can reviews whether user may act on the resource described by attrs.
+func (s *Server) can(ctx context.Context, user authenticationv1.UserInfo, attrs *authorizationv1.ResourceAttributes) (bool, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			UID:                user.UID,
+			Groups:             user.Groups,
+			Extra:              extra,
+			ResourceAttributes: attrs,
+		},
+	}
+	if err := s.Client.Create(ctx, review); err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+func (s *Server) serverError(w http.ResponseWriter, msg string, err error) {
+	ctrl.Log.WithName("viewer").Error(err, msg)
+	http.Error(w, msg, http.StatusInternalServerError)
+}
+
+func storedInObjects(ref *zapv1alpha1.ReportRef) bool {
+	return ref.Type == zapv1alpha1.ReportStorageConfigMap || ref.Type == zapv1alpha1.ReportStorageSecret
+}
+
+func summarize(scan *zapv1alpha1.ZapScan) scanSummary {
+	out := scanSummary{
+		Namespace:    scan.Namespace,
+		Name:         scan.Name,
+		Target:       scan.Spec.Target,
+		Phase:        scan.Status.Phase,
+		AlertsFound:  scan.Status.AlertsFound,
+		AlertsByRisk: scan.Status.AlertsByRisk,
+		ReportURLs:   scan.Status.ReportURLs,
+	}
+	if scan.Status.FinishedAt != nil {
+		out.FinishedAt = scan.Status.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	if ref := scan.Status.ReportRef; ref != nil && storedInObjects(ref) {
+		out.Files = ref.Files
+	}
+	return out
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>ZAP scan reports</title></head>
+<body>
+<h1>ZAP scan reports</h1>
+{{- if not . }}
+<p>No scans visible.</p>
+{{- else }}
+<table>
+<tr><th>Namespace</th><th>Scan</th><th>Target</th><th>Phase</th><th>Finished</th><th>High</th><th>Medium</th><th>Reports</th></tr>
+{{- range . }}
+<tr>
+<td>{{ .Namespace }}</td><td>{{ .Name }}</td><td>{{ .Target }}</td><td>{{ .Phase }}</td><td>{{ .FinishedAt }}</td>
+<td>{{ with .AlertsByRisk }}{{ .High }}{{ end }}</td><td>{{ with .AlertsByRisk }}{{ .Medium }}{{ end }}</td>
+<td>
+{{- $scan := . }}
+{{- range .Files }} <a href="scans/{{ $scan.Namespace }}/{{ $scan.Name }}/{{ . }}">{{ . }}</a>{{ end }}
+{{- range .ReportURLs }} <a href="{{ . }}">{{ . }}</a>{{ end }}
+</td>
+</tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
@@ -0,0 +1,222 @@
+package viewer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	zapv1alpha1 "github.com/NCCloud/zap-operator/api/v1alpha1"
+	"github.com/NCCloud/zap-operator/internal/reportstore"
+)
+
+// reviews fakes the API server: the token "alice" authenticates as alice,
+// who may get ZapScans in team-a only, and the Secrets of its scan "shared".
+var reviews = interceptor.Funcs{
+	Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+		switch review := obj.(type) {
+		case *authenticationv1.TokenReview:
+			if review.Spec.Token == "alice" {
+				review.Status.Authenticated = true
+				review.Status.User = authenticationv1.UserInfo{Username: "alice", Groups: []string{"devs"}}
+			}
+			return nil
+		case *authorizationv1.SubjectAccessReview:
+			attrs := review.Spec.ResourceAttributes
+			review.Status.Allowed = review.Spec.User == "alice" && attrs.Namespace == "team-a" && attrs.Verb == "get" &&
+				((attrs.Group == "spaceship.com" && attrs.Resource == "zapscans") ||
+					(attrs.Group == "" && attrs.Resource == "secrets" && attrs.Name == reportstore.ChunkName("shared", 0)))
+			return nil
+		}
+		return c.Create(ctx, obj, opts...)
+	},
+}
+
+func newTestServer(t *testing.T, objs ...client.Object) *Server {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("add core scheme: %v", err)
+	}
+	if err := zapv1alpha1.AddToScheme(s); err != nil {
+		t.Fatalf("add zap scheme: %v", err)
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(s).
+		WithStatusSubresource(&zapv1alpha1.ZapScan{}).
+		WithObjects(objs...).
+		WithInterceptorFuncs(reviews).
+		Build()
+
+	// Store a report for team-a/web the way the scan reconciler does.
+	web := &zapv1alpha1.ZapScan{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "web"}, web); err == nil {
+		ref, err := reportstore.Save(context.Background(), c, s, web, zapv1alpha1.ReportStorageConfigMap, map[string][]byte{
+			"zap.html": []byte("<html>report</html>"),
+			"zap.json": []byte(`{"site":[]}`),
+		})
+		if err != nil {
+			t.Fatalf("save reports: %v", err)
+		}
+		web.Status.ReportRef = ref
+		if err := c.Status().Update(context.Background(), web); err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+	}
+
+	return &Server{Client: c}
+}
+
+func scan(ns, name string) *zapv1alpha1.ZapScan {
+	return &zapv1alpha1.ZapScan{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec:       zapv1alpha1.ZapScanSpec{Target: "https://" + name + ".example.com"},
+	}
+}
+
+func get(h http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_RequiresAuthentication(t *testing.T) {
+	h := newTestServer(t, scan("team-a", "web")).Handler()
+
+	for _, path := range []string{"/", "/api/scans", "/scans/team-a/web/zap.html"} {
+		if rec := get(h, path, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s without token: expected 401, got %d", path, rec.Code)
+		}
+		if rec := get(h, path, "bogus"); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s with invalid token: expected 401, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestServer_ListsOnlyAuthorizedNamespaces(t *testing.T) {
+	h := newTestServer(t, scan("team-a", "web"), scan("team-a", "api"), scan("team-b", "admin")).Handler()
+
+	rec := get(h, "/api/scans", "alice")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var scans []scanSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &scans); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(scans) != 2 || scans[0].Name != "api" || scans[1].Name != "web" {
+		t.Fatalf("expected team-a scans only, got %+v", scans)
+	}
+	if len(scans[1].Files) != 2 || scans[1].Files[0] != "zap.html" {
+		t.Errorf("expected stored files to be listed, got %v", scans[1].Files)
+	}
+
+	rec = get(h, "/", "alice")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="scans/team-a/web/zap.html"`) {
+		t.Errorf("expected a link to the HTML report, got %s", body)
+	}
+	if strings.Contains(body, "admin") {
+		t.Errorf("expected team-b scans to be hidden, got %s", body)
+	}
+}
+
+func TestServer_ServesStoredReport(t *testing.T) {
+	h := newTestServer(t, scan("team-a", "web")).Handler()
+
+	rec := get(h, "/scans/team-a/web/zap.html", "alice")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Body.String() != "<html>report</html>" {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected an HTML content type, got %q", ct)
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); csp != "sandbox" {
+		t.Errorf("expected reports to be sandboxed, got %q", csp)
+	}
+
+	rec = get(h, "/scans/team-a/web/zap.json", "alice")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON report, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestServer_SecretReportsNeedSecretAccess(t *testing.T) {
+	srv := newTestServer(t, scan("team-a", "shared"), scan("team-a", "private"))
+	for _, name := range []string{"shared", "private"} {
+		sc := &zapv1alpha1.ZapScan{}
+		if err := srv.Client.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: name}, sc); err != nil {
+			t.Fatalf("get scan: %v", err)
+		}
+		ref, err := reportstore.Save(context.Background(), srv.Client, srv.Client.Scheme(), sc, zapv1alpha1.ReportStorageSecret, map[string][]byte{"zap.html": []byte("<html>report</html>")})
+		if err != nil {
+			t.Fatalf("save reports: %v", err)
+		}
+		sc.Status.ReportRef = ref
+		if err := srv.Client.Status().Update(context.Background(), sc); err != nil {
+			t.Fatalf("update status: %v", err)
+		}
+	}
+	h := srv.Handler()
+
+	if rec := get(h, "/scans/team-a/shared/zap.html", "alice"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with access to the Secrets, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := get(h, "/scans/team-a/private/zap.html", "alice"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without access to the Secrets, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_FileErrors(t *testing.T) {
+	pvc := scan("team-a", "pvc")
+	pvc.Status.ReportRef = &zapv1alpha1.ReportRef{Type: zapv1alpha1.ReportStoragePVC, ClaimName: "reports", Files: []string{"zap.html"}}
+	h := newTestServer(t, scan("team-a", "web"), scan("team-a", "pending"), pvc, scan("team-b", "admin")).Handler()
+
+	cases := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"forbidden namespace", "/scans/team-b/admin/zap.html", http.StatusForbidden},
+		{"missing scan", "/scans/team-a/nope/zap.html", http.StatusNotFound},
+		{"unknown file", "/scans/team-a/web/secret.txt", http.StatusNotFound},
+		{"nothing stored", "/scans/team-a/pending/zap.html", http.StatusNotFound},
+		{"stored on a volume", "/scans/team-a/pvc/zap.html", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := get(h, tc.path, "alice"); rec.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_NeedLeaderElection(t *testing.T) {
+	if (&Server{}).NeedLeaderElection() {
+		t.Errorf("expected the viewer to run on every replica")
+	}
+}